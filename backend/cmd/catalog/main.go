@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"ecommerce-backend/config"
+	"ecommerce-backend/controllers"
+	"ecommerce-backend/database"
+)
+
+const usage = `Usage:
+  catalog import -file <path> [-type products|categories|all] [-format csv|json] [-dry-run]
+  catalog export [-type products|categories|all] [-format csv|json] [-out <path>]
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "import":
+		os.Exit(runImport(os.Args[2:]))
+	case "export":
+		os.Exit(runExport(os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "CSV or JSON file to import")
+	catalogType := flags.String("type", controllers.CatalogTypeProducts, "products, categories or all (JSON only)")
+	format := flags.String("format", "", "csv or json (defaults to the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing")
+	flags.Parse(args)
+
+	if *file == "" {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	if err := controllers.ValidateCatalogOptions(*format, *catalogType); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	input, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to open file:", err)
+		return 1
+	}
+	defer input.Close()
+
+	config.LoadConfig()
+	database.ConnectDB()
+
+	report, err := controllers.RunCatalogImport(*format, *catalogType, input, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Import failed:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	catalogType := flags.String("type", controllers.CatalogTypeProducts, "products, categories or all (JSON only)")
+	format := flags.String("format", controllers.CatalogFormatCSV, "csv or json")
+	out := flags.String("out", "", "output file (defaults to stdout)")
+	flags.Parse(args)
+
+	if err := controllers.ValidateCatalogOptions(*format, *catalogType); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var output io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to create file:", err)
+			return 1
+		}
+		defer file.Close()
+		output = file
+	}
+
+	config.LoadConfig()
+	database.ConnectDB()
+
+	if err := controllers.WriteCatalogExport(*format, *catalogType, output); err != nil {
+		fmt.Fprintln(os.Stderr, "Export failed:", err)
+		return 1
+	}
+	return 0
+}
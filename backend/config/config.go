@@ -34,4 +34,13 @@ func LoadConfig() {
 	if os.Getenv("DB_NAME") == "" {
 		os.Setenv("DB_NAME", "ecommerce_db")
 	}
+	if os.Getenv("FRONTEND_URL") == "" {
+		os.Setenv("FRONTEND_URL", "http://localhost:3000")
+	}
+	if os.Getenv("STORE_NAME") == "" {
+		os.Setenv("STORE_NAME", "E-Commerce Store")
+	}
+	if os.Getenv("SMTP_PORT") == "" {
+		os.Setenv("SMTP_PORT", "587")
+	}
 }
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RecoverCartRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// RecoverCart handles the link in an abandoned cart reminder. Items from the
+// snapshot that are no longer in the cart are added back, capped at the
+// available stock. Carts belonging to a user can only be restored by that
+// user.
+func RecoverCart(c *gin.Context) {
+	var req RecoverCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	abandonedCartID, err := utils.ValidateRecoveryToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired recovery link"})
+		return
+	}
+
+	var event models.AbandonedCart
+	if err := database.DB.First(&event, abandonedCartID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+		return
+	}
+
+	var owner cartOwner
+	if event.UserID != nil {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Please log in to restore your cart"})
+			return
+		}
+		if userID.(uint) != *event.UserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This cart belongs to another account"})
+			return
+		}
+		owner = cartOwner{UserID: *event.UserID}
+	} else {
+		var guestCart models.GuestCart
+		if err := database.DB.Where("id = ? AND expires_at > ?", *event.GuestCartID, time.Now()).First(&guestCart).Error; err != nil {
+			c.JSON(http.StatusGone, gin.H{"error": "This cart has expired"})
+			return
+		}
+		token, err := utils.GenerateCartToken(guestCart.ID, guestCart.ExpiresAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate cart token"})
+			return
+		}
+		c.Header("X-Cart-Token", token)
+		owner = cartOwner{GuestCartID: guestCart.ID}
+	}
+
+	restored := 0
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, line := range event.Items {
+			var count int64
+			owner.scope(tx.Model(&models.Cart{})).Where("product_id = ?", line.ProductID).Count(&count)
+			if count > 0 {
+				continue
+			}
+
+			var product models.Product
+			if err := tx.Scopes(models.VisibleProducts(time.Now())).First(&product, line.ProductID).Error; err != nil || product.Stock <= 0 {
+				continue
+			}
+			quantity := line.Quantity
+			if quantity > product.Stock {
+				quantity = product.Stock
+			}
+
+			cartItem := owner.newItem(product.ID, quantity)
+			cartItem.PriceAtAdd = line.UnitPrice
+			if err := tx.Create(&cartItem).Error; err != nil {
+				return err
+			}
+			restored++
+		}
+
+		if event.RestoredAt == nil {
+			return tx.Model(&event).Update("restored_at", time.Now()).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Cart restored successfully",
+		"restored_items": restored,
+	})
+}
+
+func GetAbandonedCarts(c *gin.Context) {
+	// Get query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.Query("status")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.AbandonedCart{}).Preload("User")
+	switch status {
+	case "recovered":
+		query = query.Where("recovered_at IS NOT NULL")
+	case "open":
+		query = query.Where("recovered_at IS NULL")
+	}
+
+	// Get total count
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var carts []models.AbandonedCart
+	if err := query.Order("detected_at DESC").Offset(offset).Limit(limit).Find(&carts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch abandoned carts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"abandoned_carts": carts,
+		"total":           totalCount,
+		"page":            page,
+		"limit":           limit,
+		"total_pages":     (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetAbandonedCartReport summarises carts detected between from and to
+// (YYYY-MM-DD, defaulting to the last 30 days) and how many were recovered.
+func GetAbandonedCartReport(c *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	var report struct {
+		Detected         int64   `json:"detected"`
+		Reminded         int64   `json:"reminded"`
+		Restored         int64   `json:"restored"`
+		Recovered        int64   `json:"recovered"`
+		AbandonedValue   float64 `json:"abandoned_value"`
+		RecoveredRevenue float64 `json:"recovered_revenue"`
+	}
+	err := database.DB.Model(&models.AbandonedCart{}).
+		Select("COUNT(*) AS detected, "+
+			"COUNT(reminder_sent_at) AS reminded, "+
+			"COUNT(restored_at) AS restored, "+
+			"COUNT(recovered_at) AS recovered, "+
+			"COALESCE(SUM(cart_value), 0) AS abandoned_value").
+		Where("detected_at >= ? AND detected_at < ?", from, to).
+		Scan(&report).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	database.DB.Model(&models.Order{}).
+		Joins("JOIN abandoned_carts ON abandoned_carts.order_id = orders.id").
+		Where("abandoned_carts.detected_at >= ? AND abandoned_carts.detected_at < ?", from, to).
+		Select("COALESCE(SUM(orders.total_amount), 0)").Scan(&report.RecoveredRevenue)
+
+	var recoveryRate, reminderRecoveryRate float64
+	if report.Detected > 0 {
+		recoveryRate = float64(report.Recovered) / float64(report.Detected)
+	}
+	if report.Reminded > 0 {
+		var remindedRecovered int64
+		database.DB.Model(&models.AbandonedCart{}).
+			Where("detected_at >= ? AND detected_at < ? AND reminder_sent_at IS NOT NULL AND recovered_at IS NOT NULL", from, to).
+			Count(&remindedRecovered)
+		reminderRecoveryRate = float64(remindedRecovered) / float64(report.Reminded)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":                   from.Format("2006-01-02"),
+		"to":                     to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"report":                 report,
+		"recovery_rate":          recoveryRate,
+		"reminder_recovery_rate": reminderRecoveryRate,
+	})
+}
+
+// markCartRecovered attributes an order to the owner's open abandoned cart
+// records, if any.
+func markCartRecovered(owner cartOwner, order models.Order) {
+	owner.scope(database.DB.Model(&models.AbandonedCart{})).Where("recovered_at IS NULL").
+		Updates(map[string]interface{}{
+			"recovered_at": time.Now(),
+			"order_id":     order.ID,
+		})
+}
@@ -0,0 +1,433 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateAttributeRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Code       string   `json:"code"`
+	Type       string   `json:"type" binding:"required"`
+	Options    []string `json:"options"`
+	Unit       string   `json:"unit"`
+	Required   bool     `json:"required"`
+	Filterable *bool    `json:"filterable"`
+	SortOrder  int      `json:"sort_order"`
+}
+
+type UpdateAttributeRequest struct {
+	Name       string   `json:"name"`
+	Options    []string `json:"options"`
+	Unit       string   `json:"unit"`
+	Required   *bool    `json:"required"`
+	Filterable *bool    `json:"filterable"`
+	SortOrder  *int     `json:"sort_order"`
+}
+
+func GetCategoryAttributes(c *gin.Context) {
+	category, err := findCategoryByIDOrSlug(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	attributes, err := applicableAttributes(database.DB, category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attributes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attributes": attributes,
+	})
+}
+
+func CreateCategoryAttribute(c *gin.Context) {
+	id := c.Param("id")
+	categoryID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req CreateAttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var category models.Category
+	if err := database.DB.First(&category, categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	if !models.IsValidAttributeType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attribute type"})
+		return
+	}
+	if req.Type == models.AttributeTypeEnum && len(req.Options) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Enum attributes require options"})
+		return
+	}
+
+	code := utils.Slugify(req.Code)
+	if code == "" {
+		code = utils.Slugify(req.Name)
+	}
+	code = strings.ReplaceAll(code, "-", "_")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attribute code"})
+		return
+	}
+
+	// Codes must be unique across the category's ancestors and descendants
+	if attributeCodeTaken(category, code, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attribute code already exists in this category tree"})
+		return
+	}
+
+	attribute := models.AttributeDefinition{
+		CategoryID: category.ID,
+		Name:       req.Name,
+		Code:       code,
+		Type:       req.Type,
+		Unit:       req.Unit,
+		Required:   req.Required,
+		Filterable: true,
+		SortOrder:  req.SortOrder,
+	}
+	if req.Type == models.AttributeTypeEnum {
+		attribute.Options = req.Options
+	}
+	if req.Filterable != nil {
+		attribute.Filterable = *req.Filterable
+	}
+
+	if err := database.DB.Create(&attribute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attribute"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Attribute created successfully",
+		"attribute": attribute,
+	})
+}
+
+func UpdateCategoryAttribute(c *gin.Context) {
+	attribute, ok := findCategoryAttribute(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Update attribute fields
+	if req.Name != "" {
+		attribute.Name = req.Name
+	}
+	if req.Options != nil {
+		if attribute.Type != models.AttributeTypeEnum {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only enum attributes have options"})
+			return
+		}
+		if len(req.Options) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Enum attributes require options"})
+			return
+		}
+		attribute.Options = req.Options
+	}
+	if req.Unit != "" {
+		attribute.Unit = req.Unit
+	}
+	if req.Required != nil {
+		attribute.Required = *req.Required
+	}
+	if req.Filterable != nil {
+		attribute.Filterable = *req.Filterable
+	}
+	if req.SortOrder != nil {
+		attribute.SortOrder = *req.SortOrder
+	}
+
+	if err := database.DB.Save(&attribute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attribute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Attribute updated successfully",
+		"attribute": attribute,
+	})
+}
+
+func DeleteCategoryAttribute(c *gin.Context) {
+	attribute, ok := findCategoryAttribute(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attribute_id = ?", attribute.ID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&attribute).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attribute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attribute deleted successfully",
+	})
+}
+
+func findCategoryAttribute(c *gin.Context) (models.AttributeDefinition, bool) {
+	var attribute models.AttributeDefinition
+
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return attribute, false
+	}
+	attributeID, err := strconv.ParseUint(c.Param("attributeId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attribute ID"})
+		return attribute, false
+	}
+
+	if err := database.DB.Where("id = ? AND category_id = ?", attributeID, categoryID).First(&attribute).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+		return attribute, false
+	}
+	return attribute, true
+}
+
+// applicableAttributes returns the attribute definitions declared on the
+// category and on every one of its ancestors.
+func applicableAttributes(db *gorm.DB, category models.Category) ([]models.AttributeDefinition, error) {
+	var attributes []models.AttributeDefinition
+	err := db.Where("category_id IN ?", category.LineageIDs()).
+		Order("sort_order ASC, name ASC").Find(&attributes).Error
+	return attributes, err
+}
+
+func attributeCodeTaken(category models.Category, code string, excludeID uint) bool {
+	var count int64
+	database.DB.Model(&models.AttributeDefinition{}).
+		Where("code = ? AND id != ?", code, excludeID).
+		Where("category_id IN ? OR category_id IN (?)", category.LineageIDs(), categorySubtreeQuery(category)).
+		Count(&count)
+	return count > 0
+}
+
+// resolveAttributeValues validates the submitted attribute values against the
+// definitions applicable to categoryID and merges them over existing values.
+// A nil value in input removes the attribute from the product.
+func resolveAttributeValues(db *gorm.DB, categoryID uint, input map[string]interface{}, existing []models.ProductAttributeValue) ([]models.ProductAttributeValue, error) {
+	var category models.Category
+	if err := db.First(&category, categoryID).Error; err != nil {
+		return nil, fmt.Errorf("Category not found")
+	}
+
+	definitions, err := applicableAttributes(db, category)
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[string]models.AttributeDefinition, len(definitions))
+	for _, definition := range definitions {
+		byCode[definition.Code] = definition
+	}
+	for code := range input {
+		if _, ok := byCode[code]; !ok {
+			return nil, fmt.Errorf("Unknown attribute: %s", code)
+		}
+	}
+
+	existingByAttribute := make(map[uint]models.ProductAttributeValue, len(existing))
+	for _, value := range existing {
+		existingByAttribute[value.AttributeID] = value
+	}
+
+	var values []models.ProductAttributeValue
+	for _, definition := range definitions {
+		raw, provided := input[definition.Code]
+		if !provided {
+			if value, ok := existingByAttribute[definition.ID]; ok {
+				value.Attribute = definition
+				values = append(values, value)
+			} else if definition.Required {
+				return nil, fmt.Errorf("Attribute %s is required", definition.Code)
+			}
+			continue
+		}
+		if raw == nil {
+			if definition.Required {
+				return nil, fmt.Errorf("Attribute %s is required", definition.Code)
+			}
+			continue
+		}
+
+		value, err := parseAttributeValue(definition, raw)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func parseAttributeValue(definition models.AttributeDefinition, raw interface{}) (models.ProductAttributeValue, error) {
+	value := models.ProductAttributeValue{AttributeID: definition.ID, Attribute: definition}
+
+	switch definition.Type {
+	case models.AttributeTypeNumber:
+		var number float64
+		switch v := raw.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return value, fmt.Errorf("Attribute %s must be a number", definition.Code)
+			}
+			number = parsed
+		default:
+			return value, fmt.Errorf("Attribute %s must be a number", definition.Code)
+		}
+		value.ValueNumber = &number
+	case models.AttributeTypeBoolean:
+		var flag bool
+		switch v := raw.(type) {
+		case bool:
+			flag = v
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return value, fmt.Errorf("Attribute %s must be true or false", definition.Code)
+			}
+			flag = parsed
+		default:
+			return value, fmt.Errorf("Attribute %s must be true or false", definition.Code)
+		}
+		value.ValueBool = &flag
+	case models.AttributeTypeEnum:
+		text, ok := raw.(string)
+		if !ok {
+			return value, fmt.Errorf("Attribute %s must be one of: %s", definition.Code, strings.Join(definition.Options, ", "))
+		}
+		valid := false
+		for _, option := range definition.Options {
+			if option == text {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return value, fmt.Errorf("Attribute %s must be one of: %s", definition.Code, strings.Join(definition.Options, ", "))
+		}
+		value.ValueText = text
+	default:
+		text, ok := raw.(string)
+		if !ok {
+			return value, fmt.Errorf("Attribute %s must be text", definition.Code)
+		}
+		if len(text) > 255 {
+			return value, fmt.Errorf("Attribute %s must be at most 255 characters", definition.Code)
+		}
+		value.ValueText = text
+	}
+	return value, nil
+}
+
+// saveAttributeValues replaces the stored attribute values of a product.
+func saveAttributeValues(tx *gorm.DB, productID uint, values []models.ProductAttributeValue) error {
+	if err := tx.Where("product_id = ?", productID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+		return err
+	}
+	for _, value := range values {
+		row := models.ProductAttributeValue{
+			ProductID:   productID,
+			AttributeID: value.AttributeID,
+			ValueText:   value.ValueText,
+			ValueNumber: value.ValueNumber,
+			ValueBool:   value.ValueBool,
+		}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyAttributeFilters narrows a product query using attr[code]=value query
+// parameters. Text and enum filters accept comma-separated alternatives,
+// number filters accept an exact value or a "min..max" range (either side may
+// be omitted), and boolean filters accept true or false.
+func applyAttributeFilters(query *gorm.DB, filters map[string]string) (*gorm.DB, error) {
+	for code, raw := range filters {
+		var definitions []models.AttributeDefinition
+		database.DB.Where("code = ? AND filterable = ?", code, true).Find(&definitions)
+		if len(definitions) == 0 {
+			return nil, fmt.Errorf("Unknown attribute filter: %s", code)
+		}
+
+		attributeIDs := make([]uint, 0, len(definitions))
+		for _, definition := range definitions {
+			attributeIDs = append(attributeIDs, definition.ID)
+		}
+
+		sub := database.DB.Model(&models.ProductAttributeValue{}).Select("product_id").Where("attribute_id IN ?", attributeIDs)
+		switch definitions[0].Type {
+		case models.AttributeTypeNumber:
+			if bounds := strings.SplitN(raw, "..", 2); len(bounds) == 2 {
+				if bounds[0] != "" {
+					min, err := strconv.ParseFloat(bounds[0], 64)
+					if err != nil {
+						return nil, fmt.Errorf("Invalid range for attribute %s", code)
+					}
+					sub = sub.Where("value_number >= ?", min)
+				}
+				if bounds[1] != "" {
+					max, err := strconv.ParseFloat(bounds[1], 64)
+					if err != nil {
+						return nil, fmt.Errorf("Invalid range for attribute %s", code)
+					}
+					sub = sub.Where("value_number <= ?", max)
+				}
+			} else {
+				number, err := strconv.ParseFloat(raw, 64)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid value for attribute %s", code)
+				}
+				sub = sub.Where("value_number = ?", number)
+			}
+		case models.AttributeTypeBoolean:
+			flag, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value for attribute %s", code)
+			}
+			sub = sub.Where("value_bool = ?", flag)
+		default:
+			sub = sub.Where("value_text IN ?", strings.Split(raw, ","))
+		}
+
+		query = query.Where("products.id IN (?)", sub)
+	}
+	return query, nil
+}
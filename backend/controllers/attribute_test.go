@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"testing"
+	"ecommerce-backend/models"
+)
+
+func TestParseAttributeValue(t *testing.T) {
+	number := models.AttributeDefinition{ID: 1, Code: "weight", Type: models.AttributeTypeNumber}
+	boolean := models.AttributeDefinition{ID: 2, Code: "waterproof", Type: models.AttributeTypeBoolean}
+	enum := models.AttributeDefinition{ID: 3, Code: "color", Type: models.AttributeTypeEnum, Options: models.StringList{"red", "blue"}}
+	text := models.AttributeDefinition{ID: 4, Code: "material", Type: models.AttributeTypeText}
+
+	tests := []struct {
+		name       string
+		definition models.AttributeDefinition
+		raw        interface{}
+		want       interface{}
+		wantErr    bool
+	}{
+		{"number from json", number, 2.5, 2.5, false},
+		{"number from string", number, " 3 ", 3.0, false},
+		{"number rejects text", number, "heavy", nil, true},
+		{"number rejects bool", number, true, nil, true},
+		{"boolean from json", boolean, true, true, false},
+		{"boolean from string", boolean, "false", false, false},
+		{"boolean rejects text", boolean, "maybe", nil, true},
+		{"enum option", enum, "blue", "blue", false},
+		{"enum rejects other values", enum, "green", nil, true},
+		{"enum rejects numbers", enum, 1.0, nil, true},
+		{"text", text, "cotton", "cotton", false},
+		{"text rejects numbers", text, 5.0, nil, true},
+		{"text rejects long values", text, string(make([]byte, 256)), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := parseAttributeValue(tt.definition, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAttributeValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if value.AttributeID != tt.definition.ID {
+				t.Errorf("AttributeID = %d, want %d", value.AttributeID, tt.definition.ID)
+			}
+			if got := value.TypedValue(); got != tt.want {
+				t.Errorf("TypedValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -74,10 +74,17 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	// Keep anything added to the cart before signing up
+	mergedItems, err := mergeGuestCart(c, user.ID)
+	if err != nil {
+		fmt.Printf("Register: Failed to merge guest cart for user: %s: %v\n", user.Email, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
-		"user":    user.ToResponse(),
-		"token":   token,
+		"message":           "User registered successfully",
+		"user":              user.ToResponse(),
+		"token":             token,
+		"merged_cart_items": mergedItems,
 	})
 }
 
@@ -113,10 +120,17 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Merge anything added to the cart before logging in
+	mergedItems, err := mergeGuestCart(c, user.ID)
+	if err != nil {
+		fmt.Printf("Login: Failed to merge guest cart for user: %s: %v\n", req.Email, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    user.ToResponse(),
-		"token":   token,
+		"message":           "Login successful",
+		"user":              user.ToResponse(),
+		"token":             token,
+		"merged_cart_items": mergedItems,
 	})
 }
 
@@ -1,130 +1,180 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 	"ecommerce-backend/database"
 	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AddToCartRequest struct {
-	ProductID uint `json:"product_id" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,gt=0"`
+	ProductID uint   `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	Note      string `json:"note" binding:"max=500"`
 }
 
 type UpdateCartItemRequest struct {
-	Quantity int `json:"quantity" binding:"required,gt=0"`
+	Quantity int     `json:"quantity" binding:"omitempty,gt=0"`
+	Note     *string `json:"note" binding:"omitempty,max=500"`
 }
 
+// GetCart lists the cart and reports lines that no longer match the catalog
+// without changing them.
 func GetCart(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
 		return
 	}
+	respondCart(c, owner, false)
+}
 
-	var cartItems []models.Cart
-	if err := database.DB.Preload("Product.Category").Where("user_id = ?", userID).Find(&cartItems).Error; err != nil {
+// AdjustCart corrects the cart lines that no longer match the catalog and
+// lists the cart as GetCart does, with the corrected warnings marked as
+// adjusted.
+func AdjustCart(c *gin.Context) {
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
+		return
+	}
+	respondCart(c, owner, true)
+}
+
+func respondCart(c *gin.Context, owner cartOwner, adjust bool) {
+	var allItems []models.Cart
+	if err := owner.scope(database.DB).Preload("Product.Category").Find(&allItems).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart items"})
 		return
 	}
 
-	// Convert to response format and calculate totals
+	// Items saved for later are listed separately and never counted
+	var cartItems []models.Cart
+	savedItems := []models.CartResponse{}
+	for _, item := range allItems {
+		if item.SavedForLater {
+			savedItems = append(savedItems, item.ToResponse())
+		} else {
+			cartItems = append(cartItems, item)
+		}
+	}
+
+	warnings := []models.CartWarning{}
+	now := time.Now()
+	for i := range cartItems {
+		itemWarnings := cartItems[i].Warnings(now)
+		if adjust && len(itemWarnings) > 0 {
+			if err := adjustCartItem(database.DB, &cartItems[i], itemWarnings); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust cart items"})
+				return
+			}
+			for j := range itemWarnings {
+				itemWarnings[j].Adjusted = true
+			}
+		}
+		warnings = append(warnings, itemWarnings...)
+	}
+
+	// Convert to response format and calculate totals. Lines that cannot be
+	// ordered are listed but left out of the totals.
 	var cartResponses []models.CartResponse
 	var totalAmount float64
 	var totalItems int
 
 	for _, item := range cartItems {
+		if item.Quantity <= 0 {
+			continue
+		}
 		cartResponse := item.ToResponse()
 		cartResponses = append(cartResponses, cartResponse)
+		if item.Product.ID == 0 || !item.Product.IsVisibleAt(now) || item.Product.Stock <= 0 {
+			continue
+		}
 		totalAmount += cartResponse.Subtotal
 		totalItems += item.Quantity
 	}
 
+	// Include notices about items removed on the customer's behalf
+	var notices []models.CartNotice
+	owner.scope(database.DB).Where("dismissed_at IS NULL").Order("created_at DESC").Find(&notices)
+
 	c.JSON(http.StatusOK, gin.H{
 		"cart_items":   cartResponses,
+		"saved_items":  savedItems,
 		"total_amount": totalAmount,
 		"total_items":  totalItems,
+		"warnings":     warnings,
+		"notices":      notices,
 	})
 }
 
-func AddToCart(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+func DismissCartNotices(c *gin.Context) {
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
 		return
 	}
 
+	if err := owner.scope(database.DB.Model(&models.CartNotice{})).Where("dismissed_at IS NULL").
+		Update("dismissed_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss notices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notices dismissed successfully",
+	})
+}
+
+func AddToCart(c *gin.Context) {
 	var req AddToCartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Verify product exists and has enough stock
-	var product models.Product
-	if err := database.DB.First(&product, req.ProductID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	owner, ok := resolveCartOwner(c, true)
+	if !ok {
 		return
 	}
 
-	if product.Stock < req.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock"})
+	cartItem, created, err := addProductToCart(database.DB, owner, req.ProductID, req.Quantity)
+	if err != nil {
+		respondCartError(c, err)
 		return
 	}
 
-	// Check if item already exists in cart
-	var existingCartItem models.Cart
-	if err := database.DB.Where("user_id = ? AND product_id = ?", userID, req.ProductID).First(&existingCartItem).Error; err == nil {
-		// Update existing item quantity
-		newQuantity := existingCartItem.Quantity + req.Quantity
-		if product.Stock < newQuantity {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock for requested quantity"})
-			return
-		}
-
-		existingCartItem.Quantity = newQuantity
-		if err := database.DB.Save(&existingCartItem).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
-			return
-		}
+	if req.Note != "" {
+		cartItem.Note = req.Note
+		database.DB.Model(&cartItem).Update("note", req.Note)
+	}
 
-		// Load product relation
-		database.DB.Preload("Product.Category").First(&existingCartItem, existingCartItem.ID)
+	// Load product relation
+	database.DB.Preload("Product.Category").First(&cartItem, cartItem.ID)
 
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "Cart item updated successfully",
-			"cart_item": existingCartItem.ToResponse(),
-		})
-		return
+	response := gin.H{
+		"message":   "Item added to cart successfully",
+		"cart_item": cartItem.ToResponse(),
 	}
-
-	// Create new cart item
-	cartItem := models.Cart{
-		UserID:    userID.(uint),
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
+	if token, exists := c.Get("cart_token"); exists {
+		response["cart_token"] = token
 	}
 
-	if err := database.DB.Create(&cartItem).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
+	if !created {
+		response["message"] = "Cart item updated successfully"
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
-	// Load product relation
-	database.DB.Preload("Product.Category").First(&cartItem, cartItem.ID)
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message":   "Item added to cart successfully",
-		"cart_item": cartItem.ToResponse(),
-	})
+	c.JSON(http.StatusCreated, response)
 }
 
 func UpdateCartItem(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
 		return
 	}
 
@@ -142,19 +192,35 @@ func UpdateCartItem(c *gin.Context) {
 	}
 
 	var cartItem models.Cart
-	if err := database.DB.Preload("Product").Where("id = ? AND user_id = ?", cartItemID, userID).First(&cartItem).Error; err != nil {
+	if err := owner.scope(database.DB).Preload("Product").Where("id = ?", cartItemID).First(&cartItem).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 		return
 	}
 
-	// Check stock availability
-	if cartItem.Product.Stock < req.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock"})
+	if req.Quantity == 0 && req.Note == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
 		return
 	}
 
-	cartItem.Quantity = req.Quantity
-	if err := database.DB.Save(&cartItem).Error; err != nil {
+	if req.Quantity != 0 {
+		if !cartItem.Product.IsVisibleAt(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is no longer available"})
+			return
+		}
+
+		// Check stock availability
+		if cartItem.Product.Stock < req.Quantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock"})
+			return
+		}
+
+		cartItem.Quantity = req.Quantity
+	}
+	if req.Note != nil {
+		cartItem.Note = *req.Note
+	}
+
+	if err := database.DB.Omit("User", "Product").Save(&cartItem).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
 		return
 	}
@@ -169,9 +235,8 @@ func UpdateCartItem(c *gin.Context) {
 }
 
 func RemoveFromCart(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
 		return
 	}
 
@@ -183,7 +248,7 @@ func RemoveFromCart(c *gin.Context) {
 	}
 
 	var cartItem models.Cart
-	if err := database.DB.Where("id = ? AND user_id = ?", cartItemID, userID).First(&cartItem).Error; err != nil {
+	if err := owner.scope(database.DB).Where("id = ?", cartItemID).First(&cartItem).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 		return
 	}
@@ -199,13 +264,13 @@ func RemoveFromCart(c *gin.Context) {
 }
 
 func ClearCart(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Where("user_id = ?", userID).Delete(&models.Cart{}).Error; err != nil {
+	// Items saved for later stay where they are
+	if err := owner.scope(database.DB).Where("saved_for_later = ?", false).Delete(&models.Cart{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
 		return
 	}
@@ -214,3 +279,307 @@ func ClearCart(c *gin.Context) {
 		"message": "Cart cleared successfully",
 	})
 }
+
+// SaveCartItemForLater moves a line out of the cart into the saved for later
+// list.
+func SaveCartItemForLater(c *gin.Context) {
+	setCartItemSavedForLater(c, true)
+}
+
+// MoveCartItemToCart moves a saved for later line back into the cart,
+// checking that it can still be bought.
+func MoveCartItemToCart(c *gin.Context) {
+	setCartItemSavedForLater(c, false)
+}
+
+func setCartItemSavedForLater(c *gin.Context, saved bool) {
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	cartItemID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart item ID"})
+		return
+	}
+
+	var cartItem models.Cart
+	if err := owner.scope(database.DB).Preload("Product").Where("id = ?", cartItemID).First(&cartItem).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+		return
+	}
+
+	if !saved {
+		if !cartItem.Product.IsVisibleAt(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is no longer available"})
+			return
+		}
+		if cartItem.Product.Stock < cartItem.Quantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock"})
+			return
+		}
+	}
+
+	cartItem.SavedForLater = saved
+	if err := database.DB.Omit("User", "Product").Save(&cartItem).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
+		return
+	}
+
+	// Load product relation
+	database.DB.Preload("Product.Category").First(&cartItem, cartItem.ID)
+
+	message := "Item moved to cart successfully"
+	if saved {
+		message = "Item saved for later successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"cart_item": cartItem.ToResponse(),
+	})
+}
+
+// guestCartTTL is how long an anonymous cart survives without a login.
+const guestCartTTL = 30 * 24 * time.Hour
+
+// cartOwner identifies whose cart a request operates on: the logged in user
+// or, for anonymous visitors, the guest cart named by the X-Cart-Token header.
+type cartOwner struct {
+	UserID      uint
+	GuestCartID uint
+}
+
+func (o cartOwner) scope(db *gorm.DB) *gorm.DB {
+	if o.UserID != 0 {
+		return db.Where("user_id = ?", o.UserID)
+	}
+	return db.Where("guest_cart_id = ?", o.GuestCartID)
+}
+
+func (o cartOwner) newItem(productID uint, quantity int) models.Cart {
+	item := models.Cart{ProductID: productID, Quantity: quantity}
+	if o.UserID != 0 {
+		item.UserID = &o.UserID
+	} else {
+		item.GuestCartID = &o.GuestCartID
+	}
+	return item
+}
+
+// resolveCartOwner works out the cart owner for the request. Anonymous
+// requests without a valid cart token get an empty guest cart, which is only
+// persisted (and its token returned) when create is set.
+func resolveCartOwner(c *gin.Context, create bool) (cartOwner, bool) {
+	if userID, exists := c.Get("user_id"); exists {
+		return cartOwner{UserID: userID.(uint)}, true
+	}
+
+	// Expired or tampered tokens are treated like a missing one
+	if guestCartID, err := utils.ValidateCartToken(c.GetHeader("X-Cart-Token")); err == nil {
+		var guestCart models.GuestCart
+		if err := database.DB.Where("id = ? AND expires_at > ?", guestCartID, time.Now()).First(&guestCart).Error; err == nil {
+			return cartOwner{GuestCartID: guestCart.ID}, true
+		}
+	}
+
+	if !create {
+		return cartOwner{}, true
+	}
+
+	guestCart := models.GuestCart{ExpiresAt: time.Now().Add(guestCartTTL)}
+	if err := database.DB.Create(&guestCart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
+		return cartOwner{}, false
+	}
+
+	token, err := utils.GenerateCartToken(guestCart.ID, guestCart.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate cart token"})
+		return cartOwner{}, false
+	}
+	c.Set("cart_token", token)
+	c.Header("X-Cart-Token", token)
+
+	return cartOwner{GuestCartID: guestCart.ID}, true
+}
+
+// mergeGuestCart moves the guest cart named by the request's cart token into
+// the user's cart. Quantities are added to existing lines and capped at the
+// available stock; lines that are capped or no longer purchasable leave a
+// cart notice. It returns the number of lines merged.
+func mergeGuestCart(c *gin.Context, userID uint) (int, error) {
+	guestCartID, err := utils.ValidateCartToken(c.GetHeader("X-Cart-Token"))
+	if err != nil {
+		return 0, nil
+	}
+
+	merged := 0
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var guestItems []models.Cart
+		if err := tx.Preload("Product").Where("guest_cart_id = ?", guestCartID).Find(&guestItems).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, guestItem := range guestItems {
+			product := guestItem.Product
+			if product.ID == 0 {
+				continue
+			}
+
+			var userItem models.Cart
+			err := tx.Where("user_id = ? AND product_id = ?", userID, product.ID).First(&userItem).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			exists := err == nil
+
+			requested := guestItem.Quantity
+			if exists {
+				requested += userItem.Quantity
+			}
+			quantity := requested
+			if quantity > product.Stock {
+				quantity = product.Stock
+			}
+
+			if !product.IsVisibleAt(now) || quantity <= 0 {
+				if err := createCartNotice(tx, userID, product, models.CartNoticeProductUnavailable,
+					product.Name+" is no longer available and was not added to your cart"); err != nil {
+					return err
+				}
+				continue
+			}
+			if quantity < requested {
+				if err := createCartNotice(tx, userID, product, models.CartNoticeQuantityAdjusted,
+					fmt.Sprintf("Only %d of %s are available, so the quantity in your cart was reduced", quantity, product.Name)); err != nil {
+					return err
+				}
+			}
+
+			if exists {
+				if err := tx.Model(&userItem).Update("quantity", quantity).Error; err != nil {
+					return err
+				}
+			} else {
+				cartItem := cartOwner{UserID: userID}.newItem(product.ID, quantity)
+				cartItem.PriceAtAdd = guestItem.PriceAtAdd
+				cartItem.SavedForLater = guestItem.SavedForLater
+				cartItem.Note = guestItem.Note
+				if err := tx.Create(&cartItem).Error; err != nil {
+					return err
+				}
+			}
+			merged++
+		}
+
+		// Carry over notices the guest has not seen yet
+		if err := tx.Model(&models.CartNotice{}).Where("guest_cart_id = ? AND dismissed_at IS NULL", guestCartID).
+			Updates(map[string]interface{}{"user_id": userID, "guest_cart_id": nil}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("guest_cart_id = ?", guestCartID).Delete(&models.Cart{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("guest_cart_id = ?", guestCartID).Delete(&models.CartNotice{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.GuestCart{}, guestCartID).Error
+	})
+	return merged, err
+}
+
+func createCartNotice(tx *gorm.DB, userID uint, product models.Product, noticeType, message string) error {
+	notice := models.CartNotice{
+		UserID:      &userID,
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Type:        noticeType,
+		Message:     message,
+	}
+	return tx.Create(&notice).Error
+}
+
+var (
+	errCartProductNotFound   = errors.New("Product not found")
+	errCartInsufficientStock = errors.New("Insufficient stock")
+	errCartQuantityExceeded  = errors.New("Insufficient stock for requested quantity")
+)
+
+// addProductToCart adds quantity units of a published product to the owner's
+// cart, merging with an existing line. It reports whether a new line was
+// created.
+func addProductToCart(db *gorm.DB, owner cartOwner, productID uint, quantity int) (models.Cart, bool, error) {
+	// Verify product exists, is published and has enough stock
+	var product models.Product
+	if err := db.Scopes(models.VisibleProducts(time.Now())).First(&product, productID).Error; err != nil {
+		return models.Cart{}, false, errCartProductNotFound
+	}
+
+	if product.Stock < quantity {
+		return models.Cart{}, false, errCartInsufficientStock
+	}
+
+	// Check if item already exists in cart
+	var existingCartItem models.Cart
+	if err := owner.scope(db).Where("product_id = ?", productID).First(&existingCartItem).Error; err == nil {
+		// Update existing item quantity
+		newQuantity := existingCartItem.Quantity + quantity
+		if product.Stock < newQuantity {
+			return existingCartItem, false, errCartQuantityExceeded
+		}
+
+		// Adding more means the customer has seen the current price and
+		// wants the item now
+		existingCartItem.Quantity = newQuantity
+		existingCartItem.PriceAtAdd = product.Price
+		existingCartItem.SavedForLater = false
+		if err := db.Omit("User", "Product").Save(&existingCartItem).Error; err != nil {
+			return existingCartItem, false, err
+		}
+		return existingCartItem, false, nil
+	}
+
+	// Create new cart item
+	cartItem := owner.newItem(productID, quantity)
+	cartItem.PriceAtAdd = product.Price
+	if err := db.Create(&cartItem).Error; err != nil {
+		return cartItem, false, err
+	}
+	return cartItem, true, nil
+}
+
+// adjustCartItem brings a cart line in line with the catalog: lines that can
+// no longer be ordered are removed (their quantity is set to zero), quantities
+// are capped at the available stock and the current price is accepted.
+func adjustCartItem(db *gorm.DB, item *models.Cart, warnings []models.CartWarning) error {
+	for _, warning := range warnings {
+		switch warning.Type {
+		case models.CartWarningUnavailable, models.CartWarningOutOfStock:
+			item.Quantity = 0
+			return db.Delete(&models.Cart{}, item.ID).Error
+		case models.CartWarningStockReduced:
+			item.Quantity = item.Product.Stock
+		case models.CartWarningPriceIncreased, models.CartWarningPriceDecreased:
+			item.PriceAtAdd = item.Product.Price
+		}
+	}
+	return db.Model(&models.Cart{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+		"quantity":     item.Quantity,
+		"price_at_add": item.PriceAtAdd,
+	}).Error
+}
+
+func respondCartError(c *gin.Context, err error) {
+	switch err {
+	case errCartProductNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errCartInsufficientStock, errCartQuantityExceeded:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
+	}
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCartOwnerNewItem(t *testing.T) {
+	userItem := cartOwner{UserID: 3}.newItem(9, 2)
+	if userItem.UserID == nil || *userItem.UserID != 3 || userItem.GuestCartID != nil {
+		t.Errorf("user item = %+v, want it owned by user 3 only", userItem)
+	}
+	if userItem.ProductID != 9 || userItem.Quantity != 2 {
+		t.Errorf("user item = %+v, want 2 of product 9", userItem)
+	}
+
+	guestItem := cartOwner{GuestCartID: 5}.newItem(9, 1)
+	if guestItem.GuestCartID == nil || *guestItem.GuestCartID != 5 || guestItem.UserID != nil {
+		t.Errorf("guest item = %+v, want it owned by guest cart 5 only", guestItem)
+	}
+}
+
+func TestCartOwnerScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		owner     cartOwner
+		column    string
+		wantOwner uint
+	}{
+		{"user", cartOwner{UserID: 3}, "user_id", 3},
+		{"guest", cartOwner{GuestCartID: 5}, "guest_cart_id", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			statements := recordStatements(db)
+			var items []models.Cart
+			tt.owner.scope(db).Find(&items)
+			if len(*statements) != 1 {
+				t.Fatalf("statements = %+v, want one query", *statements)
+			}
+			query := (*statements)[0]
+			if query.Table != "carts" || query.Where[tt.column] != tt.wantOwner {
+				t.Errorf("query = %+v, want carts with %s %d", query, tt.column, tt.wantOwner)
+			}
+			if len(query.Where) != 2 {
+				t.Errorf("query filters on %v, want only the owner and soft deletion", query.Where)
+			}
+		})
+	}
+}
+
+func TestResolveCartOwnerWithoutDatabase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/cart", nil)
+	c.Set("user_id", uint(3))
+	owner, ok := resolveCartOwner(c, true)
+	if !ok || owner != (cartOwner{UserID: 3}) {
+		t.Errorf("logged in owner = %+v, %v, want user 3", owner, ok)
+	}
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/cart", nil)
+	c.Request.Header.Set("X-Cart-Token", "tampered")
+	owner, ok = resolveCartOwner(c, false)
+	if !ok || owner != (cartOwner{}) {
+		t.Errorf("anonymous owner = %+v, %v, want an empty guest cart", owner, ok)
+	}
+}
+
+func TestAdjustCartItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		item         models.Cart
+		wantQuantity int
+		wantPrice    float64
+		wantRemoved  bool
+	}{
+		{
+			name:         "accepts new price",
+			item:         models.Cart{ID: 4, Quantity: 2, PriceAtAdd: 10, Product: models.Product{ID: 9, Price: 12, Stock: 5, Status: models.ProductStatusActive}},
+			wantQuantity: 2,
+			wantPrice:    12,
+		},
+		{
+			name:         "caps quantity",
+			item:         models.Cart{ID: 4, Quantity: 5, PriceAtAdd: 10, Product: models.Product{ID: 9, Price: 10, Stock: 3, Status: models.ProductStatusActive}},
+			wantQuantity: 3,
+			wantPrice:    10,
+		},
+		{
+			name:         "removes unavailable line",
+			item:         models.Cart{ID: 4, Quantity: 1, PriceAtAdd: 10, Product: models.Product{ID: 9, Price: 10, Status: models.ProductStatusArchived}},
+			wantQuantity: 0,
+			wantPrice:    10,
+			wantRemoved:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			statements := recordStatements(db)
+			item := tt.item
+			if err := adjustCartItem(db, &item, item.Warnings(time.Now())); err != nil {
+				t.Fatalf("adjustCartItem() error = %v", err)
+			}
+			if item.Quantity != tt.wantQuantity || item.PriceAtAdd != tt.wantPrice {
+				t.Errorf("item = %d at %v, want %d at %v", item.Quantity, item.PriceAtAdd, tt.wantQuantity, tt.wantPrice)
+			}
+
+			if len(*statements) != 1 {
+				t.Fatalf("statements = %+v, want one", *statements)
+			}
+			saved := (*statements)[0]
+			if saved.Table != "carts" || saved.Where["id"] != uint(4) {
+				t.Errorf("statement = %+v, want it to change cart line 4", saved)
+			}
+			if tt.wantRemoved {
+				if saved.Action != "delete" {
+					t.Errorf("statement = %+v, want the line deleted", saved)
+				}
+				return
+			}
+			if saved.Action != "update" || saved.Values["quantity"] != tt.wantQuantity || saved.Values["price_at_add"] != tt.wantPrice {
+				t.Errorf("statement = %+v, want quantity %d at %v saved", saved, tt.wantQuantity, tt.wantPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,897 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	CatalogFormatCSV  = "csv"
+	CatalogFormatJSON = "json"
+
+	CatalogTypeProducts   = "products"
+	CatalogTypeCategories = "categories"
+	CatalogTypeAll        = "all"
+
+	catalogAttributePrefix = "attr:"
+)
+
+var (
+	categoryCSVHeader = []string{"slug", "name", "description", "image", "parent", "sort_order"}
+	productCSVHeader  = []string{"sku", "name", "description", "price", "stock", "image", "category", "status", "publish_at", "unpublish_at"}
+
+	errRollbackImport = errors.New("import rolled back")
+)
+
+type CatalogCategory struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+	Parent      string `json:"parent"`
+	SortOrder   *int   `json:"sort_order"`
+}
+
+type CatalogProduct struct {
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Price       *float64               `json:"price"`
+	Stock       *int                   `json:"stock"`
+	Image       string                 `json:"image"`
+	Category    string                 `json:"category"`
+	Status      string                 `json:"status,omitempty"`
+	PublishAt   *time.Time             `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time             `json:"unpublish_at,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+}
+
+type CatalogDocument struct {
+	Categories []CatalogCategory `json:"categories,omitempty"`
+	Products   []CatalogProduct  `json:"products,omitempty"`
+
+	// firstRow is the row number of the first record, used in error reports
+	// (2 for CSV files because of the header line, 1 for JSON documents).
+	firstRow int
+	// invalid marks record indexes that failed to parse and were reported.
+	invalid map[int]bool
+}
+
+type ImportCounts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+type ImportError struct {
+	Entity  string `json:"entity"`
+	Row     int    `json:"row"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun     bool          `json:"dry_run"`
+	Applied    bool          `json:"applied"`
+	Categories ImportCounts  `json:"categories"`
+	Products   ImportCounts  `json:"products"`
+	Errors     []ImportError `json:"errors"`
+}
+
+func (r *ImportReport) addError(entity string, row int, key, message string) {
+	r.Errors = append(r.Errors, ImportError{Entity: entity, Row: row, Key: key, Message: message})
+}
+
+func ImportCatalog(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	catalogType := c.DefaultQuery("type", CatalogTypeProducts)
+	format := c.Query("format")
+
+	var reader io.Reader = c.Request.Body
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		reader = file
+		if format == "" {
+			format = catalogFormatFromName(header.Filename)
+		}
+	}
+	if format == "" {
+		format = CatalogFormatCSV
+		if strings.Contains(c.ContentType(), "json") {
+			format = CatalogFormatJSON
+		}
+	}
+
+	if err := ValidateCatalogOptions(format, catalogType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := RunCatalogImport(format, catalogType, reader, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import catalog"})
+		return
+	}
+
+	status := http.StatusOK
+	if len(report.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{
+		"report": report,
+	})
+}
+
+func ExportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", CatalogFormatCSV)
+	catalogType := c.DefaultQuery("type", CatalogTypeProducts)
+
+	if err := ValidateCatalogOptions(format, catalogType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv"
+	if format == CatalogFormatJSON {
+		contentType = "application/json"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", catalogType, format))
+	c.Status(http.StatusOK)
+
+	if err := WriteCatalogExport(format, catalogType, c.Writer); err != nil {
+		// Headers are already sent, so the best we can do is stop the stream
+		c.Error(err)
+	}
+}
+
+func ValidateCatalogOptions(format, catalogType string) error {
+	if format != CatalogFormatCSV && format != CatalogFormatJSON {
+		return fmt.Errorf("Unsupported format: %s", format)
+	}
+	switch catalogType {
+	case CatalogTypeProducts, CatalogTypeCategories:
+		return nil
+	case CatalogTypeAll:
+		if format == CatalogFormatJSON {
+			return nil
+		}
+		return fmt.Errorf("Type all is only supported for JSON")
+	}
+	return fmt.Errorf("Unsupported type: %s", catalogType)
+}
+
+func catalogFormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return CatalogFormatJSON
+	case ".csv":
+		return CatalogFormatCSV
+	}
+	return ""
+}
+
+// RunCatalogImport validates and applies a catalog file inside a single
+// transaction. Nothing is written when the report contains errors or when
+// dryRun is set; the report still reflects what would have happened.
+func RunCatalogImport(format, catalogType string, r io.Reader, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Errors: []ImportError{}}
+
+	document, err := decodeCatalog(format, catalogType, r, &report)
+	if err != nil {
+		report.addError("document", 0, "", err.Error())
+		return report, nil
+	}
+
+	var importer catalogImporter
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		importer = catalogImporter{tx: tx, report: &report, seen: map[string]bool{}}
+		for i, row := range document.Categories {
+			if document.invalid[i] {
+				continue
+			}
+			if err := importer.importCategory(document.firstRow+i, row); err != nil {
+				return err
+			}
+		}
+		for i, row := range document.Products {
+			if document.invalid[i] {
+				continue
+			}
+			if err := importer.importProduct(document.firstRow+i, row); err != nil {
+				return err
+			}
+		}
+		if dryRun || len(report.Errors) > 0 {
+			return errRollbackImport
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollbackImport) {
+		return report, err
+	}
+
+	report.Applied = err == nil
+	if report.Applied {
+		notifications.Publish(importer.events...)
+	}
+	return report, nil
+}
+
+type catalogImporter struct {
+	tx     *gorm.DB
+	report *ImportReport
+	seen   map[string]bool
+	events []notifications.Event
+}
+
+func (i *catalogImporter) importCategory(row int, in CatalogCategory) error {
+	slug := utils.Slugify(in.Slug)
+	if slug == "" {
+		slug = utils.Slugify(in.Name)
+	}
+	if slug == "" {
+		i.report.addError(CatalogTypeCategories, row, "", "slug or name is required")
+		return nil
+	}
+	if i.seen["category:"+slug] {
+		i.report.addError(CatalogTypeCategories, row, slug, "duplicate slug in file")
+		return nil
+	}
+	i.seen["category:"+slug] = true
+
+	var parent *models.Category
+	if in.Parent != "" {
+		var parentCategory models.Category
+		if err := i.tx.Where("slug = ?", utils.Slugify(in.Parent)).First(&parentCategory).Error; err != nil {
+			i.report.addError(CatalogTypeCategories, row, slug, "parent category not found: "+in.Parent)
+			return nil
+		}
+		parent = &parentCategory
+	}
+
+	var category models.Category
+	err := i.tx.Where("slug = ?", slug).First(&category).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	exists := err == nil
+
+	if exists {
+		currentParent, targetParent := uint(0), uint(0)
+		if category.ParentID != nil {
+			currentParent = *category.ParentID
+		}
+		if parent != nil {
+			targetParent = parent.ID
+		}
+		if currentParent != targetParent {
+			i.report.addError(CatalogTypeCategories, row, slug, "changing the parent of an existing category is not supported by import")
+			return nil
+		}
+	} else if in.Name == "" {
+		i.report.addError(CatalogTypeCategories, row, slug, "name is required for new categories")
+		return nil
+	}
+
+	if in.Name != "" && in.Name != category.Name {
+		query := i.tx.Model(&models.Category{}).Where("name = ? AND id != ?", in.Name, category.ID)
+		if parent == nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", parent.ID)
+		}
+		var count int64
+		query.Count(&count)
+		if count > 0 {
+			i.report.addError(CatalogTypeCategories, row, slug, "a sibling category with this name already exists")
+			return nil
+		}
+		category.Name = in.Name
+	}
+	if in.Description != "" {
+		category.Description = in.Description
+	}
+	if in.Image != "" {
+		category.Image = in.Image
+	}
+	if in.SortOrder != nil {
+		category.SortOrder = *in.SortOrder
+	}
+
+	if exists {
+		if err := i.tx.Omit("Parent", "Children", "Products").Save(&category).Error; err != nil {
+			return err
+		}
+		i.report.Categories.Updated++
+		return nil
+	}
+
+	category.Slug = slug
+	if parent != nil {
+		category.ParentID = &parent.ID
+		category.Depth = parent.Depth + 1
+	}
+	if err := i.tx.Create(&category).Error; err != nil {
+		return err
+	}
+	category.Path = category.BuildPath(parent)
+	if err := i.tx.Model(&category).Update("path", category.Path).Error; err != nil {
+		return err
+	}
+	i.report.Categories.Created++
+	return nil
+}
+
+func (i *catalogImporter) importProduct(row int, in CatalogProduct) error {
+	sku := strings.TrimSpace(in.SKU)
+	if sku == "" {
+		i.report.addError(CatalogTypeProducts, row, "", "sku is required")
+		return nil
+	}
+	if i.seen["product:"+sku] {
+		i.report.addError(CatalogTypeProducts, row, sku, "duplicate sku in file")
+		return nil
+	}
+	i.seen["product:"+sku] = true
+
+	var product models.Product
+	err := i.tx.Preload("AttributeValues").Where("sku = ?", sku).First(&product).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	exists := err == nil
+	oldPrice := product.Price
+
+	if !exists {
+		if in.Name == "" {
+			i.report.addError(CatalogTypeProducts, row, sku, "name is required for new products")
+			return nil
+		}
+		if in.Price == nil {
+			i.report.addError(CatalogTypeProducts, row, sku, "price is required for new products")
+			return nil
+		}
+		if in.Category == "" {
+			i.report.addError(CatalogTypeProducts, row, sku, "category is required for new products")
+			return nil
+		}
+	}
+	if in.Price != nil && *in.Price <= 0 {
+		i.report.addError(CatalogTypeProducts, row, sku, "price must be greater than 0")
+		return nil
+	}
+	if in.Stock != nil && *in.Stock < 0 {
+		i.report.addError(CatalogTypeProducts, row, sku, "stock must not be negative")
+		return nil
+	}
+	if in.Status != "" && !models.IsValidProductStatus(in.Status) {
+		i.report.addError(CatalogTypeProducts, row, sku, "invalid status: "+in.Status)
+		return nil
+	}
+
+	if in.Category != "" {
+		var category models.Category
+		if err := i.tx.Where("slug = ?", utils.Slugify(in.Category)).First(&category).Error; err != nil {
+			i.report.addError(CatalogTypeProducts, row, sku, "category not found: "+in.Category)
+			return nil
+		}
+		product.CategoryID = category.ID
+	}
+
+	attributeValues, err := resolveAttributeValues(i.tx, product.CategoryID, in.Attributes, product.AttributeValues)
+	if err != nil {
+		i.report.addError(CatalogTypeProducts, row, sku, err.Error())
+		return nil
+	}
+
+	product.SKU = sku
+	if in.Name != "" {
+		product.Name = in.Name
+	}
+	if in.Description != "" {
+		product.Description = in.Description
+	}
+	if in.Price != nil {
+		product.Price = *in.Price
+	}
+	if in.Image != "" {
+		product.Image = in.Image
+	}
+	archiving := false
+	if in.Status != "" {
+		archiving = exists && in.Status == models.ProductStatusArchived && product.Status != models.ProductStatusArchived
+		if in.Status != models.ProductStatusArchived {
+			product.ArchivedAt = nil
+		}
+		product.Status = in.Status
+	} else if !exists {
+		product.Status = models.ProductStatusDraft
+	}
+	if in.PublishAt != nil {
+		product.PublishAt = in.PublishAt
+	}
+	if in.UnpublishAt != nil {
+		product.UnpublishAt = in.UnpublishAt
+	}
+	if product.PublishAt != nil && product.UnpublishAt != nil && !product.UnpublishAt.After(*product.PublishAt) {
+		i.report.addError(CatalogTypeProducts, row, sku, "unpublish_at must be after publish_at")
+		return nil
+	}
+
+	if archiving {
+		if err := archiveProduct(i.tx, &product); err != nil {
+			return err
+		}
+	}
+	// Stock and cost are only changed through their histories, and the
+	// rating totals by review moderation
+	if err := i.tx.Omit("AttributeValues", "Stock", "Cost", "RatingSum", "RatingCount").Save(&product).Error; err != nil {
+		return err
+	}
+	if err := saveAttributeValues(i.tx, product.ID, attributeValues); err != nil {
+		return err
+	}
+	if in.Stock != nil {
+		events, err := setStock(i.tx, models.StockMovement{
+			ProductID: product.ID,
+			Reason:    models.StockReasonAdjustment,
+			Note:      "Catalog import",
+		}, *in.Stock)
+		if _, ok := err.(inventoryError); ok {
+			i.report.addError(CatalogTypeProducts, row, sku, err.Error())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if exists {
+			i.events = append(i.events, events...)
+		}
+	}
+
+	if exists {
+		i.events = append(i.events, notifications.ProductChangeEvents(product.ID, oldPrice, product.Price, product.Stock, product.Stock)...)
+		i.report.Products.Updated++
+	} else {
+		i.report.Products.Created++
+	}
+	return nil
+}
+
+func decodeCatalog(format, catalogType string, r io.Reader, report *ImportReport) (CatalogDocument, error) {
+	if format == CatalogFormatJSON {
+		return decodeCatalogJSON(catalogType, r)
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return CatalogDocument{}, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return CatalogDocument{}, fmt.Errorf("file is empty")
+	}
+
+	header := make([]string, len(records[0]))
+	for i, column := range records[0] {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		if !strings.HasPrefix(header[i], catalogAttributePrefix) {
+			header[i] = strings.ToLower(header[i])
+		}
+	}
+
+	document := CatalogDocument{firstRow: 2, invalid: map[int]bool{}}
+	if catalogType == CatalogTypeCategories {
+		if err := checkCSVHeader(header, categoryCSVHeader, false); err != nil {
+			return document, err
+		}
+		for i, record := range records[1:] {
+			category, err := parseCategoryRecord(header, record)
+			if err != nil {
+				report.addError(CatalogTypeCategories, i+2, category.Slug, err.Error())
+				document.invalid[i] = true
+			}
+			document.Categories = append(document.Categories, category)
+		}
+		return document, nil
+	}
+
+	if err := checkCSVHeader(header, productCSVHeader, true); err != nil {
+		return document, err
+	}
+	for i, record := range records[1:] {
+		product, err := parseProductRecord(header, record)
+		if err != nil {
+			report.addError(CatalogTypeProducts, i+2, product.SKU, err.Error())
+			document.invalid[i] = true
+		}
+		document.Products = append(document.Products, product)
+	}
+	return document, nil
+}
+
+func decodeCatalogJSON(catalogType string, r io.Reader) (CatalogDocument, error) {
+	buffered := bufio.NewReader(r)
+	var first byte
+	for {
+		b, err := buffered.ReadByte()
+		if err != nil {
+			return CatalogDocument{}, fmt.Errorf("file is empty")
+		}
+		if b != ' ' && b != '\n' && b != '\r' && b != '\t' {
+			first = b
+			buffered.UnreadByte()
+			break
+		}
+	}
+
+	document := CatalogDocument{firstRow: 1}
+	decoder := json.NewDecoder(buffered)
+	decoder.UseNumber()
+
+	var err error
+	switch {
+	case first == '{':
+		err = decoder.Decode(&document)
+	case catalogType == CatalogTypeCategories:
+		err = decoder.Decode(&document.Categories)
+	default:
+		err = decoder.Decode(&document.Products)
+	}
+	if err != nil {
+		return document, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	// json.Number keeps attribute values precise; convert them for validation
+	for i := range document.Products {
+		for code, value := range document.Products[i].Attributes {
+			if number, ok := value.(json.Number); ok {
+				document.Products[i].Attributes[code] = number.String()
+			}
+		}
+	}
+	return document, nil
+}
+
+func checkCSVHeader(header, known []string, allowAttributes bool) error {
+	allowed := make(map[string]bool, len(known))
+	for _, column := range known {
+		allowed[column] = true
+	}
+	for _, column := range header {
+		if allowed[column] || (allowAttributes && strings.HasPrefix(column, catalogAttributePrefix)) {
+			continue
+		}
+		return fmt.Errorf("unknown column: %s", column)
+	}
+	return nil
+}
+
+func parseCategoryRecord(header, record []string) (CatalogCategory, error) {
+	var category CatalogCategory
+	for i, column := range header {
+		if i >= len(record) {
+			break
+		}
+		value := strings.TrimSpace(record[i])
+		switch column {
+		case "slug":
+			category.Slug = value
+		case "name":
+			category.Name = value
+		case "description":
+			category.Description = value
+		case "image":
+			category.Image = value
+		case "parent":
+			category.Parent = value
+		case "sort_order":
+			if value == "" {
+				continue
+			}
+			sortOrder, err := strconv.Atoi(value)
+			if err != nil {
+				return category, fmt.Errorf("invalid sort_order: %s", value)
+			}
+			category.SortOrder = &sortOrder
+		}
+	}
+	return category, nil
+}
+
+func parseProductRecord(header, record []string) (CatalogProduct, error) {
+	product := CatalogProduct{Attributes: map[string]interface{}{}}
+	for i, column := range header {
+		if i >= len(record) {
+			break
+		}
+		value := strings.TrimSpace(record[i])
+		switch {
+		case column == "sku":
+			product.SKU = value
+		case column == "name":
+			product.Name = value
+		case column == "description":
+			product.Description = value
+		case column == "price":
+			if value == "" {
+				continue
+			}
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return product, fmt.Errorf("invalid price: %s", value)
+			}
+			product.Price = &price
+		case column == "stock":
+			if value == "" {
+				continue
+			}
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				return product, fmt.Errorf("invalid stock: %s", value)
+			}
+			product.Stock = &stock
+		case column == "image":
+			product.Image = value
+		case column == "category":
+			product.Category = value
+		case column == "status":
+			product.Status = strings.ToLower(value)
+		case column == "publish_at", column == "unpublish_at":
+			if value == "" {
+				continue
+			}
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return product, fmt.Errorf("invalid %s: %s", column, value)
+			}
+			if column == "publish_at" {
+				product.PublishAt = &at
+			} else {
+				product.UnpublishAt = &at
+			}
+		case strings.HasPrefix(column, catalogAttributePrefix):
+			if value != "" {
+				product.Attributes[strings.TrimPrefix(column, catalogAttributePrefix)] = value
+			}
+		}
+	}
+	return product, nil
+}
+
+// WriteCatalogExport streams the catalog in a format RunCatalogImport accepts,
+// so an export can be edited and imported again.
+func WriteCatalogExport(format, catalogType string, w io.Writer) error {
+	if format == CatalogFormatCSV {
+		if catalogType == CatalogTypeCategories {
+			return writeCategoriesCSV(w)
+		}
+		return writeProductsCSV(w)
+	}
+
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
+	if catalogType != CatalogTypeProducts {
+		if _, err := io.WriteString(w, `"categories":`); err != nil {
+			return err
+		}
+		categories, err := exportCategories()
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(w).Encode(categories); err != nil {
+			return err
+		}
+	}
+	if catalogType != CatalogTypeCategories {
+		prefix := `"products":[`
+		if catalogType == CatalogTypeAll {
+			prefix = "," + prefix
+		}
+		if _, err := io.WriteString(w, prefix); err != nil {
+			return err
+		}
+		first := true
+		err := eachExportProduct(func(product CatalogProduct) error {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			data, err := json.Marshal(product)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "]"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+func exportCategories() ([]CatalogCategory, error) {
+	var categories []models.Category
+	if err := database.DB.Order("depth ASC, sort_order ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	slugs := make(map[uint]string, len(categories))
+	for _, category := range categories {
+		slugs[category.ID] = category.Slug
+	}
+
+	rows := make([]CatalogCategory, 0, len(categories))
+	for _, category := range categories {
+		sortOrder := category.SortOrder
+		row := CatalogCategory{
+			Slug:        category.Slug,
+			Name:        category.Name,
+			Description: category.Description,
+			Image:       category.Image,
+			SortOrder:   &sortOrder,
+		}
+		if category.ParentID != nil {
+			row.Parent = slugs[*category.ParentID]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func writeCategoriesCSV(w io.Writer) error {
+	categories, err := exportCategories()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(categoryCSVHeader); err != nil {
+		return err
+	}
+	for _, category := range categories {
+		record := []string{
+			category.Slug,
+			category.Name,
+			category.Description,
+			category.Image,
+			category.Parent,
+			strconv.Itoa(*category.SortOrder),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeProductsCSV(w io.Writer) error {
+	var codes []string
+	if err := database.DB.Model(&models.AttributeDefinition{}).Distinct().Order("code ASC").Pluck("code", &codes).Error; err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := append([]string{}, productCSVHeader...)
+	for _, code := range codes {
+		header = append(header, catalogAttributePrefix+code)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := eachExportProduct(func(product CatalogProduct) error {
+		record := []string{
+			product.SKU,
+			product.Name,
+			product.Description,
+			strconv.FormatFloat(*product.Price, 'f', 2, 64),
+			strconv.Itoa(*product.Stock),
+			product.Image,
+			product.Category,
+			product.Status,
+			formatCatalogTime(product.PublishAt),
+			formatCatalogTime(product.UnpublishAt),
+		}
+		for _, code := range codes {
+			record = append(record, formatAttributeCell(product.Attributes[code]))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// eachExportProduct walks all products in batches so large catalogs are never
+// held in memory at once.
+func eachExportProduct(fn func(CatalogProduct) error) error {
+	var products []models.Product
+	var callbackErr error
+	result := database.DB.Preload("Category").Preload("AttributeValues.Attribute").
+		Order("id ASC").FindInBatches(&products, 200, func(tx *gorm.DB, batch int) error {
+		for _, product := range products {
+			price := product.Price
+			stock := product.Stock
+			row := CatalogProduct{
+				SKU:         product.SKU,
+				Name:        product.Name,
+				Description: product.Description,
+				Price:       &price,
+				Stock:       &stock,
+				Image:       product.Image,
+				Category:    product.Category.Slug,
+				Status:      product.Status,
+				PublishAt:   product.PublishAt,
+				UnpublishAt: product.UnpublishAt,
+			}
+			if len(product.AttributeValues) > 0 {
+				row.Attributes = make(map[string]interface{}, len(product.AttributeValues))
+				for _, value := range product.AttributeValues {
+					row.Attributes[value.Attribute.Code] = value.TypedValue()
+				}
+			}
+			if err := fn(row); err != nil {
+				callbackErr = err
+				return err
+			}
+		}
+		return nil
+	})
+	if callbackErr != nil {
+		return callbackErr
+	}
+	return result.Error
+}
+
+func formatAttributeCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+func formatCatalogTime(at *time.Time) string {
+	if at == nil {
+		return ""
+	}
+	return at.Format(time.RFC3339)
+}
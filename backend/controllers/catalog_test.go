@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCatalogOptions(t *testing.T) {
+	tests := []struct {
+		format      string
+		catalogType string
+		wantErr     bool
+	}{
+		{CatalogFormatCSV, CatalogTypeProducts, false},
+		{CatalogFormatCSV, CatalogTypeCategories, false},
+		{CatalogFormatCSV, CatalogTypeAll, true},
+		{CatalogFormatJSON, CatalogTypeAll, false},
+		{CatalogFormatJSON, "orders", true},
+		{"xml", CatalogTypeProducts, true},
+	}
+	for _, tt := range tests {
+		err := ValidateCatalogOptions(tt.format, tt.catalogType)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateCatalogOptions(%q, %q) error = %v, wantErr %v", tt.format, tt.catalogType, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCatalogFormatFromName(t *testing.T) {
+	tests := map[string]string{
+		"catalog.json":     CatalogFormatJSON,
+		"Products.CSV":     CatalogFormatCSV,
+		"export.tar.gz":    "",
+		"no-extension":     "",
+		"dir.csv/file.txt": "",
+	}
+	for name, want := range tests {
+		if got := catalogFormatFromName(name); got != want {
+			t.Errorf("catalogFormatFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCheckCSVHeader(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          []string
+		allowAttributes bool
+		wantErr         bool
+	}{
+		{"known columns", []string{"sku", "name", "price"}, true, false},
+		{"attribute columns", []string{"sku", "attr:color"}, true, false},
+		{"attributes not allowed", []string{"slug", "attr:color"}, false, true},
+		{"unknown column", []string{"sku", "weight"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known := productCSVHeader
+			if !tt.allowAttributes {
+				known = categoryCSVHeader
+			}
+			err := checkCSVHeader(tt.header, known, tt.allowAttributes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkCSVHeader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCategoryRecord(t *testing.T) {
+	header := []string{"slug", "name", "parent", "sort_order"}
+
+	category, err := parseCategoryRecord(header, []string{" garden ", "Garden", "home", "3"})
+	if err != nil {
+		t.Fatalf("parseCategoryRecord() error = %v", err)
+	}
+	if category.Slug != "garden" || category.Name != "Garden" || category.Parent != "home" {
+		t.Errorf("parseCategoryRecord() = %+v", category)
+	}
+	if category.SortOrder == nil || *category.SortOrder != 3 {
+		t.Errorf("SortOrder = %v, want 3", category.SortOrder)
+	}
+
+	category, err = parseCategoryRecord(header, []string{"tools", "Tools"})
+	if err != nil {
+		t.Fatalf("short record error = %v", err)
+	}
+	if category.SortOrder != nil {
+		t.Errorf("SortOrder = %v, want nil for a missing cell", *category.SortOrder)
+	}
+
+	category, err = parseCategoryRecord(header, []string{"seeds", "Seeds", "", "first"})
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric sort_order")
+	}
+	if category.Slug != "seeds" {
+		t.Errorf("Slug = %q, want the slug kept for the error report", category.Slug)
+	}
+}
+
+func TestParseProductRecord(t *testing.T) {
+	header := []string{"sku", "name", "price", "stock", "status", "publish_at", "attr:color", "attr:size"}
+
+	product, err := parseProductRecord(header, []string{"SKU-1", "Lamp", "19.90", "4", "Active", "2026-03-01T09:00:00Z", "red", ""})
+	if err != nil {
+		t.Fatalf("parseProductRecord() error = %v", err)
+	}
+	if product.SKU != "SKU-1" || product.Name != "Lamp" || product.Status != "active" {
+		t.Errorf("parseProductRecord() = %+v", product)
+	}
+	if product.Price == nil || *product.Price != 19.90 {
+		t.Errorf("Price = %v, want 19.90", product.Price)
+	}
+	if product.Stock == nil || *product.Stock != 4 {
+		t.Errorf("Stock = %v, want 4", product.Stock)
+	}
+	if want := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC); product.PublishAt == nil || !product.PublishAt.Equal(want) {
+		t.Errorf("PublishAt = %v, want %v", product.PublishAt, want)
+	}
+	if want := map[string]interface{}{"color": "red"}; !reflect.DeepEqual(product.Attributes, want) {
+		t.Errorf("Attributes = %v, want %v", product.Attributes, want)
+	}
+
+	product, err = parseProductRecord(header, []string{"SKU-2", "Chair", "", ""})
+	if err != nil {
+		t.Fatalf("blank cells error = %v", err)
+	}
+	if product.Price != nil || product.Stock != nil {
+		t.Errorf("blank price and stock should stay unset, got %v and %v", product.Price, product.Stock)
+	}
+
+	for _, record := range [][]string{
+		{"SKU-3", "Desk", "cheap"},
+		{"SKU-3", "Desk", "10", "many"},
+		{"SKU-3", "Desk", "10", "1", "", "tomorrow"},
+	} {
+		product, err := parseProductRecord(header, record)
+		if err == nil {
+			t.Errorf("parseProductRecord(%v) expected an error", record)
+		}
+		if product.SKU != "SKU-3" {
+			t.Errorf("SKU = %q, want the SKU kept for the error report", product.SKU)
+		}
+	}
+}
+
+func TestDecodeCatalogCSV(t *testing.T) {
+	input := "\ufeffSKU, Name, Price, attr:Color\nA-1,Lamp,10,red\nA-2,Chair,free,blue\n"
+
+	var report ImportReport
+	document, err := decodeCatalog(CatalogFormatCSV, CatalogTypeProducts, strings.NewReader(input), &report)
+	if err != nil {
+		t.Fatalf("decodeCatalog() error = %v", err)
+	}
+	if len(document.Products) != 2 {
+		t.Fatalf("decoded %d products, want 2", len(document.Products))
+	}
+	if got := document.Products[0].Attributes["Color"]; got != "red" {
+		t.Errorf("attribute code case = %v, want it kept as written", got)
+	}
+	if !document.invalid[1] || document.invalid[0] {
+		t.Errorf("invalid = %v, want only the second record", document.invalid)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Row != 3 || report.Errors[0].Key != "A-2" {
+		t.Errorf("report errors = %+v, want one error on row 3 for A-2", report.Errors)
+	}
+
+	if _, err := decodeCatalog(CatalogFormatCSV, CatalogTypeProducts, strings.NewReader("sku,weight\n"), &report); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+	if _, err := decodeCatalog(CatalogFormatCSV, CatalogTypeProducts, strings.NewReader(""), &report); err == nil {
+		t.Error("expected an error for an empty file")
+	}
+}
+
+func TestDecodeCatalogJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		catalogType    string
+		input          string
+		wantCategories int
+		wantProducts   int
+	}{
+		{"document", CatalogTypeAll, `{"categories":[{"slug":"a"}],"products":[{"sku":"x"}]}`, 1, 1},
+		{"category list", CatalogTypeCategories, ` [{"slug":"a"},{"slug":"b"}]`, 2, 0},
+		{"product list", CatalogTypeProducts, "\n[{\"sku\":\"x\"}]", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := decodeCatalogJSON(tt.catalogType, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("decodeCatalogJSON() error = %v", err)
+			}
+			if len(document.Categories) != tt.wantCategories || len(document.Products) != tt.wantProducts {
+				t.Errorf("decoded %d categories and %d products, want %d and %d",
+					len(document.Categories), len(document.Products), tt.wantCategories, tt.wantProducts)
+			}
+		})
+	}
+
+	document, err := decodeCatalogJSON(CatalogTypeProducts, strings.NewReader(`[{"sku":"x","attributes":{"weight":1.50}}]`))
+	if err != nil {
+		t.Fatalf("decodeCatalogJSON() error = %v", err)
+	}
+	if got := document.Products[0].Attributes["weight"]; got != "1.50" {
+		t.Errorf("number attribute = %#v, want the literal \"1.50\"", got)
+	}
+
+	if _, err := decodeCatalogJSON(CatalogTypeProducts, strings.NewReader("   ")); err == nil {
+		t.Error("expected an error for an empty file")
+	}
+	if _, err := decodeCatalogJSON(CatalogTypeProducts, strings.NewReader("[{")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestFormatAttributeCell(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{2.50, "2.5"},
+		{1e6, "1000000"},
+		{true, "true"},
+		{"red", "red"},
+		{7, "7"},
+	}
+	for _, tt := range tests {
+		if got := formatAttributeCell(tt.value); got != tt.want {
+			t.Errorf("formatAttributeCell(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
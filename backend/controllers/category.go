@@ -1,29 +1,51 @@
-	package controllers
+package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"ecommerce-backend/database"
 	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateCategoryRequest struct {
 	Name        string `json:"name" binding:"required"`
+	Slug        string `json:"slug"`
 	Description string `json:"description"`
 	Image       string `json:"image"`
+	ParentID    *uint  `json:"parent_id"`
+	SortOrder   int    `json:"sort_order"`
 }
 
 type UpdateCategoryRequest struct {
 	Name        string `json:"name"`
+	Slug        string `json:"slug"`
 	Description string `json:"description"`
 	Image       string `json:"image"`
+	ParentID    *uint  `json:"parent_id"`
+	SortOrder   *int   `json:"sort_order"`
 }
 
 func GetCategories(c *gin.Context) {
 	var categories []models.Category
-	
-	if err := database.DB.Find(&categories).Error; err != nil {
+
+	query := database.DB.Order("sort_order ASC, name ASC")
+
+	// Filter by parent if provided ("root" or 0 for top-level categories)
+	if parent := c.Query("parent_id"); parent != "" {
+		if parent == "root" || parent == "0" {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", parent)
+		}
+	}
+
+	if err := query.Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
 		return
 	}
@@ -33,22 +55,31 @@ func GetCategories(c *gin.Context) {
 	})
 }
 
-func GetCategory(c *gin.Context) {
-	id := c.Param("id")
-	categoryID, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+func GetCategoryTree(c *gin.Context) {
+	var categories []models.Category
+	if err := database.DB.Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
 		return
 	}
 
-	var category models.Category
-	if err := database.DB.Preload("Products").First(&category, categoryID).Error; err != nil {
+	c.JSON(http.StatusOK, gin.H{
+		"tree": models.BuildCategoryTree(categories),
+	})
+}
+
+func GetCategory(c *gin.Context) {
+	category, err := findCategoryByIDOrSlug(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 
+	database.DB.Order("sort_order ASC, name ASC").Where("parent_id = ?", category.ID).Find(&category.Children)
+	database.DB.Scopes(models.VisibleProducts(time.Now())).Where("category_id = ?", category.ID).Find(&category.Products)
+
 	c.JSON(http.StatusOK, gin.H{
-		"category": category,
+		"category":    category,
+		"breadcrumbs": models.BuildBreadcrumbs(category.ID, loadCategoryLookup()),
 	})
 }
 
@@ -59,20 +90,51 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// Check if category already exists
-	var existingCategory models.Category
-	if err := database.DB.Where("name = ?", req.Name).First(&existingCategory).Error; err == nil {
+	// Resolve parent category if provided
+	var parent *models.Category
+	if req.ParentID != nil && *req.ParentID != 0 {
+		var parentCategory models.Category
+		if err := database.DB.First(&parentCategory, *req.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+		parent = &parentCategory
+	}
+
+	// Check if category already exists under the same parent
+	if siblingNameTaken(req.Name, parent, 0) {
 		c.JSON(http.StatusConflict, gin.H{"error": "Category already exists"})
 		return
 	}
 
+	slug := utils.Slugify(req.Slug)
+	if slug == "" {
+		slug = utils.Slugify(req.Name)
+	} else if categorySlugTaken(slug, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category slug already exists"})
+		return
+	}
+
 	category := models.Category{
 		Name:        req.Name,
+		Slug:        uniqueCategorySlug(slug, 0),
 		Description: req.Description,
 		Image:       req.Image,
+		SortOrder:   req.SortOrder,
+	}
+	if parent != nil {
+		category.ParentID = &parent.ID
+		category.Depth = parent.Depth + 1
 	}
 
-	if err := database.DB.Create(&category).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		category.Path = category.BuildPath(parent)
+		return tx.Model(&category).Update("path", category.Path).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
 		return
 	}
@@ -103,27 +165,98 @@ func UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	// Check if new name already exists (if name is being updated)
-	if req.Name != "" && req.Name != category.Name {
-		var existingCategory models.Category
-		if err := database.DB.Where("name = ? AND id != ?", req.Name, categoryID).First(&existingCategory).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "Category name already exists"})
-			return
+	// Resolve the target parent: unchanged unless parent_id is present (0 moves to root)
+	var parent *models.Category
+	moving := false
+	if req.ParentID != nil {
+		currentParent := uint(0)
+		if category.ParentID != nil {
+			currentParent = *category.ParentID
+		}
+		moving = *req.ParentID != currentParent
+		if *req.ParentID != 0 {
+			var parentCategory models.Category
+			if err := database.DB.First(&parentCategory, *req.ParentID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+				return
+			}
+			// A category cannot be moved under itself or one of its descendants
+			if parentCategory.ID == category.ID || strings.HasPrefix(parentCategory.Path, category.Path) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Category cannot be moved under itself or its descendants"})
+				return
+			}
+			parent = &parentCategory
+		}
+	} else if category.ParentID != nil {
+		var parentCategory models.Category
+		if err := database.DB.First(&parentCategory, *category.ParentID).Error; err == nil {
+			parent = &parentCategory
 		}
 	}
 
-	// Update category fields
+	// Check if new name already exists among the target siblings
+	name := category.Name
 	if req.Name != "" {
-		category.Name = req.Name
+		name = req.Name
+	}
+	if (name != category.Name || moving) && siblingNameTaken(name, parent, category.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category name already exists"})
+		return
+	}
+
+	if req.Slug != "" {
+		slug := utils.Slugify(req.Slug)
+		if slug == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category slug"})
+			return
+		}
+		if categorySlugTaken(slug, category.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Category slug already exists"})
+			return
+		}
+		category.Slug = slug
 	}
+
+	// Update category fields
+	category.Name = name
 	if req.Description != "" {
 		category.Description = req.Description
 	}
 	if req.Image != "" {
 		category.Image = req.Image
 	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+
+	oldPath := category.Path
+	oldDepth := category.Depth
+	if moving {
+		category.ParentID = nil
+		category.Depth = 0
+		if parent != nil {
+			category.ParentID = &parent.ID
+			category.Depth = parent.Depth + 1
+		}
+		category.Path = category.BuildPath(parent)
+	}
 
-	if err := database.DB.Save(&category).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Parent", "Children", "Products").Save(&category).Error; err != nil {
+			return err
+		}
+		if !moving || oldPath == "" {
+			return nil
+		}
+		// Re-root the materialized paths of every descendant
+		return tx.Model(&models.Category{}).
+			Where("path LIKE ? AND id != ?", oldPath+"%", category.ID).
+			Updates(map[string]interface{}{
+				"path":  gorm.Expr("CONCAT(?, SUBSTRING(path, ?))", category.Path, len(oldPath)+1),
+				"depth": gorm.Expr("depth + ?", category.Depth-oldDepth),
+			}).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
 		return
 	}
@@ -148,6 +281,14 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
+	// Check if category has subcategories
+	var childCount int64
+	database.DB.Model(&models.Category{}).Where("parent_id = ?", categoryID).Count(&childCount)
+	if childCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete category with subcategories"})
+		return
+	}
+
 	// Check if category has products
 	var productCount int64
 	database.DB.Model(&models.Product{}).Where("category_id = ?", categoryID).Count(&productCount)
@@ -165,3 +306,68 @@ func DeleteCategory(c *gin.Context) {
 		"message": "Category deleted successfully",
 	})
 }
+
+func findCategoryByIDOrSlug(value string) (models.Category, error) {
+	var category models.Category
+	if categoryID, err := strconv.ParseUint(value, 10, 32); err == nil {
+		err := database.DB.First(&category, categoryID).Error
+		return category, err
+	}
+	err := database.DB.Where("slug = ?", value).First(&category).Error
+	return category, err
+}
+
+func loadCategoryLookup() map[uint]models.Category {
+	var categories []models.Category
+	database.DB.Find(&categories)
+
+	lookup := make(map[uint]models.Category, len(categories))
+	for _, category := range categories {
+		lookup[category.ID] = category
+	}
+	return lookup
+}
+
+// categorySubtreeQuery selects the IDs of the categories and all of their
+// descendants.
+func categorySubtreeQuery(categories ...models.Category) *gorm.DB {
+	subtrees := database.DB
+	for _, category := range categories {
+		if category.Path == "" {
+			subtrees = subtrees.Or("id = ?", category.ID)
+		} else {
+			subtrees = subtrees.Or("path LIKE ?", category.Path+"%")
+		}
+	}
+	return database.DB.Model(&models.Category{}).Select("id").Where(subtrees)
+}
+
+func siblingNameTaken(name string, parent *models.Category, excludeID uint) bool {
+	query := database.DB.Model(&models.Category{}).Where("name = ? AND id != ?", name, excludeID)
+	if parent == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", parent.ID)
+	}
+
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
+
+func categorySlugTaken(slug string, excludeID uint) bool {
+	var count int64
+	database.DB.Model(&models.Category{}).Where("slug = ? AND id != ?", slug, excludeID).Count(&count)
+	return count > 0
+}
+
+func uniqueCategorySlug(base string, excludeID uint) string {
+	if base == "" {
+		base = "category"
+	}
+	slug := base
+	for i := 2; categorySlugTaken(slug, excludeID); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// quoteTTL is how long a quoted price is honoured by order creation.
+const quoteTTL = 30 * time.Minute
+
+const defaultShippingMethod = "standard"
+
+// shippingMethod prices delivery of an order. FreeOver waives the rate once
+// the discounted subtotal reaches it; zero means never.
+type shippingMethod struct {
+	Name     string  `json:"name"`
+	Label    string  `json:"label"`
+	Rate     float64 `json:"rate"`
+	FreeOver float64 `json:"free_over,omitempty"`
+}
+
+var shippingMethods = map[string]shippingMethod{
+	"standard": {Name: "standard", Label: "Standard delivery", Rate: 5.00, FreeOver: 50.00},
+	"express":  {Name: "express", Label: "Express delivery", Rate: 15.00},
+	"pickup":   {Name: "pickup", Label: "Store pickup", Rate: 0},
+}
+
+type CheckoutQuoteRequest struct {
+	ShippingAddress string `json:"shipping_address" binding:"required"`
+	ShippingMethod  string `json:"shipping_method"`
+	CouponCode      string `json:"coupon_code"`
+}
+
+// checkoutInput is what the customer chose at checkout, shared by quotes and
+// order creation.
+type checkoutInput struct {
+	ShippingMethod string
+	CouponCode     string
+	QuoteID        string
+}
+
+func GetShippingMethods(c *gin.Context) {
+	methods := make([]shippingMethod, 0, len(shippingMethods))
+	for _, method := range shippingMethods {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Rate < methods[j].Rate })
+
+	c.JSON(http.StatusOK, gin.H{
+		"shipping_methods": methods,
+		"default":          defaultShippingMethod,
+	})
+}
+
+// CreateCheckoutQuote prices the current cart with the same code order
+// creation uses and stores the result so the order can be placed at the
+// quoted price.
+func CreateCheckoutQuote(c *gin.Context) {
+	var req CheckoutQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
+		return
+	}
+
+	cartItems, err := loadCheckoutCart(database.DB, owner)
+	if err != nil {
+		respondCheckoutError(c, err)
+		return
+	}
+
+	input := checkoutInput{ShippingMethod: req.ShippingMethod, CouponCode: req.CouponCode}
+	breakdown, _, err := priceCheckout(database.DB, cartItems, input, time.Now())
+	if err != nil {
+		respondCheckoutError(c, err)
+		return
+	}
+
+	reference, err := utils.GenerateToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quote"})
+		return
+	}
+
+	quote := models.CheckoutQuote{
+		Reference:       "q_" + reference,
+		CartFingerprint: cartFingerprint(cartItems),
+		ShippingAddress: req.ShippingAddress,
+		ShippingMethod:  breakdown.ShippingMethod,
+		CouponCode:      breakdown.CouponCode,
+		Lines:           breakdown.Lines,
+		Subtotal:        breakdown.Subtotal,
+		Discount:        breakdown.Discount,
+		Shipping:        breakdown.Shipping,
+		TaxRate:         breakdown.TaxRate,
+		Tax:             breakdown.Tax,
+		Total:           breakdown.Total,
+		ExpiresAt:       time.Now().Add(quoteTTL),
+	}
+	if owner.UserID != 0 {
+		quote.UserID = &owner.UserID
+	} else {
+		quote.GuestCartID = &owner.GuestCartID
+	}
+
+	if err := database.DB.Create(&quote).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quote"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"quote": quote,
+	})
+}
+
+// loadCheckoutCart loads the owner's cart, leaving out items saved for later,
+// and checks that every line can be ordered, reporting all problems at once.
+func loadCheckoutCart(db *gorm.DB, owner cartOwner) ([]models.Cart, error) {
+	var cartItems []models.Cart
+	if err := owner.scope(db).Preload("Product").Where("saved_for_later = ?", false).Find(&cartItems).Error; err != nil {
+		return nil, err
+	}
+
+	if len(cartItems) == 0 {
+		return nil, checkoutError{message: "Cart is empty"}
+	}
+
+	var blocking []models.CartWarning
+	now := time.Now()
+	for _, item := range cartItems {
+		for _, warning := range item.Warnings(now) {
+			if warning.Blocking() {
+				blocking = append(blocking, warning)
+			}
+		}
+	}
+	if len(blocking) == 1 {
+		return nil, checkoutError{message: blocking[0].Message + ": " + blocking[0].ProductName, warnings: blocking}
+	}
+	if len(blocking) > 1 {
+		return nil, checkoutError{message: "Some items in your cart cannot be ordered", warnings: blocking}
+	}
+	return cartItems, nil
+}
+
+// priceCheckout computes the itemized breakdown for a cart: subtotal at
+// current prices, coupon discount, shipping for the chosen method and tax on
+// the discounted goods plus shipping. It returns the applied coupon, if any.
+func priceCheckout(db *gorm.DB, cartItems []models.Cart, input checkoutInput, now time.Time) (models.CheckoutBreakdown, *models.Coupon, error) {
+	breakdown := models.CheckoutBreakdown{Lines: models.QuoteLines{}}
+
+	for _, item := range cartItems {
+		line := models.QuoteLine{
+			ProductID:   item.ProductID,
+			ProductName: item.Product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Product.Price,
+			LineTotal:   roundMoney(item.Product.Price * float64(item.Quantity)),
+		}
+		breakdown.Lines = append(breakdown.Lines, line)
+		breakdown.Subtotal += line.LineTotal
+	}
+	breakdown.Subtotal = roundMoney(breakdown.Subtotal)
+
+	methodName := input.ShippingMethod
+	if methodName == "" {
+		methodName = defaultShippingMethod
+	}
+	method, ok := shippingMethods[methodName]
+	if !ok {
+		return breakdown, nil, checkoutError{message: "Invalid shipping method"}
+	}
+	breakdown.ShippingMethod = method.Name
+
+	var coupon *models.Coupon
+	if code := strings.TrimSpace(input.CouponCode); code != "" {
+		var found models.Coupon
+		if err := db.Where("code = ?", strings.ToUpper(code)).First(&found).Error; err != nil || !found.IsRedeemableAt(now) {
+			return breakdown, nil, checkoutError{message: "Coupon is invalid or has expired"}
+		}
+		if breakdown.Subtotal < found.MinSubtotal {
+			return breakdown, nil, checkoutError{message: fmt.Sprintf("Coupon requires a subtotal of at least %.2f", found.MinSubtotal)}
+		}
+		coupon = &found
+	}
+
+	applyCharges(&breakdown, method, coupon, taxRate())
+	return breakdown, coupon, nil
+}
+
+// applyCharges fills in the discount, shipping, tax and total of a breakdown
+// whose subtotal is known. Tax is charged on the discounted goods plus
+// shipping.
+func applyCharges(breakdown *models.CheckoutBreakdown, method shippingMethod, coupon *models.Coupon, rate float64) {
+	freeShipping := false
+	breakdown.Discount = 0
+	if coupon != nil {
+		switch coupon.Type {
+		case models.CouponTypePercent:
+			breakdown.Discount = roundMoney(breakdown.Subtotal * coupon.Value / 100)
+		case models.CouponTypeFixed:
+			breakdown.Discount = math.Min(coupon.Value, breakdown.Subtotal)
+		case models.CouponTypeFreeShipping:
+			freeShipping = true
+		}
+		breakdown.CouponCode = coupon.Code
+	}
+
+	goods := breakdown.Subtotal - breakdown.Discount
+	breakdown.Shipping = 0
+	if !freeShipping && (method.FreeOver == 0 || goods < method.FreeOver) {
+		breakdown.Shipping = method.Rate
+	}
+
+	breakdown.TaxRate = rate
+	breakdown.Tax = roundMoney((goods + breakdown.Shipping) * breakdown.TaxRate)
+	breakdown.Total = roundMoney(goods + breakdown.Shipping + breakdown.Tax)
+}
+
+// quoteMatches reports whether the choices made when placing an order are the
+// ones the quote was priced with. Blank shipping methods and coupon codes are
+// read the way priceCheckout reads them.
+func quoteMatches(quote models.CheckoutQuote, input checkoutInput, shippingAddress string) bool {
+	method := input.ShippingMethod
+	if method == "" {
+		method = defaultShippingMethod
+	}
+	return method == quote.ShippingMethod &&
+		strings.ToUpper(strings.TrimSpace(input.CouponCode)) == quote.CouponCode &&
+		shippingAddress == quote.ShippingAddress
+}
+
+// cartFingerprint identifies the products and quantities in a cart, so a
+// quote is only honoured for the cart it was computed from.
+func cartFingerprint(cartItems []models.Cart) string {
+	lines := make([]string, 0, len(cartItems))
+	for _, item := range cartItems {
+		lines = append(lines, fmt.Sprintf("%d:%d", item.ProductID, item.Quantity))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// taxRate reads TAX_RATE as a fraction, e.g. 0.08 for 8%.
+func taxRate() float64 {
+	rate, err := strconv.ParseFloat(os.Getenv("TAX_RATE"), 64)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestApplyCharges(t *testing.T) {
+	standard := shippingMethods["standard"]
+	express := shippingMethods["express"]
+
+	tests := []struct {
+		name     string
+		subtotal float64
+		method   shippingMethod
+		coupon   *models.Coupon
+		rate     float64
+		want     models.CheckoutBreakdown
+	}{
+		{
+			name:     "standard below free shipping",
+			subtotal: 40, method: standard, rate: 0.1,
+			want: models.CheckoutBreakdown{Subtotal: 40, Shipping: 5, TaxRate: 0.1, Tax: 4.5, Total: 49.5},
+		},
+		{
+			name:     "standard ships free at threshold",
+			subtotal: 50, method: standard,
+			want: models.CheckoutBreakdown{Subtotal: 50, Total: 50},
+		},
+		{
+			name:     "express never free",
+			subtotal: 200, method: express,
+			want: models.CheckoutBreakdown{Subtotal: 200, Shipping: 15, Total: 215},
+		},
+		{
+			name:     "percent coupon",
+			subtotal: 80, method: express, rate: 0.08,
+			coupon: &models.Coupon{Code: "TENOFF", Type: models.CouponTypePercent, Value: 10},
+			want:   models.CheckoutBreakdown{Subtotal: 80, Discount: 8, Shipping: 15, TaxRate: 0.08, Tax: 6.96, Total: 93.96, CouponCode: "TENOFF"},
+		},
+		{
+			name:     "discount drops below free shipping",
+			subtotal: 55, method: standard,
+			coupon: &models.Coupon{Code: "FIVE", Type: models.CouponTypeFixed, Value: 10},
+			want:   models.CheckoutBreakdown{Subtotal: 55, Discount: 10, Shipping: 5, Total: 50, CouponCode: "FIVE"},
+		},
+		{
+			name:     "fixed coupon capped at subtotal",
+			subtotal: 20, method: shippingMethods["pickup"],
+			coupon: &models.Coupon{Code: "BIG", Type: models.CouponTypeFixed, Value: 30},
+			want:   models.CheckoutBreakdown{Subtotal: 20, Discount: 20, Total: 0, CouponCode: "BIG"},
+		},
+		{
+			name:     "free shipping coupon",
+			subtotal: 30, method: express, rate: 0.1,
+			coupon: &models.Coupon{Code: "SHIP", Type: models.CouponTypeFreeShipping},
+			want:   models.CheckoutBreakdown{Subtotal: 30, TaxRate: 0.1, Tax: 3, Total: 33, CouponCode: "SHIP"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := models.CheckoutBreakdown{Subtotal: tt.subtotal, Discount: 99, Shipping: 99}
+			applyCharges(&breakdown, tt.method, tt.coupon, tt.rate)
+			if !reflect.DeepEqual(breakdown, tt.want) {
+				t.Errorf("applyCharges() = %+v, want %+v", breakdown, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceCheckoutWithoutCoupon(t *testing.T) {
+	t.Setenv("TAX_RATE", "0.1")
+	cartItems := []models.Cart{
+		{ProductID: 1, Quantity: 3, Product: models.Product{Name: "Mug", Price: 3.33}},
+		{ProductID: 2, Quantity: 1, Product: models.Product{Name: "Lamp", Price: 20}},
+	}
+
+	breakdown, coupon, err := priceCheckout(nil, cartItems, checkoutInput{}, time.Now())
+	if err != nil {
+		t.Fatalf("priceCheckout() error = %v", err)
+	}
+	if coupon != nil {
+		t.Errorf("coupon = %+v, want none", coupon)
+	}
+	if len(breakdown.Lines) != 2 || breakdown.Lines[0].LineTotal != 9.99 {
+		t.Errorf("lines = %+v, want two lines starting with 9.99", breakdown.Lines)
+	}
+	want := models.CheckoutBreakdown{Subtotal: 29.99, Shipping: 5, TaxRate: 0.1, Tax: 3.5, Total: 38.49, ShippingMethod: defaultShippingMethod}
+	breakdown.Lines = nil
+	if !reflect.DeepEqual(breakdown, want) {
+		t.Errorf("priceCheckout() = %+v, want %+v", breakdown, want)
+	}
+
+	if _, _, err := priceCheckout(nil, cartItems, checkoutInput{ShippingMethod: "drone"}, time.Now()); err == nil {
+		t.Error("priceCheckout() accepted an unknown shipping method")
+	} else if _, ok := err.(checkoutError); !ok {
+		t.Errorf("error = %T, want a checkoutError", err)
+	}
+}
+
+func TestQuoteMatches(t *testing.T) {
+	quote := models.CheckoutQuote{ShippingMethod: defaultShippingMethod, CouponCode: "TENOFF", ShippingAddress: "1 Main St"}
+
+	tests := []struct {
+		name    string
+		input   checkoutInput
+		address string
+		want    bool
+	}{
+		{"same choices", checkoutInput{ShippingMethod: defaultShippingMethod, CouponCode: "TENOFF"}, "1 Main St", true},
+		{"default method and coupon as typed", checkoutInput{CouponCode: " tenoff "}, "1 Main St", true},
+		{"other method", checkoutInput{ShippingMethod: "express", CouponCode: "TENOFF"}, "1 Main St", false},
+		{"coupon dropped", checkoutInput{}, "1 Main St", false},
+		{"other coupon", checkoutInput{CouponCode: "SHIP"}, "1 Main St", false},
+		{"other address", checkoutInput{CouponCode: "TENOFF"}, "2 High St", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteMatches(quote, tt.input, tt.address); got != tt.want {
+				t.Errorf("quoteMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCartFingerprint(t *testing.T) {
+	a := []models.Cart{{ProductID: 1, Quantity: 2}, {ProductID: 5, Quantity: 1}}
+	reordered := []models.Cart{{ProductID: 5, Quantity: 1}, {ProductID: 1, Quantity: 2}}
+	changed := []models.Cart{{ProductID: 1, Quantity: 3}, {ProductID: 5, Quantity: 1}}
+
+	if cartFingerprint(a) != cartFingerprint(reordered) {
+		t.Error("fingerprint depends on the order of cart lines")
+	}
+	if cartFingerprint(a) == cartFingerprint(changed) {
+		t.Error("fingerprint ignores a quantity change")
+	}
+	if len(cartFingerprint(nil)) != 64 {
+		t.Errorf("fingerprint = %q, want a SHA-256 hex digest", cartFingerprint(nil))
+	}
+}
+
+func TestTaxRate(t *testing.T) {
+	tests := map[string]float64{
+		"0.08": 0.08,
+		"":     0,
+		"high": 0,
+		"-0.1": 0,
+	}
+	for value, want := range tests {
+		t.Setenv("TAX_RATE", value)
+		if got := taxRate(); got != want {
+			t.Errorf("taxRate() with TAX_RATE=%q = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestRoundMoney(t *testing.T) {
+	tests := map[float64]float64{
+		1.005:     1.0,
+		2.675:     2.68,
+		9.999:     10,
+		-1.234:    -1.23,
+		0.1 + 0.2: 0.3,
+	}
+	for in, want := range tests {
+		if got := roundMoney(in); got != want {
+			t.Errorf("roundMoney(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestLoadCheckoutCartLeavesOutSavedItems(t *testing.T) {
+	db := newDryRunDB(t)
+	statements := recordStatements(db)
+
+	_, err := loadCheckoutCart(db, cartOwner{UserID: 3})
+	if checkoutErr, ok := err.(checkoutError); !ok || checkoutErr.message != "Cart is empty" {
+		t.Errorf("loadCheckoutCart() error = %v, want the empty cart error", err)
+	}
+	if len(*statements) != 1 {
+		t.Fatalf("statements = %+v, want one query", *statements)
+	}
+	if query := (*statements)[0]; query.Table != "carts" || query.Where["user_id"] != uint(3) || query.Where["saved_for_later"] != false {
+		t.Errorf("query = %+v, want user 3's cart lines not saved for later", query)
+	}
+}
+
+func TestSaveCartItemForLaterRejectsInvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/cart/abc/save-for-later", nil)
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+	SaveCartItemForLater(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CouponRequest struct {
+	Code        string     `json:"code" binding:"required,max=50"`
+	Description string     `json:"description" binding:"max=255"`
+	Type        string     `json:"type" binding:"required"`
+	Value       float64    `json:"value" binding:"gte=0"`
+	MinSubtotal float64    `json:"min_subtotal" binding:"gte=0"`
+	StartsAt    *time.Time `json:"starts_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	UsageLimit  int        `json:"usage_limit" binding:"gte=0"`
+	Active      *bool      `json:"active"`
+}
+
+func GetCoupons(c *gin.Context) {
+	var coupons []models.Coupon
+	if err := database.DB.Order("created_at DESC").Find(&coupons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupons": coupons,
+	})
+}
+
+func CreateCoupon(c *gin.Context) {
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var coupon models.Coupon
+	if !applyCouponRequest(c, &coupon, req) {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.Coupon{}).Where("code = ?", coupon.Code).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Coupon code already exists"})
+		return
+	}
+
+	if err := database.DB.Create(&coupon).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create coupon"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Coupon created successfully",
+		"coupon":  coupon,
+	})
+}
+
+func UpdateCoupon(c *gin.Context) {
+	id := c.Param("id")
+	couponID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var coupon models.Coupon
+	if err := database.DB.First(&coupon, couponID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+
+	if !applyCouponRequest(c, &coupon, req) {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.Coupon{}).Where("code = ? AND id != ?", coupon.Code, coupon.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Coupon code already exists"})
+		return
+	}
+
+	if err := database.DB.Save(&coupon).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update coupon"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon updated successfully",
+		"coupon":  coupon,
+	})
+}
+
+func DeleteCoupon(c *gin.Context) {
+	id := c.Param("id")
+	couponID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	result := database.DB.Delete(&models.Coupon{}, couponID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete coupon"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Coupon deleted successfully",
+	})
+}
+
+// applyCouponRequest validates the request and copies it onto the coupon.
+// Codes are stored upper case so customers can enter them in any case.
+func applyCouponRequest(c *gin.Context, coupon *models.Coupon, req CouponRequest) bool {
+	if !models.IsValidCouponType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon type"})
+		return false
+	}
+	if req.Type == models.CouponTypePercent && (req.Value <= 0 || req.Value > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Percent coupons need a value between 0 and 100"})
+		return false
+	}
+	if req.Type == models.CouponTypeFixed && req.Value <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fixed coupons need a value greater than 0"})
+		return false
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be after starts_at"})
+		return false
+	}
+
+	coupon.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	coupon.Description = req.Description
+	coupon.Type = req.Type
+	coupon.Value = req.Value
+	coupon.MinSubtotal = req.MinSubtotal
+	coupon.StartsAt = req.StartsAt
+	coupon.ExpiresAt = req.ExpiresAt
+	coupon.UsageLimit = req.UsageLimit
+	if req.Active != nil {
+		coupon.Active = *req.Active
+	} else if coupon.ID == 0 {
+		coupon.Active = true
+	}
+	return true
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderItemQuantityRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+type ReturnOrderItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+	// Restock puts the returned units back into stock; defaults to true.
+	Restock *bool `json:"restock"`
+	// WarehouseID is where restocked units go; defaults to the warehouse
+	// the line was first allocated from.
+	WarehouseID *uint `json:"warehouse_id"`
+}
+
+// orderItemError is a line change the order's current state does not allow,
+// reported to the admin as is.
+type orderItemError string
+
+func (e orderItemError) Error() string {
+	return string(e)
+}
+
+// FulfillOrderItem records units of a line as shipped.
+func FulfillOrderItem(c *gin.Context) {
+	var req OrderItemQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changeOrderItem(c, "Order item fulfilled successfully", func(tx *gorm.DB, order *models.Order, item *models.OrderItem) ([]notifications.Event, error) {
+		if req.Quantity > item.OpenQuantity() {
+			return nil, orderItemError("Quantity exceeds the unfulfilled quantity of the item")
+		}
+		item.FulfilledQuantity += req.Quantity
+		return nil, saveOrderItem(tx, item)
+	})
+}
+
+// CancelOrderItem cancels unshipped units of a line, putting them back into
+// stock and refunding their share of the order total.
+func CancelOrderItem(c *gin.Context) {
+	var req OrderItemQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changeOrderItem(c, "Order item cancelled successfully", func(tx *gorm.DB, order *models.Order, item *models.OrderItem) ([]notifications.Event, error) {
+		if req.Quantity > item.OpenQuantity() {
+			return nil, orderItemError("Quantity exceeds the unfulfilled quantity of the item")
+		}
+		return cancelOrderItem(tx, order, item, req.Quantity)
+	})
+}
+
+// ReturnOrderItem records shipped units of a line as returned and refunds
+// them, restocking them unless asked not to.
+func ReturnOrderItem(c *gin.Context) {
+	var req ReturnOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changeOrderItem(c, "Order item returned successfully", func(tx *gorm.DB, order *models.Order, item *models.OrderItem) ([]notifications.Event, error) {
+		if req.Quantity > item.FulfilledQuantity-item.ReturnedQuantity {
+			return nil, orderItemError("Quantity exceeds the shipped quantity not yet returned")
+		}
+
+		item.ReturnedQuantity += req.Quantity
+		refundOrderItem(order, item, req.Quantity)
+
+		var events []notifications.Event
+		if req.Restock == nil || *req.Restock {
+			var err error
+			if events, err = returnStock(tx, *item, req.Quantity, req.WarehouseID); err != nil {
+				return nil, err
+			}
+		}
+		return events, saveOrderItem(tx, item)
+	})
+}
+
+// changeOrderItem applies change to the line named in the URL with the order
+// locked, then re-derives the order's status and responds with the order.
+func changeOrderItem(c *gin.Context, message string, change func(tx *gorm.DB, order *models.Order, item *models.OrderItem) ([]notifications.Event, error)) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order item ID"})
+		return
+	}
+
+	var order models.Order
+	var events []notifications.Event
+	var refunded float64
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, &order, uint(orderID)); err != nil {
+			return err
+		}
+
+		var item *models.OrderItem
+		for i := range order.OrderItems {
+			if order.OrderItems[i].ID == uint(itemID) {
+				item = &order.OrderItems[i]
+			}
+		}
+		if item == nil {
+			return gorm.ErrRecordNotFound
+		}
+
+		before := order.RefundedAmount
+		if events, err = change(tx, &order, item); err != nil {
+			return err
+		}
+		if err := saveOrderProgress(tx, &order); err != nil {
+			return err
+		}
+		refunded = roundMoney(order.RefundedAmount - before)
+		return nil
+	})
+	switch err.(type) {
+	case orderItemError, inventoryError:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order item"})
+		return
+	}
+	notifications.Publish(events...)
+
+	// Load order with relations
+	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       message,
+		"refund_amount": refunded,
+		"order":         order.ToResponse(),
+	})
+}
+
+// lockOrder loads an order and its lines, locking the order row so
+// concurrent line changes apply one after another.
+func lockOrder(tx *gorm.DB, order *models.Order, orderID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("OrderItems").First(order, orderID).Error
+}
+
+// cancelOrderItem cancels quantity open units of item, restocking and
+// refunding them. The caller saves the order.
+func cancelOrderItem(tx *gorm.DB, order *models.Order, item *models.OrderItem, quantity int) ([]notifications.Event, error) {
+	item.CancelledQuantity += quantity
+	refundOrderItem(order, item, quantity)
+
+	events, err := releaseStock(tx, *item, quantity, models.StockReasonCancellation)
+	if err != nil {
+		return nil, err
+	}
+	return events, saveOrderItem(tx, item)
+}
+
+// refundOrderItem adds the refund for quantity units of item to the line and
+// the order. Units are refunded at their price less their share of the
+// order discount, plus their share of the tax.
+func refundOrderItem(order *models.Order, item *models.OrderItem, quantity int) {
+	goods := item.Price * float64(quantity)
+	refund := goods
+	if order.Subtotal > 0 {
+		discount := order.DiscountAmount * goods / order.Subtotal
+		refund = goods - discount
+		if taxable := order.Subtotal - order.DiscountAmount + order.ShippingAmount; taxable > 0 {
+			refund += order.TaxAmount * refund / taxable
+		}
+	}
+
+	refund = roundMoney(refund)
+	if remaining := roundMoney(order.TotalAmount - order.RefundedAmount); refund > remaining {
+		refund = remaining
+	}
+	item.RefundedAmount = roundMoney(item.RefundedAmount + refund)
+	order.RefundedAmount = roundMoney(order.RefundedAmount + refund)
+}
+
+func saveOrderItem(tx *gorm.DB, item *models.OrderItem) error {
+	item.Status = item.DeriveStatus()
+	return tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+		"fulfilled_quantity": item.FulfilledQuantity,
+		"cancelled_quantity": item.CancelledQuantity,
+		"returned_quantity":  item.ReturnedQuantity,
+		"refunded_amount":    item.RefundedAmount,
+		"status":             item.Status,
+	}).Error
+}
+
+// saveOrderProgress re-derives the order's status from its lines. Once every
+// line is cancelled whatever has not been refunded yet, shipping included,
+// is refunded with the last one.
+func saveOrderProgress(tx *gorm.DB, order *models.Order) error {
+	order.Status = order.DeriveStatus()
+	if order.Status == models.OrderStatusCancelled {
+		order.RefundedAmount = order.TotalAmount
+	}
+	return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"status":          order.Status,
+		"refunded_amount": order.RefundedAmount,
+	}).Error
+}
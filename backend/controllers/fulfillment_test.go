@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRefundOrderItem(t *testing.T) {
+	// 100 of goods less a 10 discount, 5 shipping and 10% tax on the rest
+	itemized := models.Order{Subtotal: 100, DiscountAmount: 10, ShippingAmount: 5, TaxAmount: 9.5, TotalAmount: 104.5}
+
+	tests := []struct {
+		name         string
+		order        models.Order
+		item         models.OrderItem
+		quantity     int
+		wantRefund   float64
+		wantOrderSum float64
+	}{
+		{"discount and tax share", itemized, models.OrderItem{Quantity: 5, Price: 20}, 1, 19.8, 19.8},
+		{"several units", itemized, models.OrderItem{Quantity: 5, Price: 20}, 3, 59.4, 59.4},
+		{"adds to earlier refunds", withRefunded(itemized, 19.8), models.OrderItem{Quantity: 5, Price: 20, RefundedAmount: 19.8}, 1, 39.6, 39.6},
+		{"capped at what is left", withRefunded(itemized, 100), models.OrderItem{Quantity: 5, Price: 20}, 1, 4.5, 104.5},
+		{"order without itemized totals", models.Order{TotalAmount: 30}, models.OrderItem{Quantity: 3, Price: 10}, 2, 20, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, item := tt.order, tt.item
+			refundOrderItem(&order, &item, tt.quantity)
+			if item.RefundedAmount != tt.wantRefund {
+				t.Errorf("item RefundedAmount = %v, want %v", item.RefundedAmount, tt.wantRefund)
+			}
+			if order.RefundedAmount != tt.wantOrderSum {
+				t.Errorf("order RefundedAmount = %v, want %v", order.RefundedAmount, tt.wantOrderSum)
+			}
+		})
+	}
+}
+
+func withRefunded(order models.Order, refunded float64) models.Order {
+	order.RefundedAmount = refunded
+	return order
+}
+
+func TestSaveOrderProgressRefundsCancelledOrders(t *testing.T) {
+	order := models.Order{
+		ID: 5, Status: "confirmed", TotalAmount: 104.5, RefundedAmount: 99.5,
+		OrderItems: []models.OrderItem{{Quantity: 1, CancelledQuantity: 1}},
+	}
+	db := newDryRunDB(t)
+	statements := recordStatements(db)
+	if err := saveOrderProgress(db, &order); err != nil {
+		t.Fatalf("saveOrderProgress() error = %v", err)
+	}
+	if order.Status != models.OrderStatusCancelled || order.RefundedAmount != 104.5 {
+		t.Errorf("order = %s refunded %v, want cancelled with everything refunded", order.Status, order.RefundedAmount)
+	}
+	if len(*statements) != 1 {
+		t.Fatalf("statements = %+v, want one update", *statements)
+	}
+	saved := (*statements)[0]
+	if saved.Action != "update" || saved.Table != "orders" || saved.Where["id"] != uint(5) {
+		t.Errorf("statement = %+v, want an update of order 5", saved)
+	}
+	if saved.Values["status"] != models.OrderStatusCancelled || saved.Values["refunded_amount"] != 104.5 {
+		t.Errorf("saved = %+v, want the cancelled status and full refund stored", saved.Values)
+	}
+}
+
+func TestSaveOrderItemStoresDerivedStatus(t *testing.T) {
+	item := models.OrderItem{ID: 8, Quantity: 3, FulfilledQuantity: 1, Status: models.OrderItemPending}
+	if err := saveOrderItem(newDryRunDB(t), &item); err != nil {
+		t.Fatalf("saveOrderItem() error = %v", err)
+	}
+	if item.Status != models.OrderItemPartiallyFulfilled {
+		t.Errorf("Status = %q, want %q", item.Status, models.OrderItemPartiallyFulfilled)
+	}
+}
+
+func TestChangeOrderItemRejectsInvalidIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []gin.Params{
+		{{Key: "id", Value: "abc"}, {Key: "itemId", Value: "1"}},
+		{{Key: "id", Value: "1"}, {Key: "itemId", Value: "-"}},
+	}
+	for _, params := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = params
+		changeOrderItem(c, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("params %v: status = %d, want %d", params, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type GuestCheckoutRequest struct {
+	Email           string `json:"email" binding:"required,email"`
+	Name            string `json:"name" binding:"max=200"`
+	ShippingAddress string `json:"shipping_address" binding:"required"`
+	PaymentMethod   string `json:"payment_method" binding:"required"`
+	ShippingMethod  string `json:"shipping_method"`
+	CouponCode      string `json:"coupon_code"`
+	QuoteID         string `json:"quote_id"`
+}
+
+type ClaimOrderRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// GuestCheckout places an order from the guest cart named by the
+// X-Cart-Token header and emails a lookup link for it.
+func GuestCheckout(c *gin.Context) {
+	var req GuestCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner, ok := resolveCartOwner(c, false)
+	if !ok {
+		return
+	}
+	if owner.GuestCartID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is empty"})
+		return
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate lookup token"})
+		return
+	}
+
+	order := models.Order{
+		GuestEmail:      strings.ToLower(strings.TrimSpace(req.Email)),
+		GuestName:       req.Name,
+		LookupToken:     utils.HashToken(token),
+		ShippingAddress: req.ShippingAddress,
+		PaymentMethod:   req.PaymentMethod,
+	}
+	input := checkoutInput{ShippingMethod: req.ShippingMethod, CouponCode: req.CouponCode, QuoteID: req.QuoteID}
+	if err := placeOrder(owner, &order, input); err != nil {
+		respondCheckoutError(c, err)
+		return
+	}
+
+	sendGuestOrderEmail(order, token)
+
+	// Load order with relations
+	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Order created successfully",
+		"order":        order.ToResponse(),
+		"lookup_token": token,
+		"lookup_url":   guestOrderLookupURL(token),
+	})
+}
+
+// LookupGuestOrder shows a guest order to whoever holds its lookup token.
+func LookupGuestOrder(c *gin.Context) {
+	var order models.Order
+	if err := database.DB.Preload("OrderItems.Product.Category").
+		Where("lookup_token = ?", utils.HashToken(c.Param("token"))).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order": order.ToResponse(),
+	})
+}
+
+// ClaimGuestOrder attaches a guest order to the current user's account. The
+// account email must match the email the order was placed with.
+func ClaimGuestOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ClaimOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.Order
+	if err := database.DB.Where("lookup_token = ?", utils.HashToken(req.Token)).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	if order.UserID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order has already been claimed"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !strings.EqualFold(user.Email, order.GuestEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Order was placed with a different email address"})
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&order).Updates(map[string]interface{}{
+		"user_id":    user.ID,
+		"claimed_at": now,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim order"})
+		return
+	}
+
+	// Load order with relations
+	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order claimed successfully",
+		"order":   order.ToResponse(),
+	})
+}
+
+func guestOrderLookupURL(token string) string {
+	return os.Getenv("FRONTEND_URL") + "/orders/lookup/" + token
+}
+
+func sendGuestOrderEmail(order models.Order, token string) {
+	message := notifications.Message{
+		Email:   order.GuestEmail,
+		Type:    "order.guest_confirmation",
+		Subject: fmt.Sprintf("Your order %s", order.OrderNumber),
+		Body: fmt.Sprintf("Thanks for your order! Your total is %.2f. Use the link below to check on your order, "+
+			"or create an account with this email address to keep track of it.", order.TotalAmount),
+		Link: guestOrderLookupURL(token),
+	}
+	if err := notifications.Send(notifications.ChannelEmail, message); err != nil {
+		log.Printf("Failed to send confirmation for guest order %d: %v", order.ID, err)
+	}
+}
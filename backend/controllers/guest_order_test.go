@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingChannel struct {
+	sent []notifications.Message
+}
+
+func (r *recordingChannel) Send(message notifications.Message) error {
+	r.sent = append(r.sent, message)
+	return nil
+}
+
+// recordEmails replaces the email channel for the duration of the test.
+func recordEmails(t *testing.T) *recordingChannel {
+	t.Helper()
+	channel := &recordingChannel{}
+	notifications.RegisterChannel(notifications.ChannelEmail, channel)
+	t.Cleanup(func() {
+		notifications.RegisterChannel(notifications.ChannelEmail, &notifications.EmailChannel{})
+	})
+	return channel
+}
+
+func TestSendGuestOrderEmail(t *testing.T) {
+	t.Setenv("FRONTEND_URL", "https://shop.example")
+	emails := recordEmails(t)
+
+	order := models.Order{ID: 12, OrderNumber: "ORD-20260301-000007-4", GuestEmail: "ada@example.com", TotalAmount: 42.5}
+	sendGuestOrderEmail(order, "lookup-token")
+
+	if len(emails.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(emails.sent))
+	}
+	message := emails.sent[0]
+	if message.Email != "ada@example.com" {
+		t.Errorf("Email = %q, want the guest email", message.Email)
+	}
+	if want := "Your order ORD-20260301-000007-4"; message.Subject != want {
+		t.Errorf("Subject = %q, want %q", message.Subject, want)
+	}
+	if want := "https://shop.example/orders/lookup/lookup-token"; message.Link != want {
+		t.Errorf("Link = %q, want %q", message.Link, want)
+	}
+}
+
+func TestClaimGuestOrderRequiresLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/orders/claim", nil)
+
+	ClaimGuestOrder(c)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
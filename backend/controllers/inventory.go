@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockAdjustmentRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	// Quantity is added to stock; negative values take stock out.
+	Quantity int    `json:"quantity" binding:"required"`
+	Reason   string `json:"reason"`
+	Note     string `json:"note"`
+	// WarehouseID defaults to the default warehouse
+	WarehouseID *uint `json:"warehouse_id"`
+}
+
+type LowStockThresholdRequest struct {
+	// Threshold of null clears the product's own threshold so the store-wide
+	// default applies again.
+	Threshold *int `json:"threshold" binding:"omitempty,gte=0"`
+}
+
+// inventoryError is a stock change the stock on hand or the warehouses do not
+// allow, reported to the admin or customer as is.
+type inventoryError string
+
+func (e inventoryError) Error() string {
+	return string(e)
+}
+
+// GetStockMovements lists the inventory ledger, newest first, optionally for
+// one product, reason or warehouse or a date range (from, to, tz).
+func GetStockMovements(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.StockMovement{})
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		if !models.IsValidStockReason(reason) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reason"})
+			return
+		}
+		query = query.Where("reason = ?", reason)
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	loc, err := timezoneParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from := c.Query("from"); from != "" {
+		start, err := parseDateParam(from, loc, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if to := c.Query("to"); to != "" {
+		end, err := parseDateParam(to, loc, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return
+		}
+		query = query.Where("created_at < ?", end)
+	}
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var movements []models.StockMovement
+	err = query.Preload("Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Warehouse").Preload("User").Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&movements).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"movements":   movements,
+		"total":       totalCount,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// AdjustStock records a manual stock change, such as a stock count
+// correction or goods received. The reason defaults to adjustment.
+func AdjustStock(c *gin.Context) {
+	var req StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = models.StockReasonAdjustment
+	}
+	if req.Reason != models.StockReasonAdjustment && req.Reason != models.StockReasonRestock {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason must be adjustment or restock"})
+		return
+	}
+	if req.Reason == models.StockReasonRestock && req.Quantity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A restock must add stock"})
+		return
+	}
+	if req.WarehouseID != nil {
+		var warehouse models.Warehouse
+		if err := database.DB.First(&warehouse, *req.WarehouseID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+			return
+		}
+	}
+	userID, _ := c.Get("user_id")
+	adminID := userID.(uint)
+
+	var events []notifications.Event
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		events, err = moveStock(tx, models.StockMovement{
+			ProductID:   req.ProductID,
+			WarehouseID: req.WarehouseID,
+			Quantity:    req.Quantity,
+			Reason:      req.Reason,
+			UserID:      &adminID,
+			Note:        strings.TrimSpace(req.Note),
+		})
+		return err
+	})
+	if _, ok := err.(inventoryError); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		return
+	}
+	notifications.Publish(events...)
+
+	var product models.Product
+	database.DB.Unscoped().Preload("Category").First(&product, req.ProductID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stock adjusted successfully",
+		"product": product.ToAdminResponse(),
+	})
+}
+
+// UpdateLowStockThreshold sets or clears a product's own low-stock threshold.
+func UpdateLowStockThreshold(c *gin.Context) {
+	id := c.Param("id")
+
+	var req LowStockThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.Unscoped().First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err := database.DB.Unscoped().Model(&product).Update("low_stock_threshold", req.Threshold).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update threshold"})
+		return
+	}
+	product.LowStockThreshold = req.Threshold
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Low stock threshold updated successfully",
+		"product_id":          product.ID,
+		"low_stock_threshold": req.Threshold,
+		"effective_threshold": product.LowStockLevel(defaultLowStockThreshold()),
+	})
+}
+
+type lowStockProduct struct {
+	ProductID  uint   `json:"product_id"`
+	SKU        string `json:"sku"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	CategoryID uint   `json:"category_id"`
+	Stock      int    `json:"stock"`
+	Threshold  int    `json:"threshold"`
+	// Shortfall is how many units would bring stock back above the threshold.
+	Shortfall int `json:"shortfall"`
+}
+
+// GetLowStockReport lists products at or below their low-stock threshold,
+// emptiest first. Archived products are left out unless include_archived is
+// set.
+func GetLowStockReport(c *gin.Context) {
+	threshold := defaultLowStockThreshold()
+
+	query := database.DB.Model(&models.Product{}).
+		Select("id AS product_id, sku, name, status, category_id, stock, COALESCE(low_stock_threshold, ?) AS threshold", threshold).
+		Where("stock <= COALESCE(low_stock_threshold, ?)", threshold)
+	if c.Query("include_archived") != "true" {
+		query = query.Where("status <> ?", models.ProductStatusArchived)
+	}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+
+	var products []lowStockProduct
+	if err := query.Order("stock ASC, name ASC").Scan(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	for i := range products {
+		products[i].Shortfall = products[i].Threshold - products[i].Stock + 1
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_threshold": threshold,
+		"products":          products,
+	})
+}
+
+// moveStock records a stock movement in its warehouse, the default one when
+// none is given, sets the product's stock there to the new ledger balance and
+// its overall stock to what active warehouses hold, failing if the movement
+// would take the warehouse's stock below zero. It returns the notification
+// events the change triggers.
+func moveStock(tx *gorm.DB, movement models.StockMovement) ([]notifications.Event, error) {
+	if movement.Quantity == 0 {
+		return nil, nil
+	}
+
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, movement.ProductID).Error; err != nil {
+		return nil, err
+	}
+	if movement.WarehouseID == nil {
+		warehouse, err := defaultWarehouse(tx)
+		if err != nil {
+			return nil, err
+		}
+		movement.WarehouseID = &warehouse.ID
+	}
+
+	balance, err := stockBalance(tx, product.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	available, err := sellableStock(tx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	level, err := stockBalance(tx, product.ID, *movement.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if level+movement.Quantity < 0 {
+		return nil, inventoryError("Insufficient stock for product: " + product.Name)
+	}
+
+	movement.BalanceAfter = balance + movement.Quantity
+	if err := tx.Omit("Product", "Warehouse", "User").Create(&movement).Error; err != nil {
+		return nil, err
+	}
+	warehouseStock := models.WarehouseStock{WarehouseID: *movement.WarehouseID, ProductID: product.ID, Quantity: level + movement.Quantity}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+	}).Omit("Warehouse", "Product").Create(&warehouseStock).Error
+	if err != nil {
+		return nil, err
+	}
+	return updateSellableStock(tx, product, available)
+}
+
+// updateSellableStock sets a locked product's stock to what its active
+// warehouses hold, returning the events a change from available triggers.
+func updateSellableStock(tx *gorm.DB, product models.Product, available int) ([]notifications.Event, error) {
+	stock, err := sellableStock(tx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", product.ID).Update("stock", stock).Error; err != nil {
+		return nil, err
+	}
+
+	events := notifications.ProductChangeEvents(product.ID, product.Price, product.Price, available, stock)
+	events = append(events, notifications.LowStockEvent(product.ID, available, stock, product.LowStockLevel(defaultLowStockThreshold()))...)
+	return events, nil
+}
+
+// setStock records the movement that brings a product's stock in active
+// warehouses to level. The difference is made up in the movement's warehouse.
+func setStock(tx *gorm.DB, movement models.StockMovement, level int) ([]notifications.Event, error) {
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, movement.ProductID).Error; err != nil {
+		return nil, err
+	}
+	available, err := sellableStock(tx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	movement.Quantity = level - available
+	return moveStock(tx, movement)
+}
+
+// stockBalance adds up a product's stock movements in a warehouse, or in all
+// of them when warehouseID is 0. Callers lock the product first so the
+// balance cannot change underneath them.
+func stockBalance(tx *gorm.DB, productID, warehouseID uint) (int, error) {
+	query := tx.Model(&models.StockMovement{}).Select("COALESCE(SUM(quantity), 0)").Where("product_id = ?", productID)
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	var balance int
+	err := query.Scan(&balance).Error
+	return balance, err
+}
+
+// sellableStock adds up a product's stock in active warehouses, the stock
+// orders can be allocated from.
+func sellableStock(tx *gorm.DB, productID uint) (int, error) {
+	active := tx.Model(&models.Warehouse{}).Select("id").Where("active = ?", true)
+	var stock int
+	err := tx.Model(&models.WarehouseStock{}).Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND warehouse_id IN (?)", productID, active).Scan(&stock).Error
+	return stock, err
+}
+
+// defaultLowStockThreshold is the store-wide low-stock level from
+// LOW_STOCK_THRESHOLD, 5 when unset.
+func defaultLowStockThreshold() int {
+	threshold, err := strconv.Atoi(os.Getenv("LOW_STOCK_THRESHOLD"))
+	if err != nil || threshold < 0 {
+		return 5
+	}
+	return threshold
+}
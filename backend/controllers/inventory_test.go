@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"testing"
+)
+
+func TestDefaultLowStockThreshold(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 5},
+		{"12", 12},
+		{"0", 0},
+		{"-1", 5},
+		{"few", 5},
+	}
+	for _, tt := range tests {
+		t.Setenv("LOW_STOCK_THRESHOLD", tt.value)
+		if got := defaultLowStockThreshold(); got != tt.want {
+			t.Errorf("defaultLowStockThreshold() with %q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/pdf"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetOrderInvoice renders the invoice of an order as a PDF. Customers can
+// download invoices for their own orders, admins for any order.
+func GetOrderInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	orderID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	query := database.DB.Preload("User").Preload("OrderItems.Product").Where("id = ?", orderID)
+	if role, _ := c.Get("user_role"); role != "admin" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var order models.Order
+	if err := query.First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	respondInvoice(c, order)
+}
+
+// GetGuestOrderInvoice renders the invoice of a guest order for whoever
+// holds its lookup token.
+func GetGuestOrderInvoice(c *gin.Context) {
+	var order models.Order
+	if err := database.DB.Preload("OrderItems.Product").
+		Where("lookup_token = ?", utils.HashToken(c.Param("token"))).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	respondInvoice(c, order)
+}
+
+func respondInvoice(c *gin.Context, order models.Order) {
+	if order.Status == "cancelled" && order.InvoiceNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cancelled orders are not invoiced"})
+		return
+	}
+
+	if err := assignInvoiceNumber(&order); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign invoice number"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err := renderInvoice(order).WriteTo(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, order.InvoiceNumber))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// assignInvoiceNumber gives the order the next invoice number the first time
+// its invoice is requested, so invoice numbers are sequential without gaps.
+func assignInvoiceNumber(order *models.Order) error {
+	if order.InvoiceNumber != "" {
+		return nil
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// Another request may have invoiced the order in the meantime; the
+		// lock makes concurrent first downloads wait for it
+		var current models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "invoice_number", "invoiced_at").
+			First(&current, order.ID).Error; err != nil {
+			return err
+		}
+		if current.InvoiceNumber != "" {
+			order.InvoiceNumber = current.InvoiceNumber
+			order.InvoicedAt = current.InvoicedAt
+			return nil
+		}
+
+		sequence, err := models.NextSequenceValue(tx, "invoice")
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		order.InvoiceNumber = utils.FormatInvoiceNumber(utils.InvoiceNumberPrefix(), sequence)
+		order.InvoicedAt = &now
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"invoice_number": order.InvoiceNumber,
+			"invoiced_at":    now,
+		}).Error
+	})
+}
+
+func renderInvoice(order models.Order) *pdf.Document {
+	const (
+		left     = 50.0
+		right    = pdf.PageWidth - 50
+		bottom   = pdf.PageHeight - 60
+		qtyX     = 360.0
+		unitX    = 450.0
+		lineSize = 10.0
+	)
+
+	doc := pdf.New("Invoice " + order.InvoiceNumber)
+	page := doc.AddPage()
+
+	storeName := os.Getenv("STORE_NAME")
+	page.Text(left, 70, 20, true, storeName)
+	y := 86.0
+	for _, line := range pdf.Wrap(os.Getenv("STORE_ADDRESS"), 9, 250) {
+		page.Text(left, y, 9, false, line)
+		y += 12
+	}
+
+	page.TextRight(right, 70, 20, true, "INVOICE")
+	invoicedAt := time.Now()
+	if order.InvoicedAt != nil {
+		invoicedAt = *order.InvoicedAt
+	}
+	meta := [][2]string{
+		{"Invoice number", order.InvoiceNumber},
+		{"Invoice date", invoicedAt.Format("2006-01-02")},
+		{"Order number", order.OrderNumber},
+		{"Order date", order.CreatedAt.Format("2006-01-02")},
+		{"Payment method", order.PaymentMethod},
+	}
+	metaY := 90.0
+	for _, row := range meta {
+		page.Text(360, metaY, 9, true, row[0])
+		page.TextRight(right, metaY, 9, false, row[1])
+		metaY += 13
+	}
+
+	// Addresses
+	y = 170
+	billTo := []string{order.GuestName, order.GuestEmail}
+	if order.User != nil {
+		billTo = []string{order.User.FirstName + " " + order.User.LastName, order.User.Email}
+		if order.User.Address != "" {
+			billTo = append(billTo, pdf.Wrap(order.User.Address, 9, 230)...)
+		}
+	}
+	page.Text(left, y, 10, true, "Bill to")
+	page.Text(310, y, 10, true, "Ship to")
+	billY, shipY := y+14, y+14
+	for _, line := range billTo {
+		if line == "" {
+			continue
+		}
+		page.Text(left, billY, 9, false, line)
+		billY += 12
+	}
+	for _, line := range pdf.Wrap(order.ShippingAddress, 9, 230) {
+		page.Text(310, shipY, 9, false, line)
+		shipY += 12
+	}
+	y = billY
+	if shipY > y {
+		y = shipY
+	}
+	y += 20
+
+	tableHeader := func(y float64) float64 {
+		page.FillRect(left, y-12, right-left, 18, 0.9)
+		page.Text(left+5, y, 9, true, "Item")
+		page.TextRight(qtyX, y, 9, true, "Qty")
+		page.TextRight(unitX, y, 9, true, "Unit price")
+		page.TextRight(right-5, y, 9, true, "Amount")
+		return y + 20
+	}
+	y = tableHeader(y)
+
+	for _, item := range order.OrderItems {
+		name := item.Product.Name
+		if name == "" {
+			name = fmt.Sprintf("Product #%d", item.ProductID)
+		}
+		nameLines := pdf.Wrap(name, lineSize, qtyX-left-40)
+		var noteLines []string
+		if item.Note != "" {
+			noteLines = pdf.Wrap("Note: "+item.Note, 8, qtyX-left-40)
+		}
+
+		if y+float64(len(nameLines)+len(noteLines))*13 > bottom {
+			page = doc.AddPage()
+			y = tableHeader(60)
+		}
+
+		page.TextRight(qtyX, y, lineSize, false, strconv.Itoa(item.Quantity))
+		page.TextRight(unitX, y, lineSize, false, formatMoney(item.Price))
+		page.TextRight(right-5, y, lineSize, false, formatMoney(item.Price*float64(item.Quantity)))
+		for _, line := range nameLines {
+			page.Text(left+5, y, lineSize, false, line)
+			y += 13
+		}
+		for _, line := range noteLines {
+			page.Text(left+5, y, 8, false, line)
+			y += 11
+		}
+		y += 4
+	}
+
+	// Totals
+	subtotal := order.Subtotal
+	if subtotal == 0 {
+		// Orders placed before itemized totals were stored
+		for _, item := range order.OrderItems {
+			subtotal += item.Price * float64(item.Quantity)
+		}
+	}
+	totals := [][2]string{{"Subtotal", formatMoney(subtotal)}}
+	if order.DiscountAmount > 0 {
+		label := "Discount"
+		if order.CouponCode != "" {
+			label += " (" + order.CouponCode + ")"
+		}
+		totals = append(totals, [2]string{label, "-" + formatMoney(order.DiscountAmount)})
+	}
+	totals = append(totals, [2]string{"Shipping", formatMoney(order.ShippingAmount)})
+	totals = append(totals, [2]string{"Tax", formatMoney(order.TaxAmount)})
+
+	if y+float64(len(totals)+1)*16+20 > bottom {
+		page = doc.AddPage()
+		y = 60
+	}
+	page.Line(unitX-80, y, right, y, 0.5)
+	y += 16
+	for _, row := range totals {
+		page.Text(unitX-80, y, 10, false, row[0])
+		page.TextRight(right-5, y, 10, false, row[1])
+		y += 16
+	}
+	page.Line(unitX-80, y-10, right, y-10, 0.5)
+	y += 4
+	page.Text(unitX-80, y, 12, true, "Total")
+	page.TextRight(right-5, y, 12, true, formatMoney(order.TotalAmount))
+
+	page.Text(left, pdf.PageHeight-40, 8, false, "Thank you for shopping with "+storeName+".")
+
+	return doc
+}
+
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"ecommerce-backend/models"
+)
+
+func TestFormatMoney(t *testing.T) {
+	tests := map[float64]string{
+		0:       "0.00",
+		12.5:    "12.50",
+		1234.56: "1234.56",
+		-3:      "-3.00",
+	}
+	for amount, want := range tests {
+		if got := formatMoney(amount); got != want {
+			t.Errorf("formatMoney(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestRenderInvoice(t *testing.T) {
+	t.Setenv("STORE_NAME", "Corner Shop")
+	invoicedAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	order := models.Order{
+		OrderNumber:     "ORD-20260301-000007-4",
+		InvoiceNumber:   "INV-000003",
+		InvoicedAt:      &invoicedAt,
+		GuestName:       "Ada Lovelace",
+		GuestEmail:      "ada@example.com",
+		ShippingAddress: "1 Main Street\nSpringfield",
+		DiscountAmount:  5,
+		CouponCode:      "FIVE",
+		TotalAmount:     40,
+		OrderItems: []models.OrderItem{
+			{ProductID: 1, Quantity: 2, Price: 10, Note: "Gift wrap, please", Product: models.Product{Name: "Mug"}},
+			{ProductID: 2, Quantity: 1, Price: 25},
+		},
+	}
+
+	var buf bytes.Buffer
+	if _, err := renderInvoice(order).WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"(INV-000003)", "(ORD-20260301-000007-4)", "(2026-03-02)", "(Ada Lovelace)",
+		"(Mug)", "(Note: Gift wrap, please)", "(Product #2)", "(Discount \\(FIVE\\))", "(-5.00)", "(45.00)", "(40.00)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("invoice is missing %s", want)
+		}
+	}
+	if !strings.Contains(out, "/Count 1") {
+		t.Error("a short invoice should fit on one page")
+	}
+}
+
+func TestRenderInvoiceAddsPages(t *testing.T) {
+	order := models.Order{InvoiceNumber: "INV-000004"}
+	for i := 0; i < 80; i++ {
+		order.OrderItems = append(order.OrderItems, models.OrderItem{ProductID: uint(i + 1), Quantity: 1, Price: 1,
+			Product: models.Product{Name: fmt.Sprintf("Item %d", i+1)}})
+	}
+
+	var buf bytes.Buffer
+	if _, err := renderInvoice(order).WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "/Count 1 >>") {
+		t.Error("80 lines were rendered on a single page")
+	}
+	if !strings.Contains(buf.String(), "(Item 80)") {
+		t.Error("the last line is missing")
+	}
+}
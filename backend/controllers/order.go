@@ -3,14 +3,23 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"time"
 	"ecommerce-backend/database"
 	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CreateOrderRequest struct {
 	ShippingAddress string `json:"shipping_address" binding:"required"`
 	PaymentMethod   string `json:"payment_method" binding:"required"`
+	ShippingMethod  string `json:"shipping_method"`
+	CouponCode      string `json:"coupon_code"`
+	// QuoteID places the order at the prices of a quote from /checkout/quote.
+	QuoteID string `json:"quote_id"`
 }
 
 type UpdateOrderStatusRequest struct {
@@ -65,22 +74,122 @@ func GetOrder(c *gin.Context) {
 		return
 	}
 
+	// Customers may quote the order number instead of the ID
+	query := database.DB.Preload("OrderItems.Product.Category").Where("user_id = ?", userID)
 	id := c.Param("id")
-	orderID, err := strconv.ParseUint(id, 10, 32)
+	if utils.ValidOrderNumber(id) {
+		query = query.Where("order_number = ?", id)
+	} else {
+		orderID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+			return
+		}
+		query = query.Where("id = ?", orderID)
+	}
+
+	var order models.Order
+	if err := query.First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order": order.ToResponse(),
+	})
+}
+
+// ReorderOrder puts the items of one of the user's past orders back into
+// their cart. Products that can no longer be ordered are left out and
+// quantities are capped at the stock available; each is reported as a
+// warning, as are price changes since the order.
+func ReorderOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
 	}
 
 	var order models.Order
-	if err := database.DB.Preload("OrderItems.Product.Category").
-		Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+	if err := database.DB.Preload("OrderItems").Where("user_id = ?", userID).First(&order, orderID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
 
+	owner := cartOwner{UserID: userID.(uint)}
+	now := time.Now()
+	added := []models.Cart{}
+	warnings := []models.CartWarning{}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, item := range order.OrderItems {
+			quantity := item.Quantity - item.CancelledQuantity
+			if quantity <= 0 {
+				continue
+			}
+
+			var product models.Product
+			tx.Scopes(models.VisibleProducts(now)).First(&product, item.ProductID)
+
+			// Compare against what the customer paid last time and against what
+			// is already in the cart
+			line := owner.newItem(item.ProductID, quantity)
+			line.Product = product
+			line.PriceAtAdd = item.Price
+			var inCart models.Cart
+			if err := owner.scope(tx).Where("product_id = ?", item.ProductID).First(&inCart).Error; err == nil {
+				line.Product.Stock -= inCart.Quantity
+			}
+
+			lineWarnings := line.Warnings(now)
+			for i := range lineWarnings {
+				if lineWarnings[i].Blocking() {
+					lineWarnings[i].Adjusted = true
+				}
+				if lineWarnings[i].Type == models.CartWarningStockReduced {
+					quantity = line.Product.Stock
+				} else if lineWarnings[i].Type == models.CartWarningOutOfStock || lineWarnings[i].Type == models.CartWarningUnavailable {
+					quantity = 0
+				}
+			}
+			warnings = append(warnings, lineWarnings...)
+			if quantity <= 0 {
+				continue
+			}
+
+			cartItem, _, err := addProductToCart(tx, owner, item.ProductID, quantity)
+			if err != nil {
+				return err
+			}
+			added = append(added, cartItem)
+		}
+		return nil
+	})
+	if err != nil {
+		respondCartError(c, err)
+		return
+	}
+
+	if len(added) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "None of the items in this order are available", "warnings": warnings})
+		return
+	}
+
+	var responses []models.CartResponse
+	for _, item := range added {
+		database.DB.Preload("Product.Category").First(&item, item.ID)
+		responses = append(responses, item.ToResponse())
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"order": order.ToResponse(),
+		"message":  "Items added to cart",
+		"items":    responses,
+		"warnings": warnings,
 	})
 }
 
@@ -97,96 +206,189 @@ func CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Get user's cart items
-	var cartItems []models.Cart
-	if err := database.DB.Preload("Product").Where("user_id = ?", userID).Find(&cartItems).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart items"})
+	uid := userID.(uint)
+	order := models.Order{
+		UserID:          &uid,
+		ShippingAddress: req.ShippingAddress,
+		PaymentMethod:   req.PaymentMethod,
+	}
+	input := checkoutInput{ShippingMethod: req.ShippingMethod, CouponCode: req.CouponCode, QuoteID: req.QuoteID}
+	if err := placeOrder(cartOwner{UserID: uid}, &order, input); err != nil {
+		respondCheckoutError(c, err)
 		return
 	}
 
-	if len(cartItems) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is empty"})
-		return
+	// Load order with relations
+	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Order created successfully",
+		"order":   order.ToResponse(),
+	})
+}
+
+// checkoutError is a checkout failure caused by the cart's contents, reported
+// to the customer as is.
+type checkoutError struct {
+	message  string
+	warnings []models.CartWarning
+}
+
+func (e checkoutError) Error() string {
+	return e.message
+}
+
+// placeOrder turns the owner's cart into a pending order, reserving stock and
+// emptying the cart in a single transaction. Prices come from the quote when
+// one is given and still matches the cart and the customer's choices,
+// otherwise from priceCheckout. The caller fills in who the order is for and
+// where it ships.
+func placeOrder(owner cartOwner, order *models.Order, input checkoutInput) error {
+	cartItems, err := loadCheckoutCart(database.DB, owner)
+	if err != nil {
+		return err
 	}
 
-	// Calculate total amount and validate stock
-	var totalAmount float64
-	for _, item := range cartItems {
-		if item.Product.Stock < item.Quantity {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Insufficient stock for product: " + item.Product.Name,
-			})
-			return
+	now := time.Now()
+	var breakdown models.CheckoutBreakdown
+	var quote models.CheckoutQuote
+	if input.QuoteID != "" {
+		err := owner.scope(database.DB).Where("reference = ? AND used_at IS NULL AND expires_at > ?", input.QuoteID, now).
+			First(&quote).Error
+		if err != nil || quote.CartFingerprint != cartFingerprint(cartItems) {
+			return checkoutError{message: "Quote has expired or no longer matches your cart"}
+		}
+		if !quoteMatches(quote, input, order.ShippingAddress) {
+			return checkoutError{message: "Shipping method, coupon or shipping address differs from the quote"}
+		}
+		breakdown = quote.Breakdown()
+	} else {
+		breakdown, _, err = priceCheckout(database.DB, cartItems, input, now)
+		if err != nil {
+			return err
 		}
-		totalAmount += item.Product.Price * float64(item.Quantity)
 	}
 
-	// Start transaction
-	tx := database.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	var events []notifications.Event
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if events, err = insertOrder(tx, order, cartItems, breakdown, now); err != nil {
+			return err
 		}
-	}()
 
-	// Create order
-	order := models.Order{
-		UserID:          userID.(uint),
-		TotalAmount:     totalAmount,
-		Status:          "pending",
-		ShippingAddress: req.ShippingAddress,
-		PaymentMethod:   req.PaymentMethod,
+		// Count the coupon redemption, checking the coupon again: a quote may
+		// have been priced with it before it expired or ran out
+		if breakdown.CouponCode != "" {
+			var coupon models.Coupon
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", breakdown.CouponCode).First(&coupon).Error
+			if err == gorm.ErrRecordNotFound || (err == nil && !coupon.IsRedeemableAt(now)) {
+				return checkoutError{message: "Coupon is invalid or has expired"}
+			}
+			if err != nil {
+				return err
+			}
+			result := tx.Model(&models.Coupon{}).Where("id = ? AND (usage_limit = 0 OR used_count < usage_limit)", coupon.ID).
+				Update("used_count", gorm.Expr("used_count + 1"))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return checkoutError{message: "Coupon is invalid or has expired"}
+			}
+		}
+
+		// Mark the quote used only if no other order got to it first
+		if quote.ID != 0 {
+			result := tx.Model(&models.CheckoutQuote{}).Where("id = ? AND used_at IS NULL", quote.ID).Update("used_at", now)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return checkoutError{message: "Quote has expired or no longer matches your cart"}
+			}
+		}
+
+		// Clear the cart, keeping items saved for later
+		return owner.scope(tx).Where("saved_for_later = ?", false).Delete(&models.Cart{}).Error
+	})
+	if err != nil {
+		return err
 	}
+	notifications.Publish(events...)
 
-	if err := tx.Create(&order).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
-		return
+	markCartRecovered(owner, *order)
+	return nil
+}
+
+// insertOrder creates a pending order priced by breakdown, numbers it and
+// reserves stock for its lines, returning the notification events the stock
+// changes trigger.
+func insertOrder(tx *gorm.DB, order *models.Order, cartItems []models.Cart, breakdown models.CheckoutBreakdown, now time.Time) ([]notifications.Event, error) {
+	unitPrices := map[uint]float64{}
+	for _, line := range breakdown.Lines {
+		unitPrices[line.ProductID] = line.UnitPrice
+	}
+
+	order.Subtotal = breakdown.Subtotal
+	order.DiscountAmount = breakdown.Discount
+	order.ShippingAmount = breakdown.Shipping
+	order.TaxRate = breakdown.TaxRate
+	order.TaxAmount = breakdown.Tax
+	order.TotalAmount = breakdown.Total
+	order.CouponCode = breakdown.CouponCode
+	order.ShippingMethod = breakdown.ShippingMethod
+	order.Status = "pending"
+
+	sequence, err := models.NextSequenceValue(tx, utils.OrderSequenceName(now))
+	if err != nil {
+		return nil, err
+	}
+	order.OrderNumber = utils.FormatOrderNumber(utils.OrderNumberPrefix(), now, sequence)
+
+	if err := tx.Omit("User", "OrderItems").Create(order).Error; err != nil {
+		return nil, err
 	}
 
 	// Create order items and update product stock
+	var events []notifications.Event
 	for _, item := range cartItems {
+		cost, err := currentProductCost(tx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
 		orderItem := models.OrderItem{
 			OrderID:   order.ID,
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			Price:     item.Product.Price,
+			Price:     unitPrices[item.ProductID],
+			UnitCost:  cost,
+			Note:      item.Note,
 		}
-
-		if err := tx.Create(&orderItem).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order item"})
-			return
+		if err := tx.Omit("Order", "Product").Create(&orderItem).Error; err != nil {
+			return nil, err
 		}
 
-		// Update product stock
-		if err := tx.Model(&item.Product).Update("stock", item.Product.Stock-item.Quantity).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
-			return
+		stockEvents, err := allocateStock(tx, orderItem, orderItem.Quantity, order.ShippingAddress)
+		if err != nil {
+			if _, ok := err.(inventoryError); ok {
+				return nil, checkoutError{message: err.Error()}
+			}
+			return nil, err
 		}
+		events = append(events, stockEvents...)
 	}
+	return events, nil
+}
 
-	// Clear user's cart
-	if err := tx.Where("user_id = ?", userID).Delete(&models.Cart{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
-		return
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete order"})
+func respondCheckoutError(c *gin.Context, err error) {
+	if checkoutErr, ok := err.(checkoutError); ok {
+		response := gin.H{"error": checkoutErr.Error()}
+		if len(checkoutErr.warnings) > 0 {
+			response["warnings"] = checkoutErr.warnings
+		}
+		c.JSON(http.StatusBadRequest, response)
 		return
 	}
-
-	// Load order with relations
-	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Order created successfully",
-		"order":   order.ToResponse(),
-	})
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 }
 
 func UpdateOrderStatus(c *gin.Context) {
@@ -218,37 +420,66 @@ func UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
+	// Shipping, delivering or cancelling the order applies to every line
+	// still open, so the status stays in step with the lines.
 	var order models.Order
-	if err := database.DB.First(&order, orderID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-		return
-	}
-
-	// Handle cancellation logic
-	if req.Status == "cancelled" && order.Status != "cancelled" {
-		// Restore product stock
-		var orderItems []models.OrderItem
-		if err := database.DB.Preload("Product").Where("order_id = ?", orderID).Find(&orderItems).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order items"})
-			return
+	var events []notifications.Event
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, &order, uint(orderID)); err != nil {
+			return err
 		}
 
-		tx := database.DB.Begin()
-		for _, item := range orderItems {
-			if err := tx.Model(&item.Product).Update("stock", item.Product.Stock+item.Quantity).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product stock"})
-				return
+		switch req.Status {
+		case models.OrderStatusCancelled:
+			if order.Status == models.OrderStatusCancelled {
+				return nil
+			}
+			for i := range order.OrderItems {
+				item := &order.OrderItems[i]
+				if item.FulfilledQuantity > 0 {
+					return orderItemError("Order has shipped items; cancel the remaining lines or record returns instead")
+				}
+				if item.OpenQuantity() == 0 {
+					continue
+				}
+				itemEvents, err := cancelOrderItem(tx, &order, item, item.OpenQuantity())
+				if err != nil {
+					return err
+				}
+				events = append(events, itemEvents...)
+			}
+		case models.OrderStatusShipped, models.OrderStatusDelivered:
+			if order.Status == models.OrderStatusCancelled {
+				return orderItemError("Order has been cancelled")
+			}
+			for i := range order.OrderItems {
+				item := &order.OrderItems[i]
+				if open := item.OpenQuantity(); open > 0 {
+					item.FulfilledQuantity += open
+					if err := saveOrderItem(tx, item); err != nil {
+						return err
+					}
+				}
 			}
 		}
-		tx.Commit()
-	}
 
-	order.Status = req.Status
-	if err := database.DB.Save(&order).Error; err != nil {
+		order.Status = req.Status
+		return saveOrderProgress(tx, &order)
+	})
+	switch err.(type) {
+	case orderItemError, inventoryError:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
 		return
 	}
+	notifications.Publish(events...)
 
 	// Load order with relations
 	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
@@ -263,18 +494,17 @@ func GetAllOrders(c *gin.Context) {
 	// Get query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
 
 	// Calculate offset
 	offset := (page - 1) * limit
 
-	// Build query
-	query := database.DB.Model(&models.Order{}).Preload("User").Preload("OrderItems.Product.Category")
-
-	// Filter by status if provided
-	if status != "" {
-		query = query.Where("status = ?", status)
+	// Build query from the filters
+	query, err := adminOrderQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	query = query.Preload("User").Preload("OrderItems.Product.Category")
 
 	// Get total count
 	var totalCount int64
@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UpdateShippingAddressRequest struct {
+	ShippingAddress string `json:"shipping_address" binding:"required"`
+	Reason          string `json:"reason"`
+}
+
+type AddOrderItemRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	Reason    string `json:"reason"`
+}
+
+type UpdateOrderItemRequest struct {
+	Quantity *int     `json:"quantity" binding:"omitempty,min=1"`
+	Price    *float64 `json:"price" binding:"omitempty,min=0"`
+	Reason   string   `json:"reason"`
+}
+
+// GetOrderEdits lists the changes admins made to an order, oldest first.
+func GetOrderEdits(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var edits []models.OrderEdit
+	if err := database.DB.Preload("Admin").Where("order_id = ?", orderID).Order("created_at ASC, id ASC").Find(&edits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order edits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"edits": edits})
+}
+
+func UpdateOrderShippingAddress(c *gin.Context) {
+	var req UpdateShippingAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	editOrder(c, "Shipping address updated successfully", func(tx *gorm.DB, order *models.Order) ([]models.OrderEdit, []notifications.Event, error) {
+		address := strings.TrimSpace(req.ShippingAddress)
+		if address == "" {
+			return nil, nil, orderItemError("Shipping address is required")
+		}
+		if address == order.ShippingAddress {
+			return nil, nil, nil
+		}
+
+		edit := models.OrderEdit{Action: models.OrderEditAddressChanged, OldValue: order.ShippingAddress, NewValue: address, Reason: req.Reason}
+		order.ShippingAddress = address
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("shipping_address", address).Error; err != nil {
+			return nil, nil, err
+		}
+		return []models.OrderEdit{edit}, nil, nil
+	})
+}
+
+// AddOrderItem adds a product to a pending order at its current price,
+// reserving the stock. A product already on the order has its line's
+// quantity increased instead.
+func AddOrderItem(c *gin.Context) {
+	var req AddOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	editOrder(c, "Order item added successfully", func(tx *gorm.DB, order *models.Order) ([]models.OrderEdit, []notifications.Event, error) {
+		for i := range order.OrderItems {
+			if order.OrderItems[i].ProductID == req.ProductID {
+				return changeOrderItemQuantity(tx, order, &order.OrderItems[i], order.OrderItems[i].Quantity+req.Quantity, req.Reason)
+			}
+		}
+
+		var product models.Product
+		if err := tx.First(&product, req.ProductID).Error; err != nil || !product.IsVisibleAt(time.Now()) {
+			return nil, nil, orderItemError("Product not available")
+		}
+		cost, err := currentProductCost(tx, product.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		item := models.OrderItem{
+			OrderID:   order.ID,
+			ProductID: product.ID,
+			Quantity:  req.Quantity,
+			Price:     product.Price,
+			UnitCost:  cost,
+			Status:    models.OrderItemPending,
+		}
+		if err := tx.Omit("Order", "Product").Create(&item).Error; err != nil {
+			return nil, nil, err
+		}
+		events, err := allocateStock(tx, item, item.Quantity, order.ShippingAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		edit := models.OrderEdit{
+			Action:      models.OrderEditItemAdded,
+			OrderItemID: &item.ID,
+			ProductID:   &item.ProductID,
+			NewValue:    fmt.Sprintf("%d x %s @ %.2f", item.Quantity, product.Name, item.Price),
+			Reason:      req.Reason,
+		}
+		return []models.OrderEdit{edit}, events, nil
+	})
+}
+
+// UpdateOrderItem changes the quantity and/or unit price of a line on a
+// pending order. Price adjustments need a reason.
+func UpdateOrderItem(c *gin.Context) {
+	var req UpdateOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Quantity == nil && req.Price == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity or price is required"})
+		return
+	}
+	if req.Price != nil && strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to adjust the price"})
+		return
+	}
+
+	editOrder(c, "Order item updated successfully", func(tx *gorm.DB, order *models.Order) ([]models.OrderEdit, []notifications.Event, error) {
+		item, err := editableOrderItem(order, c.Param("itemId"))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var edits []models.OrderEdit
+		var events []notifications.Event
+		if req.Quantity != nil && *req.Quantity != item.Quantity {
+			if edits, events, err = changeOrderItemQuantity(tx, order, item, *req.Quantity, req.Reason); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if req.Price != nil && roundMoney(*req.Price) != item.Price {
+			price := roundMoney(*req.Price)
+			edits = append(edits, models.OrderEdit{
+				Action:      models.OrderEditPriceAdjusted,
+				OrderItemID: &item.ID,
+				ProductID:   &item.ProductID,
+				OldValue:    fmt.Sprintf("%.2f", item.Price),
+				NewValue:    fmt.Sprintf("%.2f", price),
+				Reason:      req.Reason,
+			})
+			item.Price = price
+			if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Update("price", price).Error; err != nil {
+				return nil, nil, err
+			}
+		}
+		return edits, events, nil
+	})
+}
+
+// RemoveOrderItem takes a line off a pending order and puts its stock back.
+// The reason may be given as a query parameter.
+func RemoveOrderItem(c *gin.Context) {
+	reason := c.Query("reason")
+
+	editOrder(c, "Order item removed successfully", func(tx *gorm.DB, order *models.Order) ([]models.OrderEdit, []notifications.Event, error) {
+		item, err := editableOrderItem(order, c.Param("itemId"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(order.OrderItems) == 1 {
+			return nil, nil, orderItemError("An order must keep at least one item; cancel the order instead")
+		}
+
+		events, err := releaseStock(tx, *item, item.Quantity, models.StockReasonCancellation)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Delete(&models.OrderItem{}, item.ID).Error; err != nil {
+			return nil, nil, err
+		}
+
+		edit := models.OrderEdit{
+			Action:      models.OrderEditItemRemoved,
+			OrderItemID: &item.ID,
+			ProductID:   &item.ProductID,
+			OldValue:    fmt.Sprintf("%d @ %.2f", item.Quantity, item.Price),
+			Reason:      reason,
+		}
+		return []models.OrderEdit{edit}, events, nil
+	})
+}
+
+// editOrder applies edit to a pending order with the order locked, then
+// reprices the order and records the returned edits against the admin
+// making them.
+func editOrder(c *gin.Context, message string, edit func(tx *gorm.DB, order *models.Order) ([]models.OrderEdit, []notifications.Event, error)) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	adminID, _ := c.Get("user_id")
+
+	var order models.Order
+	var edits []models.OrderEdit
+	var events []notifications.Event
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, &order, uint(orderID)); err != nil {
+			return err
+		}
+		if order.Status != "pending" {
+			return orderItemError("Only pending orders can be edited")
+		}
+
+		oldTotal := order.TotalAmount
+		if edits, events, err = edit(tx, &order); err != nil {
+			return err
+		}
+		if len(edits) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("order_id = ?", order.ID).Find(&order.OrderItems).Error; err != nil {
+			return err
+		}
+		if err := repriceOrder(tx, &order); err != nil {
+			return err
+		}
+
+		for i := range edits {
+			edits[i].OrderID = order.ID
+			edits[i].AdminID = adminID.(uint)
+			edits[i].OldTotal = oldTotal
+			edits[i].NewTotal = order.TotalAmount
+		}
+		return tx.Create(&edits).Error
+	})
+	switch err.(type) {
+	case orderItemError, inventoryError:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		return
+	}
+	notifications.Publish(events...)
+
+	// Load order with relations
+	database.DB.Preload("OrderItems.Product.Category").First(&order, order.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"order":   order.ToResponse(),
+		"edits":   edits,
+	})
+}
+
+// editableOrderItem finds the line named by itemID on the order. Lines with
+// cancelled or shipped units can no longer be edited.
+func editableOrderItem(order *models.Order, itemID string) (*models.OrderItem, error) {
+	id, err := strconv.ParseUint(itemID, 10, 32)
+	if err != nil {
+		return nil, orderItemError("Invalid order item ID")
+	}
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		if item.ID != uint(id) {
+			continue
+		}
+		if item.DeriveStatus() != models.OrderItemPending || item.CancelledQuantity > 0 {
+			return nil, orderItemError("Order item has been cancelled or shipped in part and can no longer be edited")
+		}
+		return item, nil
+	}
+	return nil, orderItemError("Order item not found on this order")
+}
+
+// changeOrderItemQuantity sets a line's quantity, reserving or restocking the
+// difference.
+func changeOrderItemQuantity(tx *gorm.DB, order *models.Order, item *models.OrderItem, quantity int, reason string) ([]models.OrderEdit, []notifications.Event, error) {
+	if item.CancelledQuantity > 0 || item.FulfilledQuantity > 0 {
+		return nil, nil, orderItemError("Order item has been cancelled or shipped in part and can no longer be edited")
+	}
+
+	var events []notifications.Event
+	var err error
+	if delta := quantity - item.Quantity; delta > 0 {
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
+			return nil, nil, orderItemError("Product not available")
+		}
+		if events, err = allocateStock(tx, *item, delta, order.ShippingAddress); err != nil {
+			return nil, nil, err
+		}
+	} else if delta < 0 {
+		if events, err = releaseStock(tx, *item, -delta, models.StockReasonCancellation); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	edit := models.OrderEdit{
+		Action:      models.OrderEditQuantityChanged,
+		OrderItemID: &item.ID,
+		ProductID:   &item.ProductID,
+		OldValue:    strconv.Itoa(item.Quantity),
+		NewValue:    strconv.Itoa(quantity),
+		Reason:      reason,
+	}
+	item.Quantity = quantity
+	if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Update("quantity", quantity).Error; err != nil {
+		return nil, nil, err
+	}
+	return []models.OrderEdit{edit}, events, nil
+}
+
+// orderStockMovement is the stock movement of quantity units for an order
+// line.
+func orderStockMovement(item models.OrderItem, quantity int, reason string) models.StockMovement {
+	return models.StockMovement{
+		ProductID:   item.ProductID,
+		Quantity:    quantity,
+		Reason:      reason,
+		OrderID:     &item.OrderID,
+		OrderItemID: &item.ID,
+	}
+}
+
+// repriceOrder recomputes an order's totals from its lines with the shipping
+// method, coupon and tax rate it was placed with. Orders from before
+// itemized pricing only carry a total.
+func repriceOrder(tx *gorm.DB, order *models.Order) error {
+	breakdown := models.CheckoutBreakdown{ShippingMethod: order.ShippingMethod}
+	for _, item := range order.OrderItems {
+		breakdown.Subtotal += roundMoney(item.Price * float64(item.Quantity))
+	}
+	breakdown.Subtotal = roundMoney(breakdown.Subtotal)
+
+	if order.ShippingMethod == "" {
+		order.TotalAmount = breakdown.Subtotal
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("total_amount", order.TotalAmount).Error
+	}
+
+	method, ok := shippingMethods[order.ShippingMethod]
+	if !ok {
+		method = shippingMethod{Name: order.ShippingMethod, Rate: order.ShippingAmount}
+	}
+
+	var coupon *models.Coupon
+	if order.CouponCode != "" {
+		var found models.Coupon
+		if err := tx.Unscoped().Where("code = ?", order.CouponCode).First(&found).Error; err == nil {
+			coupon = &found
+		}
+	}
+
+	rate := order.TaxRate
+	if taxable := order.Subtotal - order.DiscountAmount + order.ShippingAmount; rate == 0 && order.TaxAmount > 0 && taxable > 0 {
+		rate = order.TaxAmount / taxable
+	}
+
+	applyCharges(&breakdown, method, coupon, rate)
+	if coupon == nil {
+		breakdown.CouponCode = order.CouponCode
+	}
+
+	order.Subtotal = breakdown.Subtotal
+	order.DiscountAmount = breakdown.Discount
+	order.ShippingAmount = breakdown.Shipping
+	order.TaxRate = breakdown.TaxRate
+	order.TaxAmount = breakdown.Tax
+	order.TotalAmount = breakdown.Total
+	return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"subtotal":        order.Subtotal,
+		"discount_amount": order.DiscountAmount,
+		"shipping_amount": order.ShippingAmount,
+		"tax_rate":        order.TaxRate,
+		"tax_amount":      order.TaxAmount,
+		"total_amount":    order.TotalAmount,
+	}).Error
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestEditableOrderItem(t *testing.T) {
+	order := models.Order{OrderItems: []models.OrderItem{
+		{ID: 1, Quantity: 2},
+		{ID: 2, Quantity: 2, FulfilledQuantity: 1},
+		{ID: 3, Quantity: 2, CancelledQuantity: 1},
+		{ID: 4, Quantity: 2, CancelledQuantity: 2},
+	}}
+
+	tests := []struct {
+		itemID  string
+		wantErr bool
+	}{
+		{"1", false},
+		{"2", true},
+		{"3", true},
+		{"4", true},
+		{"9", true},
+		{"one", true},
+	}
+	for _, tt := range tests {
+		item, err := editableOrderItem(&order, tt.itemID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("editableOrderItem(%q) error = %v, wantErr %v", tt.itemID, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			if _, ok := err.(orderItemError); !ok {
+				t.Errorf("editableOrderItem(%q) error = %T, want an orderItemError", tt.itemID, err)
+			}
+			continue
+		}
+		if item != &order.OrderItems[0] {
+			t.Errorf("editableOrderItem(%q) did not return the order's own line", tt.itemID)
+		}
+	}
+}
+
+func TestRepriceOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order models.Order
+		want  models.Order
+	}{
+		{
+			name: "standard shipping with stored tax rate",
+			order: models.Order{ShippingMethod: "standard", TaxRate: 0.1,
+				OrderItems: []models.OrderItem{{Quantity: 2, Price: 10}, {Quantity: 1, Price: 5.5}}},
+			want: models.Order{Subtotal: 25.5, ShippingAmount: 5, TaxRate: 0.1, TaxAmount: 3.05, TotalAmount: 33.55},
+		},
+		{
+			name: "crosses free shipping threshold",
+			order: models.Order{ShippingMethod: "standard",
+				OrderItems: []models.OrderItem{{Quantity: 3, Price: 20}}},
+			want: models.Order{Subtotal: 60, TotalAmount: 60},
+		},
+		{
+			name: "tax rate derived from older totals",
+			order: models.Order{ShippingMethod: "express", Subtotal: 40, ShippingAmount: 15, TaxAmount: 5.5,
+				OrderItems: []models.OrderItem{{Quantity: 1, Price: 40}, {Quantity: 1, Price: 10}}},
+			want: models.Order{Subtotal: 50, ShippingAmount: 15, TaxRate: 0.1, TaxAmount: 6.5, TotalAmount: 71.5},
+		},
+		{
+			name: "retired shipping method keeps its charge",
+			order: models.Order{ShippingMethod: "courier", ShippingAmount: 8,
+				OrderItems: []models.OrderItem{{Quantity: 1, Price: 12}}},
+			want: models.Order{Subtotal: 12, ShippingAmount: 8, TotalAmount: 20},
+		},
+		{
+			name: "order without itemized pricing",
+			order: models.Order{TotalAmount: 99,
+				OrderItems: []models.OrderItem{{Quantity: 2, Price: 7.25}}},
+			want: models.Order{TotalAmount: 14.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			if err := repriceOrder(newDryRunDB(t), &order); err != nil {
+				t.Fatalf("repriceOrder() error = %v", err)
+			}
+			if order.Subtotal != tt.want.Subtotal || order.ShippingAmount != tt.want.ShippingAmount ||
+				order.TaxRate != tt.want.TaxRate || order.TaxAmount != tt.want.TaxAmount || order.TotalAmount != tt.want.TotalAmount {
+				t.Errorf("repriced to subtotal %v, shipping %v, tax %v at %v, total %v; want %v, %v, %v at %v, %v",
+					order.Subtotal, order.ShippingAmount, order.TaxAmount, order.TaxRate, order.TotalAmount,
+					tt.want.Subtotal, tt.want.ShippingAmount, tt.want.TaxAmount, tt.want.TaxRate, tt.want.TotalAmount)
+			}
+		})
+	}
+}
+
+func TestChangeOrderItemQuantityRejectsProgressedLines(t *testing.T) {
+	for _, item := range []models.OrderItem{
+		{ID: 1, Quantity: 2, FulfilledQuantity: 1},
+		{ID: 1, Quantity: 2, CancelledQuantity: 1},
+	} {
+		_, _, err := changeOrderItemQuantity(nil, &models.Order{}, &item, 3, "")
+		if _, ok := err.(orderItemError); !ok {
+			t.Errorf("changeOrderItemQuantity(%+v) error = %v, want an orderItemError", item, err)
+		}
+	}
+}
+
+func TestEditOrderRejectsInvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "latest"}}
+
+	editOrder(c, "", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestOrderStockMovement(t *testing.T) {
+	movement := orderStockMovement(models.OrderItem{ID: 8, OrderID: 5, ProductID: 3}, -2, models.StockReasonSale)
+	if movement.ProductID != 3 || movement.Quantity != -2 || movement.Reason != models.StockReasonSale {
+		t.Errorf("movement = %+v, want -2 of product 3 for a sale", movement)
+	}
+	if movement.OrderID == nil || *movement.OrderID != 5 || movement.OrderItemID == nil || *movement.OrderItemID != 8 {
+		t.Errorf("movement = %+v, want it linked to order 5 line 8", movement)
+	}
+}
+
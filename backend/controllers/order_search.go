@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/xlsx"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many orders are loaded at a time while exporting.
+const exportBatchSize = 500
+
+var orderExportHeader = []string{
+	"Order Number", "Placed At", "Status", "Customer Name", "Customer Email", "Guest",
+	"Items", "Item Count", "Subtotal", "Discount", "Shipping", "Tax", "Total", "Refunded",
+	"Coupon", "Payment Method", "Shipping Method", "Shipping Address",
+}
+
+// adminOrderQuery builds the order query for the admin order list and export
+// from the request's filters:
+//   - status, payment_method: exact match
+//   - from, to: placement date range (YYYY-MM-DD or RFC 3339; a bare end
+//     date includes the whole day), in the tz time zone
+//   - email, name: customer email or name, for users and guests alike
+//   - min_total, max_total: order total range
+//   - product_id, product: orders containing a product, by ID or by name/SKU
+//   - address: free text on the shipping address
+func adminOrderQuery(c *gin.Context) (*gorm.DB, error) {
+	query := database.DB.Model(&models.Order{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if method := c.Query("payment_method"); method != "" {
+		query = query.Where("payment_method = ?", method)
+	}
+
+	loc, err := timezoneParam(c)
+	if err != nil {
+		return nil, err
+	}
+	if from := c.Query("from"); from != "" {
+		start, err := parseDateParam(from, loc, false)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid from date")
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if to := c.Query("to"); to != "" {
+		end, err := parseDateParam(to, loc, true)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid to date")
+		}
+		query = query.Where("created_at < ?", end)
+	}
+
+	if email := strings.TrimSpace(c.Query("email")); email != "" {
+		users := database.DB.Model(&models.User{}).Select("id").Where("email LIKE ?", "%"+email+"%")
+		query = query.Where("(user_id IN (?) OR guest_email LIKE ?)", users, "%"+email+"%")
+	}
+	if name := strings.TrimSpace(c.Query("name")); name != "" {
+		users := database.DB.Model(&models.User{}).Select("id").
+			Where("CONCAT(first_name, ' ', last_name) LIKE ?", "%"+name+"%")
+		query = query.Where("(user_id IN (?) OR guest_name LIKE ?)", users, "%"+name+"%")
+	}
+
+	if value := c.Query("min_total"); value != "" {
+		minTotal, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid min_total")
+		}
+		query = query.Where("total_amount >= ?", minTotal)
+	}
+	if value := c.Query("max_total"); value != "" {
+		maxTotal, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid max_total")
+		}
+		query = query.Where("total_amount <= ?", maxTotal)
+	}
+
+	if value := c.Query("product_id"); value != "" {
+		productID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid product_id")
+		}
+		items := database.DB.Model(&models.OrderItem{}).Select("order_id").Where("product_id = ?", productID)
+		query = query.Where("id IN (?)", items)
+	}
+	if product := strings.TrimSpace(c.Query("product")); product != "" {
+		products := database.DB.Unscoped().Model(&models.Product{}).Select("id").
+			Where("name LIKE ? OR sku = ?", "%"+product+"%", product)
+		items := database.DB.Model(&models.OrderItem{}).Select("order_id").Where("product_id IN (?)", products)
+		query = query.Where("id IN (?)", items)
+	}
+
+	if address := strings.TrimSpace(c.Query("address")); address != "" {
+		query = query.Where("shipping_address LIKE ?", "%"+address+"%")
+	}
+
+	return query, nil
+}
+
+// ExportOrders streams the orders matching the admin order filters as CSV
+// or XLSX, loading them in batches.
+func ExportOrders(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format: " + format})
+		return
+	}
+
+	query, err := adminOrderQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc, _ := timezoneParam(c)
+
+	filename := "orders-" + time.Now().In(loc).Format("20060102") + "." + format
+	contentType := "text/csv"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	var writeRow func(order models.Order) error
+	var finish func() error
+	if format == "xlsx" {
+		sheet, err := xlsx.NewWriter(c.Writer, "Orders")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		header := make([]interface{}, len(orderExportHeader))
+		for i, title := range orderExportHeader {
+			header[i] = title
+		}
+		sheet.WriteRow(header...)
+		writeRow = func(order models.Order) error {
+			return sheet.WriteRow(orderExportRow(order, loc)...)
+		}
+		finish = sheet.Close
+	} else {
+		writer := csv.NewWriter(c.Writer)
+		writer.Write(orderExportHeader)
+		writeRow = func(order models.Order) error {
+			values := orderExportRow(order, loc)
+			record := make([]string, len(values))
+			for i, value := range values {
+				switch v := value.(type) {
+				case time.Time:
+					record[i] = v.Format("2006-01-02 15:04:05")
+				case float64:
+					record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+				default:
+					record[i] = fmt.Sprint(v)
+				}
+			}
+			return writer.Write(record)
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	}
+
+	var orders []models.Order
+	result := query.Preload("User").Preload("OrderItems.Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).FindInBatches(&orders, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, order := range orders {
+			if err := writeRow(order); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if result.Error != nil {
+		// Headers are already sent, so the best we can do is stop the stream
+		c.Error(result.Error)
+		return
+	}
+	if err := finish(); err != nil {
+		c.Error(err)
+	}
+}
+
+func orderExportRow(order models.Order, loc *time.Location) []interface{} {
+	name, email := order.GuestName, order.GuestEmail
+	if order.User != nil {
+		name = strings.TrimSpace(order.User.FirstName + " " + order.User.LastName)
+		email = order.User.Email
+	}
+
+	items := make([]string, 0, len(order.OrderItems))
+	count := 0
+	for _, item := range order.OrderItems {
+		items = append(items, fmt.Sprintf("%d x %s", item.Quantity, item.Product.Name))
+		count += item.Quantity
+	}
+
+	return []interface{}{
+		order.OrderNumber, order.CreatedAt.In(loc), order.Status, spreadsheetText(name), spreadsheetText(email), order.UserID == nil,
+		strings.Join(items, "; "), count, order.Subtotal, order.DiscountAmount, order.ShippingAmount,
+		order.TaxAmount, order.TotalAmount, order.RefundedAmount,
+		spreadsheetText(order.CouponCode), spreadsheetText(order.PaymentMethod), order.ShippingMethod, spreadsheetText(order.ShippingAddress),
+	}
+}
+
+// spreadsheetText keeps free text, most of it typed by customers, from being
+// run as a formula when the export is opened in a spreadsheet, by prefixing
+// text that starts like one with an apostrophe.
+func spreadsheetText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// timezoneParam reads the tz query parameter as an IANA time zone name,
+// defaulting to UTC.
+func timezoneParam(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid time zone: %s", name)
+	}
+	return loc, nil
+}
+
+// parseDateParam parses a YYYY-MM-DD date in loc or an RFC 3339 timestamp.
+// With endOfDay a bare date returns the start of the following day, for use
+// as an exclusive upper bound.
+func parseDateParam(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return t, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseDateParam(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+
+	tests := []struct {
+		name     string
+		value    string
+		loc      *time.Location
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{"date", "2024-03-05", time.UTC, false, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false},
+		{"end of day", "2024-03-05", time.UTC, true, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), false},
+		{"end of month", "2024-02-29", time.UTC, true, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"date in zone", "2024-03-05", tokyo, false, time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC), false},
+		{"timestamp", "2024-03-05T10:30:00Z", tokyo, true, time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC), false},
+		{"timestamp with offset", "2024-03-05T10:30:00+02:00", time.UTC, false, time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC), false},
+		{"invalid", "05/03/2024", time.UTC, false, time.Time{}, true},
+		{"empty", "", time.UTC, false, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDateParam(tt.value, tt.loc, tt.endOfDay)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDateParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseDateParam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimezoneParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"default", "", "UTC", false},
+		{"named zone", "?tz=UTC", "UTC", false},
+		{"unknown zone", "?tz=Mars/Olympus", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/admin/orders"+tt.query, nil)
+
+			loc, err := timezoneParam(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("timezoneParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && loc.String() != tt.want {
+				t.Errorf("timezoneParam() = %v, want %v", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpreadsheetText(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"Jane Doe":           "Jane Doe",
+		"=HYPERLINK(\"x\")":  "'=HYPERLINK(\"x\")",
+		"+1 555 0100":        "'+1 555 0100",
+		"-2+3":               "'-2+3",
+		"@SUM(A1)":           "'@SUM(A1)",
+		"\t=1":               "'\t=1",
+		"12 Main St, Apt =4": "12 Main St, Apt =4",
+	}
+	for value, want := range tests {
+		if got := spreadsheetText(value); got != want {
+			t.Errorf("spreadsheetText(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrderSubscriptionItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,min=1"`
+}
+
+type CreateOrderSubscriptionRequest struct {
+	Frequency       string `json:"frequency" binding:"required"`
+	ShippingAddress string `json:"shipping_address" binding:"required"`
+	PaymentMethod   string `json:"payment_method" binding:"required"`
+	ShippingMethod  string `json:"shipping_method"`
+	// StartAt is when the first order is placed; defaults to one period
+	// from now.
+	StartAt *time.Time                     `json:"start_at"`
+	Items   []OrderSubscriptionItemRequest `json:"items"`
+	// OrderID copies the items of one of the user's past orders instead.
+	OrderID uint `json:"order_id"`
+}
+
+func GetOrderSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var subscriptions []models.OrderSubscription
+	if err := database.DB.Preload("Items.Product").Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+func GetOrderSubscription(c *gin.Context) {
+	subscription, ok := findOrderSubscription(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+}
+
+func CreateOrderSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateOrderSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !models.IsValidSubscriptionFrequency(req.Frequency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frequency must be weekly or monthly"})
+		return
+	}
+	if req.ShippingMethod == "" {
+		req.ShippingMethod = defaultShippingMethod
+	}
+	if _, ok := shippingMethods[req.ShippingMethod]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method"})
+		return
+	}
+
+	now := time.Now()
+	if req.StartAt != nil && !req.StartAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start date must be in the future"})
+		return
+	}
+
+	if req.OrderID != 0 {
+		var order models.Order
+		if err := database.DB.Preload("OrderItems").Where("user_id = ?", userID).First(&order, req.OrderID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		req.Items = nil
+		for _, item := range order.OrderItems {
+			if quantity := item.Quantity - item.CancelledQuantity; quantity > 0 {
+				req.Items = append(req.Items, OrderSubscriptionItemRequest{ProductID: item.ProductID, Quantity: quantity})
+			}
+		}
+	}
+	if len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A subscription needs at least one item"})
+		return
+	}
+
+	subscription := models.OrderSubscription{
+		UserID:          userID.(uint),
+		Frequency:       req.Frequency,
+		Status:          models.OrderSubscriptionActive,
+		ShippingAddress: req.ShippingAddress,
+		PaymentMethod:   req.PaymentMethod,
+		ShippingMethod:  req.ShippingMethod,
+	}
+	subscription.NextRunAt = subscription.NextRunAfter(now)
+	if req.StartAt != nil {
+		subscription.NextRunAt = *req.StartAt
+	}
+
+	quantities := map[uint]int{}
+	for _, item := range req.Items {
+		var product models.Product
+		if err := database.DB.Scopes(models.VisibleProducts(now)).First(&product, item.ProductID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product %d is not available", item.ProductID)})
+			return
+		}
+		if _, seen := quantities[item.ProductID]; !seen {
+			subscription.Items = append(subscription.Items, models.OrderSubscriptionItem{ProductID: item.ProductID})
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	for i := range subscription.Items {
+		subscription.Items[i].Quantity = quantities[subscription.Items[i].ProductID]
+	}
+
+	if err := database.DB.Omit("Items.Product").Create(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+
+	database.DB.Preload("Items.Product").First(&subscription, subscription.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Subscription created successfully",
+		"subscription": subscription,
+	})
+}
+
+// PauseOrderSubscription stops orders being placed until the subscription
+// is resumed.
+func PauseOrderSubscription(c *gin.Context) {
+	subscription, ok := findOrderSubscription(c)
+	if !ok {
+		return
+	}
+	if subscription.Status != models.OrderSubscriptionActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only active subscriptions can be paused"})
+		return
+	}
+
+	subscription.Status = models.OrderSubscriptionPaused
+	saveOrderSubscription(c, &subscription, "Subscription paused successfully")
+}
+
+// ResumeOrderSubscription restarts a paused subscription at its next
+// scheduled run still ahead.
+func ResumeOrderSubscription(c *gin.Context) {
+	subscription, ok := findOrderSubscription(c)
+	if !ok {
+		return
+	}
+	if subscription.Status != models.OrderSubscriptionPaused {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only paused subscriptions can be resumed"})
+		return
+	}
+
+	subscription.Status = models.OrderSubscriptionActive
+	subscription.NextRunAt = subscription.NextRunFrom(time.Now())
+	saveOrderSubscription(c, &subscription, "Subscription resumed successfully")
+}
+
+// SkipOrderSubscription skips the next scheduled order.
+func SkipOrderSubscription(c *gin.Context) {
+	subscription, ok := findOrderSubscription(c)
+	if !ok {
+		return
+	}
+	if subscription.Status == models.OrderSubscriptionCancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subscription has been cancelled"})
+		return
+	}
+
+	subscription.NextRunAt = subscription.NextRunAfter(subscription.NextRunFrom(time.Now()))
+	saveOrderSubscription(c, &subscription, "Next order skipped successfully")
+}
+
+func CancelOrderSubscription(c *gin.Context) {
+	subscription, ok := findOrderSubscription(c)
+	if !ok {
+		return
+	}
+	if subscription.Status == models.OrderSubscriptionCancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subscription has already been cancelled"})
+		return
+	}
+
+	now := time.Now()
+	subscription.Status = models.OrderSubscriptionCancelled
+	subscription.CancelledAt = &now
+	saveOrderSubscription(c, &subscription, "Subscription cancelled successfully")
+}
+
+// findOrderSubscription loads the subscription named in the URL if it
+// belongs to the current user, responding with an error otherwise.
+func findOrderSubscription(c *gin.Context) (models.OrderSubscription, bool) {
+	var subscription models.OrderSubscription
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return subscription, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return subscription, false
+	}
+
+	if err := database.DB.Preload("Items.Product").Where("user_id = ?", userID).First(&subscription, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return subscription, false
+	}
+	return subscription, true
+}
+
+func saveOrderSubscription(c *gin.Context, subscription *models.OrderSubscription, message string) {
+	err := database.DB.Model(&models.OrderSubscription{}).Where("id = ?", subscription.ID).Updates(map[string]interface{}{
+		"status":       subscription.Status,
+		"next_run_at":  subscription.NextRunAt,
+		"cancelled_at": subscription.CancelledAt,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      message,
+		"subscription": subscription,
+	})
+}
+
+// PlaceDueSubscriptionOrders places an order for every active subscription
+// whose next run has passed and moves it on to its next run. Runs missed
+// while the scheduler was down are not made up. It returns the number of
+// orders placed.
+func PlaceDueSubscriptionOrders(now time.Time) (int, error) {
+	var due []models.OrderSubscription
+	if err := database.DB.Where("status = ? AND next_run_at <= ?", models.OrderSubscriptionActive, now).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	placed := 0
+	for _, subscription := range due {
+		// Claim the run, so it is only placed once if several instances run
+		// the scheduler
+		result := database.DB.Model(&models.OrderSubscription{}).
+			Where("id = ? AND status = ? AND next_run_at = ?", subscription.ID, models.OrderSubscriptionActive, subscription.NextRunAt).
+			Update("next_run_at", subscription.NextRunFrom(now))
+		if result.Error != nil {
+			return placed, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		order, err := placeSubscriptionOrder(subscription, now)
+		updates := map[string]interface{}{"last_run_at": now, "last_error": ""}
+		if err != nil {
+			// Cart problems are the customer's to see; anything else is logged
+			if _, ok := err.(checkoutError); !ok {
+				log.Printf("Failed to place order for subscription %d: %v", subscription.ID, err)
+				err = checkoutError{message: "The order could not be placed"}
+			}
+			updates["last_error"] = err.Error()
+		} else {
+			updates["last_order_id"] = order.ID
+			placed++
+		}
+		database.DB.Model(&models.OrderSubscription{}).Where("id = ?", subscription.ID).Updates(updates)
+
+		sendSubscriptionOrderNotice(subscription, order, err)
+	}
+	return placed, nil
+}
+
+// placeSubscriptionOrder orders the subscription's items at current prices.
+// Products that can no longer be ordered are left out and quantities are
+// capped at the stock available; the run fails only if nothing is left.
+func placeSubscriptionOrder(subscription models.OrderSubscription, now time.Time) (models.Order, error) {
+	var items []models.OrderSubscriptionItem
+	if err := database.DB.Preload("Product").Where("subscription_id = ?", subscription.ID).Find(&items).Error; err != nil {
+		return models.Order{}, err
+	}
+
+	var cartItems []models.Cart
+	for _, item := range items {
+		line := models.Cart{ProductID: item.ProductID, Quantity: item.Quantity, Product: item.Product}
+		for _, warning := range line.Warnings(now) {
+			switch warning.Type {
+			case models.CartWarningStockReduced:
+				line.Quantity = line.Product.Stock
+			case models.CartWarningOutOfStock, models.CartWarningUnavailable:
+				line.Quantity = 0
+			}
+		}
+		if line.Quantity > 0 {
+			cartItems = append(cartItems, line)
+		}
+	}
+	if len(cartItems) == 0 {
+		return models.Order{}, checkoutError{message: "None of the subscribed products are available"}
+	}
+
+	breakdown, _, err := priceCheckout(database.DB, cartItems, checkoutInput{ShippingMethod: subscription.ShippingMethod}, now)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	order := models.Order{
+		UserID:          &subscription.UserID,
+		SubscriptionID:  &subscription.ID,
+		ShippingAddress: subscription.ShippingAddress,
+		PaymentMethod:   subscription.PaymentMethod,
+	}
+	var events []notifications.Event
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		events, err = insertOrder(tx, &order, cartItems, breakdown, now)
+		return err
+	})
+	if err != nil {
+		return order, err
+	}
+	notifications.Publish(events...)
+	return order, nil
+}
+
+func sendSubscriptionOrderNotice(subscription models.OrderSubscription, order models.Order, placeErr error) {
+	var user models.User
+	if err := database.DB.First(&user, subscription.UserID).Error; err != nil {
+		return
+	}
+
+	message := notifications.Message{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Type:    "subscription.order_placed",
+		Subject: "Your subscription order " + order.OrderNumber + " has been placed",
+		Body:    fmt.Sprintf("Hi %s, we placed your %s order %s for %.2f.", user.FirstName, subscription.Frequency, order.OrderNumber, order.TotalAmount),
+		Link:    os.Getenv("FRONTEND_URL") + fmt.Sprintf("/orders/%d", order.ID),
+		Data:    map[string]interface{}{"subscription_id": subscription.ID, "order_id": order.ID},
+	}
+	if placeErr != nil {
+		message.Type = "subscription.order_failed"
+		message.Subject = "We could not place your subscription order"
+		message.Body = fmt.Sprintf("Hi %s, your %s order could not be placed: %s.", user.FirstName, subscription.Frequency, placeErr.Error())
+		message.Link = os.Getenv("FRONTEND_URL") + "/subscriptions"
+		message.Data = map[string]interface{}{"subscription_id": subscription.ID}
+	}
+
+	if err := notifications.Send(notifications.ChannelEmail, message); err != nil {
+		log.Printf("Failed to send subscription notice for subscription %d: %v", subscription.ID, err)
+	}
+}
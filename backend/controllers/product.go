@@ -1,47 +1,106 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"ecommerce-backend/database"
 	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	Stock       int     `json:"stock" binding:"required,gte=0"`
-	Image       string  `json:"image"`
-	CategoryID  uint    `json:"category_id" binding:"required"`
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Price       float64                `json:"price" binding:"required,gt=0"`
+	Stock       int                    `json:"stock" binding:"required,gte=0"`
+	Cost        *float64               `json:"cost" binding:"omitempty,gte=0"`
+	Image       string                 `json:"image"`
+	CategoryID  uint                   `json:"category_id" binding:"required"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Status      string                 `json:"status"`
+	PublishAt   *time.Time             `json:"publish_at"`
+	UnpublishAt *time.Time             `json:"unpublish_at"`
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
-	Image       string  `json:"image"`
-	CategoryID  uint    `json:"category_id"`
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Price       float64                `json:"price"`
+	Stock       *int                   `json:"stock" binding:"omitempty,gte=0"`
+	Cost        *float64               `json:"cost" binding:"omitempty,gte=0"`
+	Image       string                 `json:"image"`
+	CategoryID  uint                   `json:"category_id"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Status      string                 `json:"status"`
+	PublishAt   *time.Time             `json:"publish_at"`
+	UnpublishAt *time.Time             `json:"unpublish_at"`
+	// ClearSchedule removes publish_at and unpublish_at before applying the
+	// values in this request.
+	ClearSchedule bool `json:"clear_schedule"`
 }
 
 func GetProducts(c *gin.Context) {
+	listProducts(c, false)
+}
+
+// GetAdminProducts lists products in every state, optionally filtered by status.
+func GetAdminProducts(c *gin.Context) {
+	listProducts(c, true)
+}
+
+func listProducts(c *gin.Context, includeHidden bool) {
 	// Get query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	categoryID := c.Query("category_id")
+	categorySlug := c.Query("category")
 	search := c.Query("search")
 
 	// Calculate offset
 	offset := (page - 1) * limit
 
 	// Build query
-	query := database.DB.Model(&models.Product{}).Preload("Category")
+	query := database.DB.Model(&models.Product{}).Preload("Category").Preload("AttributeValues.Attribute")
 
-	// Filter by category if provided
-	if categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
+	// Only published products are visible on the storefront
+	if includeHidden {
+		if status := c.Query("status"); status != "" {
+			query = query.Where("products.status = ?", status)
+		}
+	} else {
+		query = query.Scopes(models.VisibleProducts(time.Now()))
+	}
+
+	// Filter by categories (including their descendants) if provided, given
+	// as a comma separated list of IDs or slugs
+	if categoryID != "" || categorySlug != "" {
+		lookupValue := categoryID
+		if lookupValue == "" {
+			lookupValue = categorySlug
+		}
+		var categories []models.Category
+		for _, value := range strings.Split(lookupValue, ",") {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			category, err := findCategoryByIDOrSlug(value)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+				return
+			}
+			categories = append(categories, category)
+		}
+		if len(categories) > 0 {
+			query = query.Where("category_id IN (?)", categorySubtreeQuery(categories...))
+		}
 	}
 
 	// Search by name if provided
@@ -49,6 +108,16 @@ func GetProducts(c *gin.Context) {
 		query = query.Where("name LIKE ?", "%"+search+"%")
 	}
 
+	// Filter by attributes if provided (attr[code]=value)
+	if filters := c.QueryMap("attr"); len(filters) > 0 {
+		filtered, err := applyAttributeFilters(query, filters)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = filtered
+	}
+
 	// Get total count
 	var totalCount int64
 	query.Count(&totalCount)
@@ -61,9 +130,15 @@ func GetProducts(c *gin.Context) {
 	}
 
 	// Convert to response format
+	categoryLookup := loadCategoryLookup()
 	var productResponses []models.ProductResponse
 	for _, product := range products {
-		productResponses = append(productResponses, product.ToResponse())
+		response := product.ToResponse()
+		if includeHidden {
+			response = product.ToAdminResponse()
+		}
+		response.Breadcrumbs = models.BuildBreadcrumbs(product.CategoryID, categoryLookup)
+		productResponses = append(productResponses, response)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -84,13 +159,41 @@ func GetProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := database.DB.Preload("Category").First(&product, productID).Error; err != nil {
+	if err := database.DB.Preload("Category").Preload("AttributeValues.Attribute").
+		Scopes(models.VisibleProducts(time.Now())).First(&product, productID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
 
+	response := product.ToResponse()
+	response.Breadcrumbs = models.BuildBreadcrumbs(product.CategoryID, loadCategoryLookup())
+
 	c.JSON(http.StatusOK, gin.H{
-		"product": product.ToResponse(),
+		"product": response,
+	})
+}
+
+// PreviewProduct lets admins view a product regardless of its publish state.
+func PreviewProduct(c *gin.Context) {
+	id := c.Param("id")
+	productID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.Preload("Category").Preload("AttributeValues.Attribute").First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	response := product.ToAdminResponse()
+	response.Breadcrumbs = models.BuildBreadcrumbs(product.CategoryID, loadCategoryLookup())
+
+	c.JSON(http.StatusOK, gin.H{
+		"product": response,
+		"visible": product.IsVisibleAt(time.Now()),
 	})
 }
 
@@ -108,26 +211,101 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	// New products are active unless a status is given, as before statuses
+	// existed
+	status := req.Status
+	if status == "" {
+		status = models.ProductStatusActive
+	}
+	if !models.IsValidProductStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product status"})
+		return
+	}
+	if req.PublishAt != nil && req.UnpublishAt != nil && !req.UnpublishAt.After(*req.PublishAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unpublish_at must be after publish_at"})
+		return
+	}
+
+	// Check if SKU is already in use
+	if req.SKU != "" && productSKUTaken(req.SKU, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product SKU already exists"})
+		return
+	}
+
+	// Validate attribute values against the category's definitions
+	attributeValues, err := resolveAttributeValues(database.DB, req.CategoryID, req.Attributes, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every product needs a SKU to be exported and imported by
+	if req.SKU == "" {
+		if req.SKU, err = utils.GenerateSKU(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate SKU"})
+			return
+		}
+	}
+
 	product := models.Product{
+		SKU:         req.SKU,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Stock:       req.Stock,
 		Image:       req.Image,
 		CategoryID:  req.CategoryID,
+		Status:      status,
+		PublishAt:   req.PublishAt,
+		UnpublishAt: req.UnpublishAt,
 	}
 
-	if err := database.DB.Create(&product).Error; err != nil {
+	userID, _ := c.Get("user_id")
+	adminID := userID.(uint)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		// Opening stock goes through the ledger like any other stock change
+		_, err := moveStock(tx, models.StockMovement{
+			ProductID: product.ID,
+			Quantity:  req.Stock,
+			Reason:    models.StockReasonRestock,
+			UserID:    &adminID,
+			Note:      "Initial stock",
+		})
+		if err != nil {
+			return err
+		}
+		if req.Cost != nil {
+			err := setProductCost(tx, models.ProductCost{
+				ProductID: product.ID,
+				Cost:      *req.Cost,
+				Source:    models.ProductCostSourceManual,
+				UserID:    &adminID,
+				Note:      "Initial cost",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return saveAttributeValues(tx, product.ID, attributeValues)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// Another product took the SKU since it was checked
+		c.JSON(http.StatusConflict, gin.H{"error": "Product SKU already exists"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
 	// Load category relation
-	database.DB.Preload("Category").First(&product, product.ID)
+	database.DB.Preload("Category").Preload("AttributeValues.Attribute").First(&product, product.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Product created successfully",
-		"product": product.ToResponse(),
+		"product": product.ToAdminResponse(),
 	})
 }
 
@@ -146,7 +324,7 @@ func UpdateProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := database.DB.First(&product, productID).Error; err != nil {
+	if err := database.DB.Preload("AttributeValues").First(&product, productID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
@@ -160,7 +338,18 @@ func UpdateProduct(c *gin.Context) {
 		}
 	}
 
+	// Check if new SKU is already in use
+	if req.SKU != "" && req.SKU != product.SKU && productSKUTaken(req.SKU, product.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product SKU already exists"})
+		return
+	}
+
+	oldPrice := product.Price
+
 	// Update product fields
+	if req.SKU != "" {
+		product.SKU = req.SKU
+	}
 	if req.Name != "" {
 		product.Name = req.Name
 	}
@@ -170,27 +359,116 @@ func UpdateProduct(c *gin.Context) {
 	if req.Price > 0 {
 		product.Price = req.Price
 	}
-	if req.Stock >= 0 {
-		product.Stock = req.Stock
-	}
 	if req.Image != "" {
 		product.Image = req.Image
 	}
 	if req.CategoryID != 0 {
 		product.CategoryID = req.CategoryID
 	}
+	archiving := false
+	if req.Status != "" {
+		if !models.IsValidProductStatus(req.Status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product status"})
+			return
+		}
+		archiving = req.Status == models.ProductStatusArchived && product.Status != models.ProductStatusArchived
+		if req.Status != models.ProductStatusArchived {
+			product.ArchivedAt = nil
+		}
+		product.Status = req.Status
+	}
+	if req.ClearSchedule {
+		product.PublishAt = nil
+		product.UnpublishAt = nil
+	}
+	if req.PublishAt != nil {
+		product.PublishAt = req.PublishAt
+	}
+	if req.UnpublishAt != nil {
+		product.UnpublishAt = req.UnpublishAt
+	}
+	if product.PublishAt != nil && product.UnpublishAt != nil && !product.UnpublishAt.After(*product.PublishAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unpublish_at must be after publish_at"})
+		return
+	}
 
-	if err := database.DB.Save(&product).Error; err != nil {
+	// Revalidate attribute values when they or the category change
+	updateAttributes := req.Attributes != nil || req.CategoryID != 0
+	var attributeValues []models.ProductAttributeValue
+	if updateAttributes {
+		attributeValues, err = resolveAttributeValues(database.DB, product.CategoryID, req.Attributes, product.AttributeValues)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+	adminID := userID.(uint)
+	var events []notifications.Event
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if archiving {
+			if err := archiveProduct(tx, &product); err != nil {
+				return err
+			}
+		}
+		// Stock and cost are only changed through their histories, and the
+		// rating totals by review moderation
+		if err := tx.Omit("AttributeValues", "Stock", "Cost", "RatingSum", "RatingCount").Save(&product).Error; err != nil {
+			return err
+		}
+		if req.Cost != nil {
+			err := setProductCost(tx, models.ProductCost{
+				ProductID: product.ID,
+				Cost:      *req.Cost,
+				Source:    models.ProductCostSourceManual,
+				UserID:    &adminID,
+				Note:      "Cost set in product update",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if req.Stock != nil {
+			stockEvents, err := setStock(tx, models.StockMovement{
+				ProductID: product.ID,
+				Reason:    models.StockReasonAdjustment,
+				UserID:    &adminID,
+				Note:      "Stock set in product update",
+			}, *req.Stock)
+			if err != nil {
+				return err
+			}
+			events = append(events, stockEvents...)
+		}
+		if !updateAttributes {
+			return nil
+		}
+		return saveAttributeValues(tx, product.ID, attributeValues)
+	})
+	if _, ok := err.(inventoryError); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product SKU already exists"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
 
+	// Stock alerts come from the ledger, so compare prices only here
+	events = append(events, notifications.ProductChangeEvents(product.ID, oldPrice, product.Price, product.Stock, product.Stock)...)
+	notifications.Publish(events...)
+
 	// Load category relation
-	database.DB.Preload("Category").First(&product, product.ID)
+	database.DB.Preload("Category").Preload("AttributeValues.Attribute").First(&product, product.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Product updated successfully",
-		"product": product.ToResponse(),
+		"product": product.ToAdminResponse(),
 	})
 }
 
@@ -208,23 +486,24 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// Check if product is in any carts
-	var cartCount int64
-	database.DB.Model(&models.Cart{}).Where("product_id = ?", productID).Count(&cartCount)
-	if cartCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete product that is in carts"})
-		return
-	}
-
-	// Check if product is in any orders
+	// Products with order history must stay resolvable, so they can only be archived
 	var orderItemCount int64
 	database.DB.Model(&models.OrderItem{}).Where("product_id = ?", productID).Count(&orderItemCount)
 	if orderItemCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete product that has been ordered"})
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete product that has been ordered, archive it instead"})
 		return
 	}
 
-	if err := database.DB.Delete(&product).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeProductFromCarts(tx, product); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&product).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
@@ -233,3 +512,142 @@ func DeleteProduct(c *gin.Context) {
 		"message": "Product deleted successfully",
 	})
 }
+
+func ArchiveProduct(c *gin.Context) {
+	id := c.Param("id")
+	productID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	if product.Status == models.ProductStatusArchived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product is already archived"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := archiveProduct(tx, &product); err != nil {
+			return err
+		}
+		return tx.Model(&product).Updates(map[string]interface{}{
+			"status":      product.Status,
+			"archived_at": product.ArchivedAt,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive product"})
+		return
+	}
+
+	// Load category relation
+	database.DB.Preload("Category").Preload("AttributeValues.Attribute").First(&product, product.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product archived successfully",
+		"product": product.ToAdminResponse(),
+	})
+}
+
+func RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+	productID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Restored products go back on sale unless a different status is requested
+	var req struct {
+		Status string `json:"status"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Status == "" {
+		req.Status = models.ProductStatusActive
+	}
+	if req.Status != models.ProductStatusActive && req.Status != models.ProductStatusDraft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Products can only be restored as active or draft"})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	if product.Status != models.ProductStatusArchived {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product is not archived"})
+		return
+	}
+
+	if err := database.DB.Model(&product).Updates(map[string]interface{}{
+		"status":      req.Status,
+		"archived_at": nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product"})
+		return
+	}
+
+	// Load category relation
+	database.DB.Preload("Category").Preload("AttributeValues.Attribute").First(&product, product.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product restored successfully",
+		"product": product.ToAdminResponse(),
+	})
+}
+
+// archiveProduct marks the product archived and takes it out of every cart.
+// The caller is responsible for persisting the product fields.
+func archiveProduct(tx *gorm.DB, product *models.Product) error {
+	now := time.Now()
+	product.Status = models.ProductStatusArchived
+	product.ArchivedAt = &now
+	return removeProductFromCarts(tx, *product)
+}
+
+// removeProductFromCarts deletes the product from all carts and leaves a
+// notice for each affected customer.
+func removeProductFromCarts(tx *gorm.DB, product models.Product) error {
+	var cartItems []models.Cart
+	if err := tx.Where("product_id = ?", product.ID).Find(&cartItems).Error; err != nil {
+		return err
+	}
+	if len(cartItems) == 0 {
+		return nil
+	}
+
+	for _, item := range cartItems {
+		notice := models.CartNotice{
+			UserID:      item.UserID,
+			GuestCartID: item.GuestCartID,
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Type:        models.CartNoticeProductUnavailable,
+			Message:     product.Name + " is no longer available and was removed from your cart",
+		}
+		if err := tx.Create(&notice).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Where("product_id = ?", product.ID).Delete(&models.Cart{}).Error
+}
+
+func productSKUTaken(sku string, excludeID uint) bool {
+	var count int64
+	database.DB.Model(&models.Product{}).Where("sku = ? AND id != ?", sku, excludeID).Count(&count)
+	return count > 0
+}
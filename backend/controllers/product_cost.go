@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductCostRequest struct {
+	Cost float64 `json:"cost" binding:"gte=0"`
+	Note string  `json:"note" binding:"max=500"`
+}
+
+// GetProductCosts lists the changes to a product's cost, newest first.
+func GetProductCosts(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.Unscoped().First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.ProductCost{}).Where("product_id = ?", product.ID)
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var costs []models.ProductCost
+	if err := query.Preload("User").Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&costs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cost history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":  product.ID,
+		"cost":        product.Cost,
+		"costs":       costs,
+		"total":       totalCount,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// UpdateProductCost sets a product's cost. Lines already sold keep the cost
+// they were sold at.
+func UpdateProductCost(c *gin.Context) {
+	var req ProductCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	adminID := userID.(uint)
+
+	var product models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().First(&product, c.Param("id")).Error; err != nil {
+			return err
+		}
+		return setProductCost(tx, models.ProductCost{
+			ProductID: product.ID,
+			Cost:      req.Cost,
+			Source:    models.ProductCostSourceManual,
+			UserID:    &adminID,
+			Note:      strings.TrimSpace(req.Note),
+		})
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cost"})
+		return
+	}
+
+	cost := roundMoney(req.Cost)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Product cost updated successfully",
+		"product_id": product.ID,
+		"cost":       cost,
+	})
+}
+
+// setProductCost makes change the product's current cost and records it in
+// the cost history, unless the cost is unchanged.
+func setProductCost(tx *gorm.DB, change models.ProductCost) error {
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "cost").
+		First(&product, change.ProductID).Error; err != nil {
+		return err
+	}
+
+	change.Cost = roundMoney(change.Cost)
+	if product.Cost != nil && *product.Cost == change.Cost {
+		return nil
+	}
+	change.PreviousCost = product.Cost
+	if err := tx.Omit("User").Create(&change).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&models.Product{}).Where("id = ?", product.ID).Update("cost", change.Cost).Error
+}
+
+// currentProductCost is the cost a product sells at now, snapshotted onto
+// order lines; nil when no cost has been set.
+func currentProductCost(tx *gorm.DB, productID uint) (*float64, error) {
+	var product models.Product
+	if err := tx.Unscoped().Select("id", "cost").First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	return product.Cost, nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSetProductCost(t *testing.T) {
+	db := newDryRunDB(t)
+	statements := recordStatements(db)
+	purchaseOrderID := uint(3)
+
+	err := setProductCost(db, models.ProductCost{
+		ProductID:       7,
+		Cost:            4.255,
+		Source:          models.ProductCostSourcePurchaseOrder,
+		PurchaseOrderID: &purchaseOrderID,
+	})
+	if err != nil {
+		t.Fatalf("setProductCost() error = %v", err)
+	}
+
+	if len(*statements) != 3 {
+		t.Fatalf("statements = %+v, want the product locked, the change recorded and the cost set", *statements)
+	}
+	lock, change, update := (*statements)[0], (*statements)[1], (*statements)[2]
+	if lock.Action != "query" || lock.Table != "products" || lock.Where["id"] != uint(7) {
+		t.Errorf("first statement = %+v, want product 7 read", lock)
+	}
+	if change.Action != "create" || change.Table != "product_costs" {
+		t.Fatalf("second statement = %+v, want a cost history entry", change)
+	}
+	if change.Values["product_id"] != uint(7) || change.Values["cost"] != 4.26 || change.Values["previous_cost"] != nil ||
+		change.Values["source"] != models.ProductCostSourcePurchaseOrder || change.Values["purchase_order_id"] != uint(3) {
+		t.Errorf("history entry = %+v, want product 7 costing 4.26 from purchase order 3", change.Values)
+	}
+	if update.Action != "update" || update.Table != "products" || update.Values["cost"] != 4.26 {
+		t.Errorf("third statement = %+v, want the product's cost set to 4.26", update)
+	}
+}
+
+func TestUpdateProductCostRejectsNegativeCost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PUT", "/admin/products/7/cost", strings.NewReader(`{"cost": -1}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+
+	UpdateProductCost(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("UpdateProductCost() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
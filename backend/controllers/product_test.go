@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+	"ecommerce-backend/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB returns a MySQL handle that builds statements without running
+// them, for helpers that take a transaction but whose effect on the models
+// can be checked without a database.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test:test@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db
+}
+
+func TestArchiveProduct(t *testing.T) {
+	publishAt := time.Now().Add(-time.Hour)
+	product := models.Product{ID: 7, Name: "Lamp", Status: models.ProductStatusActive, PublishAt: &publishAt}
+
+	before := time.Now()
+	if err := archiveProduct(newDryRunDB(t), &product); err != nil {
+		t.Fatalf("archiveProduct() error = %v", err)
+	}
+	if product.Status != models.ProductStatusArchived {
+		t.Errorf("Status = %q, want %q", product.Status, models.ProductStatusArchived)
+	}
+	if product.ArchivedAt == nil || product.ArchivedAt.Before(before) {
+		t.Errorf("ArchivedAt = %v, want the time of archiving", product.ArchivedAt)
+	}
+	if product.IsVisibleAt(time.Now()) {
+		t.Error("archived product is still visible")
+	}
+}
@@ -0,0 +1,558 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PurchaseOrderLineRequest is a purchase order line. UnitCost defaults to the
+// supplier's cost for the product.
+type PurchaseOrderLineRequest struct {
+	ProductID uint     `json:"product_id" binding:"required"`
+	Quantity  int      `json:"quantity" binding:"required,gt=0"`
+	UnitCost  *float64 `json:"unit_cost" binding:"omitempty,gte=0"`
+}
+
+// PurchaseOrderRequest drafts a purchase order. WarehouseID is where the goods
+// are delivered and defaults to the default warehouse.
+type PurchaseOrderRequest struct {
+	SupplierID  uint                       `json:"supplier_id" binding:"required"`
+	WarehouseID *uint                      `json:"warehouse_id"`
+	ExpectedAt  *time.Time                 `json:"expected_at"`
+	Notes       string                     `json:"notes"`
+	Lines       []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Lines []struct {
+		LineID   uint `json:"line_id" binding:"required"`
+		Quantity int  `json:"quantity" binding:"required,gt=0"`
+	} `json:"lines" binding:"required,min=1,dive"`
+	Note string `json:"note"`
+}
+
+// purchaseOrderError is a purchase order change its state or contents do
+// not allow, reported to the admin as is.
+type purchaseOrderError string
+
+func (e purchaseOrderError) Error() string {
+	return string(e)
+}
+
+func GetPurchaseOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.PurchaseOrder{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var orders []models.PurchaseOrder
+	if err := query.Preload("Supplier", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Warehouse").Order("created_at DESC").Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purchase_orders": orders,
+		"total":           totalCount,
+		"page":            page,
+		"limit":           limit,
+		"total_pages":     (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+func GetPurchaseOrder(c *gin.Context) {
+	var order models.PurchaseOrder
+	if err := loadPurchaseOrder(database.DB, &order, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purchase_order": order,
+	})
+}
+
+// CreatePurchaseOrder drafts a purchase order. Lines are priced at the
+// supplier's cost unless a unit cost is given.
+func CreatePurchaseOrder(c *gin.Context) {
+	var req PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := c.Get("user_id")
+
+	order := models.PurchaseOrder{Status: models.PurchaseOrderDraft, CreatedByID: userID.(uint)}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyPurchaseOrderRequest(tx, &order, req); err != nil {
+			return err
+		}
+
+		sequence, err := models.NextSequenceValue(tx, "purchase_order")
+		if err != nil {
+			return err
+		}
+		order.Number = utils.FormatPurchaseOrderNumber(utils.PurchaseOrderNumberPrefix(), sequence)
+		return tx.Omit("Supplier", "Warehouse").Create(&order).Error
+	})
+	if !respondPurchaseOrderError(c, err, "Failed to create purchase order") {
+		return
+	}
+
+	loadPurchaseOrder(database.DB, &order, order.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Purchase order created successfully",
+		"purchase_order": order,
+	})
+}
+
+// UpdatePurchaseOrder replaces the supplier, destination, notes and lines of
+// a draft purchase order.
+func UpdatePurchaseOrder(c *gin.Context) {
+	var req PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.PurchaseOrder
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, &order, c.Param("id")); err != nil {
+			return err
+		}
+		if order.Status != models.PurchaseOrderDraft {
+			return purchaseOrderError("Only draft purchase orders can be edited")
+		}
+
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&models.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		if err := applyPurchaseOrderRequest(tx, &order, req); err != nil {
+			return err
+		}
+		return tx.Omit("Supplier", "Warehouse").Save(&order).Error
+	})
+	if !respondPurchaseOrderError(c, err, "Failed to update purchase order") {
+		return
+	}
+
+	loadPurchaseOrder(database.DB, &order, order.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Purchase order updated successfully",
+		"purchase_order": order,
+	})
+}
+
+// SubmitPurchaseOrder marks a draft as sent to the supplier, after which
+// goods can be received against it.
+func SubmitPurchaseOrder(c *gin.Context) {
+	changePurchaseOrder(c, "Purchase order submitted successfully", func(tx *gorm.DB, order *models.PurchaseOrder) ([]notifications.Event, error) {
+		if order.Status != models.PurchaseOrderDraft {
+			return nil, purchaseOrderError("Only draft purchase orders can be submitted")
+		}
+		now := time.Now()
+		order.Status = models.PurchaseOrderOrdered
+		order.OrderedAt = &now
+		return nil, tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).
+			Updates(map[string]interface{}{"status": order.Status, "ordered_at": now}).Error
+	})
+}
+
+// ReceivePurchaseOrder books goods received against an ordered purchase
+// order into stock at its warehouse, and makes each line's unit cost its
+// product's cost. Deliveries may arrive in parts; a line cannot receive more
+// than is outstanding on it.
+func ReceivePurchaseOrder(c *gin.Context) {
+	var req ReceivePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	adminID := userID.(uint)
+
+	changePurchaseOrder(c, "Goods received successfully", func(tx *gorm.DB, order *models.PurchaseOrder) ([]notifications.Event, error) {
+		if !order.IsOpen() {
+			return nil, purchaseOrderError("Goods can only be received against ordered purchase orders")
+		}
+
+		note := "Received on " + order.Number
+		if strings.TrimSpace(req.Note) != "" {
+			note += ": " + strings.TrimSpace(req.Note)
+		}
+
+		var events []notifications.Event
+		for _, received := range req.Lines {
+			var line *models.PurchaseOrderLine
+			for i := range order.Lines {
+				if order.Lines[i].ID == received.LineID {
+					line = &order.Lines[i]
+				}
+			}
+			if line == nil {
+				return nil, purchaseOrderError(fmt.Sprintf("Line %d is not on this purchase order", received.LineID))
+			}
+			if received.Quantity > line.OutstandingQuantity() {
+				return nil, purchaseOrderError(fmt.Sprintf("Line %d has only %d units outstanding", line.ID, line.OutstandingQuantity()))
+			}
+
+			line.ReceivedQuantity += received.Quantity
+			if err := tx.Model(&models.PurchaseOrderLine{}).Where("id = ?", line.ID).
+				Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+				return nil, err
+			}
+			stockEvents, err := moveStock(tx, models.StockMovement{
+				ProductID:       line.ProductID,
+				WarehouseID:     &order.WarehouseID,
+				Quantity:        received.Quantity,
+				Reason:          models.StockReasonRestock,
+				PurchaseOrderID: &order.ID,
+				UserID:          &adminID,
+				Note:            note,
+			})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, stockEvents...)
+
+			// Products cost what they were last bought for
+			err = setProductCost(tx, models.ProductCost{
+				ProductID:       line.ProductID,
+				Cost:            line.UnitCost,
+				Source:          models.ProductCostSourcePurchaseOrder,
+				PurchaseOrderID: &order.ID,
+				UserID:          &adminID,
+				Note:            "Received on " + order.Number,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		updates := map[string]interface{}{"status": order.DeriveStatus()}
+		if updates["status"] == models.PurchaseOrderReceived {
+			updates["received_at"] = time.Now()
+		}
+		return events, tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).Updates(updates).Error
+	})
+}
+
+// CancelPurchaseOrder cancels a purchase order nothing has been received
+// against. One that has received goods is closed instead, writing off what
+// is still outstanding.
+func CancelPurchaseOrder(c *gin.Context) {
+	changePurchaseOrder(c, "Purchase order cancelled successfully", func(tx *gorm.DB, order *models.PurchaseOrder) ([]notifications.Event, error) {
+		status := models.PurchaseOrderCancelled
+		switch order.Status {
+		case models.PurchaseOrderDraft, models.PurchaseOrderOrdered:
+		case models.PurchaseOrderPartiallyReceived:
+			status = models.PurchaseOrderClosed
+		default:
+			return nil, purchaseOrderError("Purchase order is already " + order.Status)
+		}
+		order.Status = status
+		return nil, tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).Update("status", status).Error
+	})
+}
+
+// changePurchaseOrder applies change to the purchase order named in the URL
+// with it locked, then responds with the order.
+func changePurchaseOrder(c *gin.Context, message string, change func(tx *gorm.DB, order *models.PurchaseOrder) ([]notifications.Event, error)) {
+	var order models.PurchaseOrder
+	var events []notifications.Event
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, &order, c.Param("id")); err != nil {
+			return err
+		}
+		var err error
+		events, err = change(tx, &order)
+		return err
+	})
+	if !respondPurchaseOrderError(c, err, "Failed to update purchase order") {
+		return
+	}
+	notifications.Publish(events...)
+
+	loadPurchaseOrder(database.DB, &order, order.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        message,
+		"purchase_order": order,
+	})
+}
+
+// respondPurchaseOrderError responds to a failed purchase order change and
+// reports whether the change succeeded.
+func respondPurchaseOrderError(c *gin.Context, err error, message string) bool {
+	if err == nil {
+		return true
+	}
+	if _, ok := err.(purchaseOrderError); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	} else if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order not found"})
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+	return false
+}
+
+// applyPurchaseOrderRequest sets the order's supplier, warehouse and lines
+// from req, pricing lines at the supplier's cost where no unit cost is
+// given, and totals it.
+func applyPurchaseOrderRequest(tx *gorm.DB, order *models.PurchaseOrder, req PurchaseOrderRequest) error {
+	var supplier models.Supplier
+	if err := tx.First(&supplier, req.SupplierID).Error; err != nil {
+		return purchaseOrderError("Supplier not found")
+	}
+	if !supplier.Active {
+		return purchaseOrderError("Supplier is inactive")
+	}
+
+	var warehouse models.Warehouse
+	if req.WarehouseID != nil {
+		if err := tx.First(&warehouse, *req.WarehouseID).Error; err != nil {
+			return purchaseOrderError("Warehouse not found")
+		}
+	} else {
+		var err error
+		if warehouse, err = defaultWarehouse(tx); err != nil {
+			return err
+		}
+	}
+
+	order.SupplierID = supplier.ID
+	order.WarehouseID = warehouse.ID
+	order.ExpectedAt = req.ExpectedAt
+	order.Notes = req.Notes
+	order.Lines = nil
+	order.Total = 0
+	for _, requested := range req.Lines {
+		var product models.Product
+		if err := tx.First(&product, requested.ProductID).Error; err != nil {
+			return purchaseOrderError(fmt.Sprintf("Product %d not found", requested.ProductID))
+		}
+
+		line := models.PurchaseOrderLine{ProductID: product.ID, Quantity: requested.Quantity}
+		var supplierProduct models.SupplierProduct
+		err := tx.Where("supplier_id = ? AND product_id = ?", supplier.ID, product.ID).First(&supplierProduct).Error
+		if err == nil {
+			line.SupplierSKU = supplierProduct.SupplierSKU
+			line.UnitCost = supplierProduct.Cost
+		} else if requested.UnitCost == nil {
+			return purchaseOrderError(fmt.Sprintf("%s has no cost from %s; give a unit cost", product.Name, supplier.Name))
+		}
+		if requested.UnitCost != nil {
+			line.UnitCost = roundMoney(*requested.UnitCost)
+		}
+
+		order.Lines = append(order.Lines, line)
+		order.Total += line.UnitCost * float64(line.Quantity)
+	}
+	order.Total = roundMoney(order.Total)
+	return nil
+}
+
+// lockPurchaseOrder loads a purchase order and its lines, locking the order
+// row so concurrent receipts apply one after another.
+func lockPurchaseOrder(tx *gorm.DB, order *models.PurchaseOrder, id interface{}) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(order, id).Error
+}
+
+func loadPurchaseOrder(db *gorm.DB, order *models.PurchaseOrder, id interface{}) error {
+	return db.Preload("Supplier", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Warehouse").Preload("Lines.Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).First(order, id).Error
+}
+
+// reorderSuggestion is a product to reorder. DailyVelocity is units sold per
+// day over the sales window and DaysOfCover how long stock and goods on
+// order last at that rate, null when nothing sold.
+type reorderSuggestion struct {
+	ProductID         uint     `json:"product_id"`
+	SKU               string   `json:"sku"`
+	Name              string   `json:"name"`
+	Stock             int      `json:"stock"`
+	OnOrder           int      `json:"on_order"`
+	Threshold         int      `json:"threshold"`
+	SoldUnits         int      `json:"sold_units"`
+	DailyVelocity     float64  `json:"daily_velocity"`
+	DaysOfCover       *float64 `json:"days_of_cover"`
+	SupplierID        *uint    `json:"supplier_id"`
+	SupplierName      string   `json:"supplier_name"`
+	SupplierSKU       string   `json:"supplier_sku"`
+	LeadTimeDays      int      `json:"lead_time_days"`
+	UnitCost          float64  `json:"unit_cost"`
+	SuggestedQuantity int      `json:"suggested_quantity"`
+	EstimatedCost     float64  `json:"estimated_cost"`
+}
+
+// GetReorderSuggestions suggests what to buy, from sales velocity over the
+// last days (30 by default). A product needs reordering when its stock plus
+// goods on order will not last its supplier's lead time and still leave its
+// low-stock threshold; the suggestion tops it up to cover the lead time
+// plus cover_days more (30 by default), in multiples of the supplier's
+// minimum order quantity. Products are bought from their preferred
+// supplier, else the cheapest. supplier_id limits the list to one supplier.
+func GetReorderSuggestions(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+	coverDays, err := strconv.Atoi(c.DefaultQuery("cover_days", "30"))
+	if err != nil || coverDays < 0 || coverDays > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cover_days must be between 0 and 365"})
+		return
+	}
+	now := time.Now()
+
+	var sales []struct {
+		ProductID uint
+		Quantity  int
+	}
+	r := reportRange{From: now.AddDate(0, 0, -days), To: now, Location: time.UTC}
+	if err := r.salesLines().Select("order_items.product_id, SUM(" + soldQuantity + ") AS quantity").
+		Group("order_items.product_id").Scan(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	sold := map[uint]int{}
+	for _, sale := range sales {
+		sold[sale.ProductID] = sale.Quantity
+	}
+
+	var incoming []struct {
+		ProductID uint
+		Quantity  int
+	}
+	open := database.DB.Model(&models.PurchaseOrder{}).Select("id").
+		Where("status IN ?", []string{models.PurchaseOrderOrdered, models.PurchaseOrderPartiallyReceived})
+	if err := database.DB.Model(&models.PurchaseOrderLine{}).
+		Select("product_id, SUM(GREATEST(quantity - received_quantity, 0)) AS quantity").
+		Where("purchase_order_id IN (?)", open).Group("product_id").Scan(&incoming).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	onOrder := map[uint]int{}
+	for _, line := range incoming {
+		onOrder[line.ProductID] = line.Quantity
+	}
+
+	var supplierProducts []models.SupplierProduct
+	active := database.DB.Model(&models.Supplier{}).Select("id").Where("active = ?", true)
+	if err := database.DB.Preload("Supplier").Where("supplier_id IN (?)", active).
+		Order("preferred DESC, cost ASC").Find(&supplierProducts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	sources := map[uint]models.SupplierProduct{}
+	for _, supplierProduct := range supplierProducts {
+		if _, ok := sources[supplierProduct.ProductID]; !ok {
+			sources[supplierProduct.ProductID] = supplierProduct
+		}
+	}
+
+	var products []models.Product
+	if err := database.DB.Select("id", "sku", "name", "stock", "low_stock_threshold").
+		Where("status <> ?", models.ProductStatusArchived).Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	defaultThreshold := defaultLowStockThreshold()
+	supplierFilter := c.Query("supplier_id")
+	suggestions := []reorderSuggestion{}
+	for _, product := range products {
+		suggestion := reorderSuggestion{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Stock:     product.Stock,
+			OnOrder:   onOrder[product.ID],
+			Threshold: product.LowStockLevel(defaultThreshold),
+			SoldUnits: sold[product.ID],
+		}
+		velocity := float64(suggestion.SoldUnits) / float64(days)
+		suggestion.DailyVelocity = math.Round(velocity*100) / 100
+		available := suggestion.Stock + suggestion.OnOrder
+		if velocity > 0 {
+			cover := math.Round(float64(available)/velocity*10) / 10
+			suggestion.DaysOfCover = &cover
+		}
+
+		minimum := 1
+		if source, ok := sources[product.ID]; ok {
+			suggestion.SupplierID = &source.SupplierID
+			suggestion.SupplierName = source.Supplier.Name
+			suggestion.SupplierSKU = source.SupplierSKU
+			suggestion.LeadTimeDays = source.LeadTime(*source.Supplier)
+			suggestion.UnitCost = source.Cost
+			if source.MinOrderQuantity > 1 {
+				minimum = source.MinOrderQuantity
+			}
+		}
+		if supplierFilter != "" && (suggestion.SupplierID == nil || strconv.FormatUint(uint64(*suggestion.SupplierID), 10) != supplierFilter) {
+			continue
+		}
+
+		reorderPoint := int(math.Ceil(velocity*float64(suggestion.LeadTimeDays))) + suggestion.Threshold
+		if available > reorderPoint {
+			continue
+		}
+		target := int(math.Ceil(velocity*float64(suggestion.LeadTimeDays+coverDays))) + suggestion.Threshold + 1
+		quantity := target - available
+		if quantity <= 0 {
+			continue
+		}
+		suggestion.SuggestedQuantity = (quantity + minimum - 1) / minimum * minimum
+		suggestion.EstimatedCost = roundMoney(suggestion.UnitCost * float64(suggestion.SuggestedQuantity))
+		suggestions = append(suggestions, suggestion)
+	}
+
+	// Most urgent first: least cover, then products that are not selling
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i].DaysOfCover, suggestions[j].DaysOfCover
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && *a != *b {
+			return *a < *b
+		}
+		return suggestions[i].Name < suggestions[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":        days,
+		"cover_days":  coverDays,
+		"suggestions": suggestions,
+	})
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestRespondPurchaseOrderError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantOK   bool
+		wantCode int
+		wantBody string
+	}{
+		{"success", nil, true, http.StatusOK, ""},
+		{"rejected change", purchaseOrderError("Only draft purchase orders can be edited"), false, http.StatusBadRequest, "Only draft purchase orders can be edited"},
+		{"not found", gorm.ErrRecordNotFound, false, http.StatusNotFound, "Purchase order not found"},
+		{"database error", errors.New("connection reset"), false, http.StatusInternalServerError, "Failed to update purchase order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			if ok := respondPurchaseOrderError(c, tt.err, "Failed to update purchase order"); ok != tt.wantOK {
+				t.Fatalf("respondPurchaseOrderError() = %v, want %v", ok, tt.wantOK)
+			}
+			if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %s, want %d with %q", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestGetReorderSuggestionsRejectsInvalidWindows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"?days=0", "days must be between 1 and 365"},
+		{"?days=366", "days must be between 1 and 365"},
+		{"?days=week", "days must be between 1 and 365"},
+		{"?cover_days=-1", "cover_days must be between 0 and 365"},
+		{"?cover_days=400", "cover_days must be between 0 and 365"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/admin/inventory/reorder-suggestions"+tt.query, nil)
+
+		GetReorderSuggestions(c)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("GetReorderSuggestions(%q) = %d %s, want 400 with %q", tt.query, w.Code, w.Body.String(), tt.want)
+		}
+	}
+}
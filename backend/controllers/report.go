@@ -0,0 +1,568 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Report intervals for revenue over time. Weeks start on Monday.
+const (
+	ReportIntervalDay   = "day"
+	ReportIntervalWeek  = "week"
+	ReportIntervalMonth = "month"
+)
+
+// reportRange is the period a report covers: [From, To) in Location. It
+// defaults to the last 30 days including today.
+type reportRange struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+func (r reportRange) response() gin.H {
+	return gin.H{
+		"from": r.From.Format("2006-01-02"),
+		"to":   r.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		"tz":   r.Location.String(),
+	}
+}
+
+// reportRangeParams reads from, to (YYYY-MM-DD, inclusive) and tz.
+func reportRangeParams(c *gin.Context) (reportRange, error) {
+	loc, err := timezoneParam(c)
+	if err != nil {
+		return reportRange{}, err
+	}
+
+	now := time.Now().In(loc)
+	r := reportRange{Location: loc, To: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)}
+	if value := c.Query("to"); value != "" {
+		to, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return r, fmt.Errorf("Invalid to date")
+		}
+		r.To = to.AddDate(0, 0, 1)
+	}
+	r.From = r.To.AddDate(0, 0, -30)
+	if value := c.Query("from"); value != "" {
+		from, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return r, fmt.Errorf("Invalid from date")
+		}
+		r.From = from
+	}
+	if !r.From.Before(r.To) {
+		return r, fmt.Errorf("The from date must not be after the to date")
+	}
+	return r, nil
+}
+
+// salesOrders scopes orders to those placed in the range that count as
+// sales, i.e. were not cancelled.
+func (r reportRange) salesOrders(db *gorm.DB) *gorm.DB {
+	return db.Where("orders.created_at >= ? AND orders.created_at < ? AND orders.status <> ? AND orders.deleted_at IS NULL",
+		r.From, r.To, models.OrderStatusCancelled)
+}
+
+type revenueBucket struct {
+	Period  string  `json:"period"`
+	Orders  int     `json:"orders"`
+	Gross   float64 `json:"gross"`
+	Refunds float64 `json:"refunds"`
+	Net     float64 `json:"net"`
+}
+
+// GetRevenueReport returns revenue per day, week or month in the requested
+// time zone. Every bucket in the range is present, empty ones included.
+// Net revenue is the order totals less refunds.
+func GetRevenueReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	interval := c.DefaultQuery("interval", ReportIntervalDay)
+	if interval != ReportIntervalDay && interval != ReportIntervalWeek && interval != ReportIntervalMonth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Interval must be day, week or month"})
+		return
+	}
+
+	var buckets []revenueBucket
+	index := map[string]int{}
+	for start := bucketStart(r.From, interval); start.Before(r.To); start = nextBucket(start, interval) {
+		key := start.Format("2006-01-02")
+		index[key] = len(buckets)
+		buckets = append(buckets, revenueBucket{Period: key})
+	}
+
+	// Bucket in Go so the time zone does not depend on the database's
+	// time zone tables
+	rows, err := r.salesOrders(database.DB.Model(&models.Order{})).
+		Select("orders.created_at, orders.total_amount, orders.refunded_amount").Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	defer rows.Close()
+
+	var totals revenueBucket
+	for rows.Next() {
+		var createdAt time.Time
+		var total, refunded float64
+		if err := rows.Scan(&createdAt, &total, &refunded); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+			return
+		}
+
+		// Orders fall in the range, so only a clock or time zone oddity can
+		// leave one without a bucket; leave it out rather than misfile it
+		i, ok := index[bucketStart(createdAt.In(r.Location), interval).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		for _, b := range []*revenueBucket{&buckets[i], &totals} {
+			b.Orders++
+			b.Gross += total
+			b.Refunds += refunded
+		}
+	}
+	for _, b := range append([]*revenueBucket{&totals}, bucketPointers(buckets)...) {
+		b.Gross = roundMoney(b.Gross)
+		b.Refunds = roundMoney(b.Refunds)
+		b.Net = roundMoney(b.Gross - b.Refunds)
+	}
+
+	response := r.response()
+	response["interval"] = interval
+	response["buckets"] = buckets
+	response["totals"] = totals
+	c.JSON(http.StatusOK, response)
+}
+
+func bucketPointers(buckets []revenueBucket) []*revenueBucket {
+	pointers := make([]*revenueBucket, len(buckets))
+	for i := range buckets {
+		pointers[i] = &buckets[i]
+	}
+	return pointers
+}
+
+func bucketStart(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case ReportIntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case ReportIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+func nextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case ReportIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case ReportIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// GetOrdersByStatusReport counts the orders placed in the range by status,
+// cancelled ones included.
+func GetOrdersByStatusReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var statuses []struct {
+		Status string  `json:"status"`
+		Orders int64   `json:"orders"`
+		Total  float64 `json:"total"`
+	}
+	err = database.DB.Model(&models.Order{}).
+		Select("status, COUNT(*) AS orders, COALESCE(SUM(total_amount), 0) AS total").
+		Where("created_at >= ? AND created_at < ?", r.From, r.To).
+		Group("status").Order("orders DESC").Scan(&statuses).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	response := r.response()
+	response["statuses"] = statuses
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAverageOrderValueReport returns the number of sales, their net revenue
+// and the average order value.
+func GetAverageOrderValueReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var summary struct {
+		Orders  int64
+		Revenue float64
+		Items   int64
+	}
+	err = r.salesOrders(database.DB.Model(&models.Order{})).
+		Select("COUNT(*) AS orders, COALESCE(SUM(orders.total_amount - orders.refunded_amount), 0) AS revenue").
+		Scan(&summary).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	r.salesOrders(database.DB.Table("order_items").Joins("JOIN orders ON orders.id = order_items.order_id")).
+		Where("order_items.deleted_at IS NULL").
+		Select("COALESCE(SUM(order_items.quantity - order_items.cancelled_quantity - order_items.returned_quantity), 0)").
+		Scan(&summary.Items)
+
+	var average, itemsPerOrder float64
+	if summary.Orders > 0 {
+		average = roundMoney(summary.Revenue / float64(summary.Orders))
+		itemsPerOrder = float64(summary.Items) / float64(summary.Orders)
+	}
+
+	response := r.response()
+	response["orders"] = summary.Orders
+	response["revenue"] = roundMoney(summary.Revenue)
+	response["average_order_value"] = average
+	response["items_per_order"] = itemsPerOrder
+	c.JSON(http.StatusOK, response)
+}
+
+// marginPercent is gross margin as a percentage of revenue.
+func marginPercent(margin, revenue float64) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(margin/revenue*10000) / 100
+}
+
+// productSales is what a product sold. GrossMargin is revenue less the cost
+// of the units sold, at the cost each line was sold at. UncostedQuantity
+// counts units sold before the product had a cost; they count as costing
+// nothing, overstating the margin.
+type productSales struct {
+	ProductID        uint    `json:"product_id"`
+	Name             string  `json:"name"`
+	SKU              string  `json:"sku"`
+	Quantity         int64   `json:"quantity"`
+	Revenue          float64 `json:"revenue"`
+	Cost             float64 `json:"cost"`
+	GrossMargin      float64 `json:"gross_margin"`
+	MarginPercent    float64 `json:"margin_percent"`
+	UncostedQuantity int64   `json:"uncosted_quantity"`
+}
+
+type categorySales struct {
+	CategoryID       uint    `json:"category_id"`
+	Name             string  `json:"name"`
+	Quantity         int64   `json:"quantity"`
+	Revenue          float64 `json:"revenue"`
+	Cost             float64 `json:"cost"`
+	GrossMargin      float64 `json:"gross_margin"`
+	MarginPercent    float64 `json:"margin_percent"`
+	UncostedQuantity int64   `json:"uncosted_quantity"`
+}
+
+// soldQuantity is the units of a line that were neither cancelled nor
+// returned.
+const soldQuantity = "(order_items.quantity - order_items.cancelled_quantity - order_items.returned_quantity)"
+
+// Revenue and cost of the sold units of a line, and the units of it sold
+// without a known cost.
+const (
+	soldRevenue      = "(" + soldQuantity + " * order_items.price)"
+	soldCost         = "(" + soldQuantity + " * COALESCE(order_items.unit_cost, 0))"
+	uncostedQuantity = "(CASE WHEN order_items.unit_cost IS NULL THEN " + soldQuantity + " ELSE 0 END)"
+)
+
+// salesMarginColumns selects the aggregate revenue, cost and gross margin
+// of sales lines.
+const salesMarginColumns = "COALESCE(SUM(" + soldRevenue + "), 0) AS revenue, " +
+	"COALESCE(SUM(" + soldCost + "), 0) AS cost, " +
+	"COALESCE(SUM(" + soldRevenue + " - " + soldCost + "), 0) AS gross_margin, " +
+	"COALESCE(SUM(" + uncostedQuantity + "), 0) AS uncosted_quantity"
+
+// salesLines selects the lines of sales in the range with their products,
+// deleted products included.
+func (r reportRange) salesLines() *gorm.DB {
+	return r.salesOrders(database.DB.Table("order_items").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("LEFT JOIN products ON products.id = order_items.product_id")).
+		Where("order_items.deleted_at IS NULL")
+}
+
+// GetTopProductsReport ranks products by revenue, by units with
+// sort=quantity or by gross margin with sort=margin.
+func GetTopProductsReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var products []productSales
+	err = r.salesLines().
+		Select("order_items.product_id, products.name, products.sku, " +
+			"SUM(" + soldQuantity + ") AS quantity, " + salesMarginColumns).
+		Group("order_items.product_id, products.name, products.sku").
+		Order(reportSort(c)).Limit(reportLimit(c)).Scan(&products).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	for i := range products {
+		products[i].Revenue = roundMoney(products[i].Revenue)
+		products[i].Cost = roundMoney(products[i].Cost)
+		products[i].GrossMargin = roundMoney(products[i].GrossMargin)
+		products[i].MarginPercent = marginPercent(products[i].GrossMargin, products[i].Revenue)
+	}
+
+	response := r.response()
+	response["products"] = products
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTopCategoriesReport ranks the categories products are sold from by
+// revenue, by units with sort=quantity or by gross margin with sort=margin.
+func GetTopCategoriesReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var categories []categorySales
+	err = r.salesLines().
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Select("categories.id AS category_id, categories.name, " +
+			"SUM(" + soldQuantity + ") AS quantity, " + salesMarginColumns).
+		Group("categories.id, categories.name").
+		Order(reportSort(c)).Limit(reportLimit(c)).Scan(&categories).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	for i := range categories {
+		categories[i].Revenue = roundMoney(categories[i].Revenue)
+		categories[i].Cost = roundMoney(categories[i].Cost)
+		categories[i].GrossMargin = roundMoney(categories[i].GrossMargin)
+		categories[i].MarginPercent = marginPercent(categories[i].GrossMargin, categories[i].Revenue)
+	}
+
+	response := r.response()
+	response["categories"] = categories
+	c.JSON(http.StatusOK, response)
+}
+
+func reportSort(c *gin.Context) string {
+	switch c.Query("sort") {
+	case "quantity":
+		return "quantity DESC"
+	case "margin":
+		return "gross_margin DESC"
+	}
+	return "revenue DESC"
+}
+
+type orderMargin struct {
+	OrderID          uint      `json:"order_id"`
+	OrderNumber      string    `json:"order_number"`
+	CreatedAt        time.Time `json:"created_at"`
+	Discount         float64   `json:"discount"`
+	Revenue          float64   `json:"revenue"`
+	Cost             float64   `json:"cost"`
+	GrossMargin      float64   `json:"gross_margin"`
+	MarginPercent    float64   `json:"margin_percent"`
+	UncostedQuantity int64     `json:"uncosted_quantity"`
+}
+
+// GetOrderMarginsReport lists the gross margin of each sale, highest first,
+// or lowest first with sort=lowest, with the margin over all of them. An
+// order's revenue is what its sold units fetched less its discount; shipping
+// and tax are left out.
+func GetOrderMarginsReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	direction := "DESC"
+	if c.Query("sort") == "lowest" {
+		direction = "ASC"
+	}
+
+	var orders []orderMargin
+	err = r.salesLines().
+		Select("orders.id AS order_id, orders.order_number, orders.created_at, " +
+			"orders.discount_amount AS discount, " +
+			"SUM(" + soldRevenue + ") - orders.discount_amount AS revenue, " +
+			"SUM(" + soldCost + ") AS cost, " +
+			"SUM(" + soldRevenue + " - " + soldCost + ") - orders.discount_amount AS gross_margin, " +
+			"SUM(" + uncostedQuantity + ") AS uncosted_quantity").
+		Group("orders.id, orders.order_number, orders.created_at, orders.discount_amount").
+		Order("gross_margin " + direction + ", orders.id").Limit(reportLimit(c)).Scan(&orders).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	for i := range orders {
+		orders[i].Revenue = roundMoney(orders[i].Revenue)
+		orders[i].Cost = roundMoney(orders[i].Cost)
+		orders[i].GrossMargin = roundMoney(orders[i].GrossMargin)
+		orders[i].MarginPercent = marginPercent(orders[i].GrossMargin, orders[i].Revenue)
+	}
+
+	var summary struct {
+		Revenue          float64
+		Cost             float64
+		GrossMargin      float64
+		UncostedQuantity int64
+	}
+	if err := r.salesLines().Select(salesMarginColumns).Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	var discount float64
+	r.salesOrders(database.DB.Model(&models.Order{})).
+		Select("COALESCE(SUM(orders.discount_amount), 0)").Scan(&discount)
+	summary.Revenue = roundMoney(summary.Revenue - discount)
+	summary.GrossMargin = roundMoney(summary.GrossMargin - discount)
+
+	response := r.response()
+	response["orders"] = orders
+	response["revenue"] = summary.Revenue
+	response["discount"] = roundMoney(discount)
+	response["cost"] = roundMoney(summary.Cost)
+	response["gross_margin"] = summary.GrossMargin
+	response["margin_percent"] = marginPercent(summary.GrossMargin, summary.Revenue)
+	response["uncosted_quantity"] = summary.UncostedQuantity
+	c.JSON(http.StatusOK, response)
+}
+
+func reportLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		return 10
+	}
+	return limit
+}
+
+// customerKey identifies the customer of an order: the user, or the guest's
+// email address.
+const customerKey = "COALESCE(CONCAT('u', orders.user_id), CONCAT('g', LOWER(orders.guest_email)))"
+
+// GetCustomersReport splits the customers who bought in the range into new
+// ones, whose first order falls in the range, and returning ones.
+func GetCustomersReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var customers []struct {
+		FirstOrderAt time.Time
+		Orders       int64
+		Revenue      float64
+	}
+	err = database.DB.Model(&models.Order{}).
+		Select("MIN(orders.created_at) AS first_order_at, "+
+			"SUM(CASE WHEN orders.created_at >= ? THEN 1 ELSE 0 END) AS orders, "+
+			"SUM(CASE WHEN orders.created_at >= ? THEN orders.total_amount - orders.refunded_amount ELSE 0 END) AS revenue", r.From, r.From).
+		Where("orders.created_at < ? AND orders.status <> ?", r.To, models.OrderStatusCancelled).
+		Group(customerKey).
+		Having("SUM(CASE WHEN orders.created_at >= ? THEN 1 ELSE 0 END) > 0", r.From).
+		Scan(&customers).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	type segment struct {
+		Customers int     `json:"customers"`
+		Orders    int64   `json:"orders"`
+		Revenue   float64 `json:"revenue"`
+	}
+	var newCustomers, returning segment
+	for _, customer := range customers {
+		s := &returning
+		if !customer.FirstOrderAt.Before(r.From) {
+			s = &newCustomers
+		}
+		s.Customers++
+		s.Orders += customer.Orders
+		s.Revenue += customer.Revenue
+	}
+	newCustomers.Revenue = roundMoney(newCustomers.Revenue)
+	returning.Revenue = roundMoney(returning.Revenue)
+
+	var returningRate float64
+	if len(customers) > 0 {
+		returningRate = float64(returning.Customers) / float64(len(customers))
+	}
+
+	response := r.response()
+	response["new"] = newCustomers
+	response["returning"] = returning
+	response["returning_rate"] = returningRate
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCartConversionReport compares carts with the orders placed from them.
+// Carts are not kept once ordered, so the carts of the period are the orders
+// placed in it plus the carts with activity in it that are still open.
+// Abandoned carts detected in the period and those recovered are reported
+// alongside.
+func GetCartConversionReport(c *gin.Context) {
+	r, err := reportRangeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ordered, open, abandoned, recovered int64
+	r.salesOrders(database.DB.Model(&models.Order{})).Count(&ordered)
+	database.DB.Model(&models.Cart{}).
+		Select("COUNT(DISTINCT COALESCE(CONCAT('u', user_id), CONCAT('g', guest_cart_id)))").
+		Where("saved_for_later = ? AND updated_at >= ? AND updated_at < ?", false, r.From, r.To).
+		Scan(&open)
+	database.DB.Model(&models.AbandonedCart{}).
+		Where("detected_at >= ? AND detected_at < ?", r.From, r.To).Count(&abandoned)
+	database.DB.Model(&models.AbandonedCart{}).
+		Where("detected_at >= ? AND detected_at < ? AND recovered_at IS NOT NULL", r.From, r.To).Count(&recovered)
+
+	var conversionRate, recoveryRate float64
+	if carts := ordered + open; carts > 0 {
+		conversionRate = float64(ordered) / float64(carts)
+	}
+	if abandoned > 0 {
+		recoveryRate = float64(recovered) / float64(abandoned)
+	}
+
+	response := r.response()
+	response["carts"] = ordered + open
+	response["ordered"] = ordered
+	response["open"] = open
+	response["conversion_rate"] = conversionRate
+	response["abandoned"] = abandoned
+	response["recovered"] = recovered
+	response["recovery_rate"] = recoveryRate
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+	"github.com/gin-gonic/gin"
+)
+
+func queryContext(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/admin/reports"+query, nil)
+	return c
+}
+
+func TestBucketStart(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		interval string
+		want     time.Time
+	}{
+		{"day", time.Date(2024, 3, 6, 15, 4, 5, 0, time.UTC), ReportIntervalDay, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"unknown interval is a day", time.Date(2024, 3, 6, 15, 4, 5, 0, time.UTC), "hour", time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"week from Wednesday", time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC), ReportIntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"week from Monday", time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), ReportIntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"week from Sunday", time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), ReportIntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"week across months", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ReportIntervalWeek, time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2024, 3, 31, 23, 59, 0, 0, time.UTC), ReportIntervalMonth, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"day in zone", time.Date(2024, 3, 6, 1, 0, 0, 0, tokyo), ReportIntervalDay, time.Date(2024, 3, 6, 0, 0, 0, 0, tokyo)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucketStart(tt.t, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("bucketStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBucket(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Time
+		interval string
+		want     time.Time
+	}{
+		{"day", time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), ReportIntervalDay, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"week", time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), ReportIntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ReportIntervalMonth, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"year end", time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), ReportIntervalMonth, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextBucket(tt.start, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("nextBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportRangeParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom string
+		wantTo   string
+		wantErr  string
+	}{
+		{"explicit range", "?from=2024-03-01&to=2024-03-31", "2024-03-01", "2024-03-31", ""},
+		{"single day", "?from=2024-03-01&to=2024-03-01", "2024-03-01", "2024-03-01", ""},
+		{"last 30 days before to", "?to=2024-03-31", "2024-03-02", "2024-03-31", ""},
+		{"invalid from", "?from=March", "", "", "Invalid from date"},
+		{"invalid to", "?to=2024-13-01", "", "", "Invalid to date"},
+		{"from after to", "?from=2024-04-01&to=2024-03-31", "", "", "The from date must not be after the to date"},
+		{"invalid zone", "?tz=Nowhere/Else", "", "", "Invalid time zone: Nowhere/Else"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := reportRangeParams(queryContext(tt.query))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("reportRangeParams() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reportRangeParams() error = %v", err)
+			}
+			response := r.response()
+			if response["from"] != tt.wantFrom || response["to"] != tt.wantTo {
+				t.Errorf("reportRangeParams() = %v to %v, want %v to %v", response["from"], response["to"], tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestReportRangeParamsDefault(t *testing.T) {
+	r, err := reportRangeParams(queryContext(""))
+	if err != nil {
+		t.Fatalf("reportRangeParams() error = %v", err)
+	}
+	if r.Location != time.UTC {
+		t.Errorf("Location = %v, want UTC", r.Location)
+	}
+	if days := r.To.Sub(r.From).Hours() / 24; days != 30 {
+		t.Errorf("range covers %v days, want 30", days)
+	}
+	if now := time.Now(); now.Before(r.To.AddDate(0, 0, -1)) || !now.Before(r.To) {
+		t.Errorf("To = %v, want the end of today", r.To)
+	}
+}
+
+func TestReportLimit(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 10},
+		{"?limit=5", 5},
+		{"?limit=100", 100},
+		{"?limit=101", 10},
+		{"?limit=0", 10},
+		{"?limit=-3", 10},
+		{"?limit=ten", 10},
+	}
+
+	for _, tt := range tests {
+		if got := reportLimit(queryContext(tt.query)); got != tt.want {
+			t.Errorf("reportLimit(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestReportSort(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "revenue DESC"},
+		{"?sort=revenue", "revenue DESC"},
+		{"?sort=quantity", "quantity DESC"},
+		{"?sort=margin", "gross_margin DESC"},
+		{"?sort=name", "revenue DESC"},
+	}
+
+	for _, tt := range tests {
+		if got := reportSort(queryContext(tt.query)); got != tt.want {
+			t.Errorf("reportSort(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMarginPercent(t *testing.T) {
+	tests := []struct {
+		margin, revenue float64
+		want            float64
+	}{
+		{0, 0, 0},
+		{25, 0, 0},
+		{25, 100, 25},
+		{1, 3, 33.33},
+		{2, 3, 66.67},
+		{-10, 40, -25},
+		{100, 100, 100},
+	}
+	for _, tt := range tests {
+		if got := marginPercent(tt.margin, tt.revenue); got != tt.want {
+			t.Errorf("marginPercent(%v, %v) = %v, want %v", tt.margin, tt.revenue, got, tt.want)
+		}
+	}
+}
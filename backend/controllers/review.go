@@ -0,0 +1,504 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxReviewImages = 6
+
+type CreateReviewRequest struct {
+	Rating int      `json:"rating" binding:"required,min=1,max=5"`
+	Title  string   `json:"title" binding:"max=255"`
+	Body   string   `json:"body"`
+	Images []string `json:"images"`
+}
+
+type UpdateReviewRequest struct {
+	Rating int      `json:"rating" binding:"omitempty,min=1,max=5"`
+	Title  string   `json:"title" binding:"max=255"`
+	Body   string   `json:"body"`
+	Images []string `json:"images"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+func GetProductReviews(c *gin.Context) {
+	id := c.Param("id")
+	productID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	// Get query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.Review{}).Preload("User").
+		Where("product_id = ? AND status = ?", productID, models.ReviewStatusApproved)
+
+	// Filter by rating if provided
+	if rating := c.Query("rating"); rating != "" {
+		query = query.Where("rating = ?", rating)
+	}
+
+	// Sort order
+	switch c.DefaultQuery("sort", "newest") {
+	case "helpful":
+		query = query.Order("helpful_count DESC, created_at DESC")
+	case "rating_high":
+		query = query.Order("rating DESC, created_at DESC")
+	case "rating_low":
+		query = query.Order("rating ASC, created_at DESC")
+	default:
+		query = query.Order("created_at DESC")
+	}
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var reviews []models.Review
+	if err := query.Offset(offset).Limit(limit).Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	reviewResponses := make([]models.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		reviewResponses = append(reviewResponses, review.ToResponse())
+	}
+
+	// Rating distribution of approved reviews
+	var buckets []struct {
+		Rating int
+		Count  int
+	}
+	database.DB.Model(&models.Review{}).Select("rating, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, models.ReviewStatusApproved).
+		Group("rating").Scan(&buckets)
+	distribution := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, bucket := range buckets {
+		distribution[bucket.Rating] = bucket.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviewResponses,
+		"summary": gin.H{
+			"average_rating": product.AverageRating(),
+			"review_count":   product.RatingCount,
+			"distribution":   distribution,
+		},
+		"total":       totalCount,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+func CreateReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	productID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Images) > maxReviewImages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many review images"})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	// Only customers who received the product may review it
+	orderItem, ok := findDeliveredOrderItem(userID.(uint), product.ID)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only verified buyers with a delivered order can review this product"})
+		return
+	}
+
+	var existingReview models.Review
+	if err := database.DB.Where("product_id = ? AND user_id = ?", product.ID, userID).First(&existingReview).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this product"})
+		return
+	}
+
+	review := models.Review{
+		ProductID:   product.ID,
+		UserID:      userID.(uint),
+		OrderItemID: orderItem.ID,
+		Rating:      req.Rating,
+		Title:       req.Title,
+		Body:        req.Body,
+		Images:      req.Images,
+		Status:      models.ReviewStatusPending,
+	}
+
+	if err := database.DB.Create(&review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+		return
+	}
+
+	database.DB.Preload("User").First(&review, review.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Review submitted for moderation",
+		"review":  review.ToResponse(),
+	})
+}
+
+func UpdateReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	reviewID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Images) > maxReviewImages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many review images"})
+		return
+	}
+
+	var review models.Review
+	if err := database.DB.Where("id = ? AND user_id = ?", reviewID, userID).First(&review).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	previous := review
+
+	// Update review fields
+	if req.Rating != 0 {
+		review.Rating = req.Rating
+	}
+	if req.Title != "" {
+		review.Title = req.Title
+	}
+	if req.Body != "" {
+		review.Body = req.Body
+	}
+	if req.Images != nil {
+		review.Images = req.Images
+	}
+
+	// Edited reviews go back through moderation
+	review.Status = models.ReviewStatusPending
+	review.ModerationNote = ""
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Product").Save(&review).Error; err != nil {
+			return err
+		}
+		return applyReviewAggregate(tx, previous, review)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+
+	database.DB.Preload("User").First(&review, review.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review updated and resubmitted for moderation",
+		"review":  review.ToResponse(),
+	})
+}
+
+func DeleteReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	reviewID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	// Admins may delete any review, customers only their own
+	query := database.DB.Where("id = ?", reviewID)
+	if role, _ := c.Get("user_role"); role != "admin" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var review models.Review
+	if err := query.First(&review).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		removed := review
+		removed.Status = models.ReviewStatusRejected
+		if err := applyReviewAggregate(tx, review, removed); err != nil {
+			return err
+		}
+		if err := tx.Where("review_id = ?", review.ID).Delete(&models.ReviewVote{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&review).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review deleted successfully",
+	})
+}
+
+func VoteReviewHelpful(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	reviewID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var review models.Review
+	if err := database.DB.Where("id = ? AND status = ?", reviewID, models.ReviewStatusApproved).First(&review).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	if review.UserID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot vote on your own review"})
+		return
+	}
+
+	var existingVote models.ReviewVote
+	if err := database.DB.Where("review_id = ? AND user_id = ?", review.ID, userID).First(&existingVote).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already voted for this review"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		vote := models.ReviewVote{ReviewID: review.ID, UserID: userID.(uint)}
+		if err := tx.Create(&vote).Error; err != nil {
+			return err
+		}
+		return tx.Model(&review).UpdateColumn("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record vote"})
+		return
+	}
+
+	database.DB.First(&review, review.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Vote recorded successfully",
+		"helpful_count": review.HelpfulCount,
+	})
+}
+
+func RemoveReviewVote(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	reviewID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var vote models.ReviewVote
+	if err := database.DB.Where("review_id = ? AND user_id = ?", reviewID, userID).First(&vote).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vote not found"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&vote).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Review{}).Where("id = ? AND helpful_count > 0", reviewID).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count - 1")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove vote"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vote removed successfully",
+	})
+}
+
+func GetAllReviews(c *gin.Context) {
+	// Get query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.Review{}).Preload("User")
+
+	// Filter by status and product if provided
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var reviews []models.Review
+	if err := query.Order("created_at ASC").Offset(offset).Limit(limit).Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	reviewResponses := make([]models.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		reviewResponses = append(reviewResponses, review.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":     reviewResponses,
+		"total":       totalCount,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+func ModerateReview(c *gin.Context) {
+	id := c.Param("id")
+	reviewID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !models.IsValidReviewStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review status"})
+		return
+	}
+
+	var review models.Review
+	if err := database.DB.First(&review, reviewID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	previous := review
+	review.Status = req.Status
+	review.ModerationNote = req.Note
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Product").Save(&review).Error; err != nil {
+			return err
+		}
+		return applyReviewAggregate(tx, previous, review)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to moderate review"})
+		return
+	}
+
+	database.DB.Preload("User").First(&review, review.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review moderated successfully",
+		"review":  review.ToResponse(),
+	})
+}
+
+// findDeliveredOrderItem returns an order line for the product from one of the
+// user's delivered orders. Lines that were cancelled or returned in full do
+// not count as a purchase.
+func findDeliveredOrderItem(userID, productID uint) (models.OrderItem, bool) {
+	var orderItem models.OrderItem
+	err := database.DB.Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ?", userID, "delivered", productID).
+		Where(soldQuantity + " > 0").
+		Order("order_items.id DESC").First(&orderItem).Error
+	return orderItem, err == nil
+}
+
+// applyReviewAggregate moves the product's rating sum and count from the
+// state of the review before a change to its state after it. Only approved
+// reviews contribute to the aggregate.
+func applyReviewAggregate(tx *gorm.DB, before, after models.Review) error {
+	sumDelta, countDelta := 0, 0
+	if before.Status == models.ReviewStatusApproved {
+		sumDelta -= before.Rating
+		countDelta--
+	}
+	if after.Status == models.ReviewStatusApproved {
+		sumDelta += after.Rating
+		countDelta++
+	}
+	if sumDelta == 0 && countDelta == 0 {
+		return nil
+	}
+
+	return tx.Model(&models.Product{}).Where("id = ?", after.ProductID).UpdateColumns(map[string]interface{}{
+		"rating_sum":   gorm.Expr("rating_sum + ?", sumDelta),
+		"rating_count": gorm.Expr("rating_count + ?", countDelta),
+	}).Error
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"ecommerce-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordedStatement is what a statement built against a dry-run database
+// does: the action, the table, the columns it is filtered on with their
+// values, and for inserts and updates the values it writes.
+type recordedStatement struct {
+	Action string
+	Table  string
+	Where  map[string]interface{}
+	Values map[string]interface{}
+}
+
+// simpleCondition matches conditions like "id = ?".
+var simpleCondition = regexp.MustCompile(`^(\w+) = \?$`)
+
+// recordStatements collects every statement db builds.
+func recordStatements(db *gorm.DB) *[]recordedStatement {
+	var statements []recordedStatement
+	record := func(action string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			statement := recordedStatement{Action: action, Table: tx.Statement.Table,
+				Where: map[string]interface{}{}, Values: map[string]interface{}{}}
+			if where, ok := tx.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+				primaryKey := ""
+				if tx.Statement.Schema != nil && tx.Statement.Schema.PrioritizedPrimaryField != nil {
+					primaryKey = tx.Statement.Schema.PrioritizedPrimaryField.DBName
+				}
+				for _, expr := range where.Exprs {
+					recordCondition(statement.Where, expr, primaryKey)
+				}
+			}
+			switch dest := tx.Statement.Dest.(type) {
+			case map[string]interface{}:
+				for column, value := range dest {
+					statement.Values[column] = value
+				}
+			default:
+				if action == "create" && tx.Statement.Schema != nil && tx.Statement.ReflectValue.Kind() == reflect.Struct {
+					for _, field := range tx.Statement.Schema.Fields {
+						if field.DBName == "" {
+							continue
+						}
+						value, _ := field.ValueOf(tx.Statement.Context, tx.Statement.ReflectValue)
+						if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr {
+							if v.IsNil() {
+								value = nil
+							} else {
+								value = v.Elem().Interface()
+							}
+						}
+						statement.Values[field.DBName] = value
+					}
+				}
+			}
+			statements = append(statements, statement)
+		}
+	}
+	db.Callback().Create().After("gorm:create").Register("test:record", record("create"))
+	db.Callback().Query().After("gorm:query").Register("test:record", record("query"))
+	db.Callback().Update().After("gorm:update").Register("test:record", record("update"))
+	db.Callback().Delete().After("gorm:delete").Register("test:record", record("delete"))
+	return &statements
+}
+
+func recordCondition(where map[string]interface{}, expr clause.Expression, primaryKey string) {
+	column := func(c interface{}) string {
+		if col, ok := c.(clause.Column); ok {
+			if col.Name == clause.PrimaryKey {
+				return primaryKey
+			}
+			return col.Name
+		}
+		return fmt.Sprint(c)
+	}
+	switch e := expr.(type) {
+	case clause.Eq:
+		where[column(e.Column)] = e.Value
+	case clause.IN:
+		if len(e.Values) == 1 {
+			where[column(e.Column)] = e.Values[0]
+		} else {
+			where[column(e.Column)] = e.Values
+		}
+	case clause.Expr:
+		vars := e.Vars
+		for _, part := range strings.Split(e.SQL, " AND ") {
+			match := simpleCondition.FindStringSubmatch(part)
+			if match == nil || len(vars) == 0 {
+				return
+			}
+			where[match[1]] = vars[0]
+			vars = vars[1:]
+		}
+	}
+}
+
+func TestApplyReviewAggregate(t *testing.T) {
+	pending := models.Review{ProductID: 7, Rating: 4, Status: models.ReviewStatusPending}
+	approved := models.Review{ProductID: 7, Rating: 4, Status: models.ReviewStatusApproved}
+	approvedFive := models.Review{ProductID: 7, Rating: 5, Status: models.ReviewStatusApproved}
+	rejected := models.Review{ProductID: 7, Rating: 4, Status: models.ReviewStatusRejected}
+
+	tests := []struct {
+		name       string
+		before     models.Review
+		after      models.Review
+		wantUpdate bool
+		wantSum    int
+		wantCount  int
+	}{
+		{"approve", pending, approved, true, 4, 1},
+		{"reject approved", approved, rejected, true, -4, -1},
+		{"change approved rating", approved, approvedFive, true, 1, 0},
+		{"reject pending", pending, rejected, false, 0, 0},
+		{"unchanged", approved, approved, false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			statements := recordStatements(db)
+			if err := applyReviewAggregate(db, tt.before, tt.after); err != nil {
+				t.Fatalf("applyReviewAggregate() error = %v", err)
+			}
+			if !tt.wantUpdate {
+				if len(*statements) != 0 {
+					t.Errorf("statements = %+v, want none", *statements)
+				}
+				return
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("statements = %+v, want one update", *statements)
+			}
+			update := (*statements)[0]
+			if update.Action != "update" || update.Table != "products" || update.Where["id"] != uint(7) {
+				t.Errorf("statement = %+v, want an update of product 7", update)
+			}
+			// The totals are adjusted in place, so concurrent reviews cannot
+			// overwrite each other's changes
+			sum, sumOK := update.Values["rating_sum"].(clause.Expr)
+			count, countOK := update.Values["rating_count"].(clause.Expr)
+			if !sumOK || !countOK || len(sum.Vars) != 1 || len(count.Vars) != 1 {
+				t.Fatalf("values = %+v, want increments of rating_sum and rating_count", update.Values)
+			}
+			if sum.Vars[0] != tt.wantSum || count.Vars[0] != tt.wantCount {
+				t.Errorf("increments = %v and %v, want %d and %d", sum.Vars[0], count.Vars[0], tt.wantSum, tt.wantCount)
+			}
+		})
+	}
+}
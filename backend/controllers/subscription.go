@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateSubscriptionRequest struct {
+	Type        string   `json:"type" binding:"required"`
+	Channel     string   `json:"channel"`
+	TargetPrice *float64 `json:"target_price" binding:"omitempty,gt=0"`
+}
+
+func GetSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := database.DB.Preload("Product.Category").Where("user_id = ?", userID)
+	if c.Query("active") != "false" {
+		query = query.Where("active = ?", true)
+	}
+
+	var subscriptions []models.ProductSubscription
+	if err := query.Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptions": subscriptions,
+	})
+}
+
+func CreateSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !models.IsValidSubscriptionType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription type"})
+		return
+	}
+	if req.Channel == "" {
+		req.Channel = notifications.ChannelInApp
+	}
+	if !notifications.HasChannel(req.Channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Notification channel is not available"})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.Scopes(models.VisibleProducts(time.Now())).First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	if req.Type == models.SubscriptionBackInStock && product.Stock > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product is already in stock"})
+		return
+	}
+	if req.TargetPrice != nil && *req.TargetPrice >= product.Price {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target price must be below the current price"})
+		return
+	}
+
+	subscription, err := subscribeToProduct(database.DB, userID.(uint), product, req.Type, req.Channel, req.TargetPrice)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+
+	database.DB.Preload("Product.Category").First(&subscription, subscription.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Subscribed successfully",
+		"subscription": subscription,
+	})
+}
+
+func DeleteSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id := c.Param("id")
+	subscriptionID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	result := database.DB.Where("id = ? AND user_id = ?", subscriptionID, userID).Delete(&models.ProductSubscription{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Subscription deleted successfully",
+	})
+}
+
+// subscribeToProduct creates the subscription or re-arms an existing one with
+// the product's current price as the new reference.
+func subscribeToProduct(db *gorm.DB, userID uint, product models.Product, subscriptionType, channel string, targetPrice *float64) (models.ProductSubscription, error) {
+	var subscription models.ProductSubscription
+	err := db.Where("user_id = ? AND product_id = ? AND type = ?", userID, product.ID, subscriptionType).First(&subscription).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return subscription, err
+	}
+
+	subscription.UserID = userID
+	subscription.ProductID = product.ID
+	subscription.Type = subscriptionType
+	subscription.Channel = channel
+	subscription.ReferencePrice = product.Price
+	subscription.TargetPrice = targetPrice
+	subscription.Active = true
+	subscription.NotifiedAt = nil
+
+	return subscription, db.Omit("User", "Product").Save(&subscription).Error
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SupplierRequest struct {
+	Name         string `json:"name" binding:"required,max=255"`
+	ContactName  string `json:"contact_name" binding:"max=255"`
+	Email        string `json:"email" binding:"omitempty,email"`
+	Phone        string `json:"phone" binding:"max=50"`
+	Address      string `json:"address"`
+	LeadTimeDays int    `json:"lead_time_days" binding:"gte=0"`
+	Notes        string `json:"notes"`
+	Active       *bool  `json:"active"`
+}
+
+type SupplierProductRequest struct {
+	SupplierSKU      string  `json:"supplier_sku" binding:"max=100"`
+	Cost             float64 `json:"cost" binding:"required,gt=0"`
+	MinOrderQuantity int     `json:"min_order_quantity" binding:"gte=0"`
+	LeadTimeDays     *int    `json:"lead_time_days" binding:"omitempty,gte=0"`
+	Preferred        bool    `json:"preferred"`
+}
+
+func GetSuppliers(c *gin.Context) {
+	query := database.DB.Order("name ASC")
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	var suppliers []models.Supplier
+	if err := query.Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suppliers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suppliers": suppliers,
+	})
+}
+
+func CreateSupplier(c *gin.Context) {
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier := models.Supplier{Active: true}
+	applySupplierRequest(&supplier, req)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&supplier).Error; err != nil {
+			return err
+		}
+		// Create skips zero values in favour of the column defaults
+		return tx.Model(&supplier).Updates(map[string]interface{}{
+			"lead_time_days": supplier.LeadTimeDays,
+			"active":         supplier.Active,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supplier"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Supplier created successfully",
+		"supplier": supplier,
+	})
+}
+
+func UpdateSupplier(c *gin.Context) {
+	id := c.Param("id")
+	supplierID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+		return
+	}
+
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var supplier models.Supplier
+	if err := database.DB.First(&supplier, supplierID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+
+	applySupplierRequest(&supplier, req)
+	if err := database.DB.Save(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update supplier"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Supplier updated successfully",
+		"supplier": supplier,
+	})
+}
+
+// DeleteSupplier removes a supplier with no open purchase orders. Its past
+// purchase orders keep referring to it.
+func DeleteSupplier(c *gin.Context) {
+	id := c.Param("id")
+	supplierID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+		return
+	}
+
+	var open int64
+	database.DB.Model(&models.PurchaseOrder{}).Where("supplier_id = ? AND status IN ?", supplierID,
+		[]string{models.PurchaseOrderDraft, models.PurchaseOrderOrdered, models.PurchaseOrderPartiallyReceived}).Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier has open purchase orders"})
+		return
+	}
+
+	var result *gorm.DB
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result = tx.Delete(&models.Supplier{}, supplierID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("supplier_id = ?", supplierID).Delete(&models.SupplierProduct{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete supplier"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Supplier deleted successfully",
+	})
+}
+
+func applySupplierRequest(supplier *models.Supplier, req SupplierRequest) {
+	supplier.Name = strings.TrimSpace(req.Name)
+	supplier.ContactName = req.ContactName
+	supplier.Email = req.Email
+	supplier.Phone = req.Phone
+	supplier.Address = req.Address
+	supplier.LeadTimeDays = req.LeadTimeDays
+	supplier.Notes = req.Notes
+	if req.Active != nil {
+		supplier.Active = *req.Active
+	}
+}
+
+// GetSupplierProducts lists the products a supplier sells with its SKUs and
+// costs.
+func GetSupplierProducts(c *gin.Context) {
+	var supplier models.Supplier
+	if err := database.DB.First(&supplier, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+
+	var products []models.SupplierProduct
+	if err := database.DB.Preload("Product").Where("supplier_id = ?", supplier.ID).
+		Order("product_id ASC").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supplier products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"supplier": supplier,
+		"products": products,
+	})
+}
+
+// GetProductSuppliers lists the suppliers of a product, preferred first, then
+// cheapest.
+func GetProductSuppliers(c *gin.Context) {
+	var suppliers []models.SupplierProduct
+	if err := database.DB.Preload("Supplier").Where("product_id = ?", c.Param("id")).
+		Order("preferred DESC, cost ASC").Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product suppliers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suppliers": suppliers,
+	})
+}
+
+// SetSupplierProduct adds a product to a supplier's range or updates its SKU
+// and cost there. Marking it preferred unmarks the product's other
+// suppliers.
+func SetSupplierProduct(c *gin.Context) {
+	var req SupplierProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MinOrderQuantity == 0 {
+		req.MinOrderQuantity = 1
+	}
+
+	var supplier models.Supplier
+	if err := database.DB.First(&supplier, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+	var product models.Product
+	if err := database.DB.First(&product, c.Param("productId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	var supplierProduct models.SupplierProduct
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("supplier_id = ? AND product_id = ?", supplier.ID, product.ID).First(&supplierProduct).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if req.Preferred {
+			if err := tx.Model(&models.SupplierProduct{}).Where("product_id = ? AND supplier_id != ?", product.ID, supplier.ID).
+				Update("preferred", false).Error; err != nil {
+				return err
+			}
+		}
+
+		supplierProduct.SupplierID = supplier.ID
+		supplierProduct.ProductID = product.ID
+		supplierProduct.SupplierSKU = strings.TrimSpace(req.SupplierSKU)
+		supplierProduct.Cost = roundMoney(req.Cost)
+		supplierProduct.MinOrderQuantity = req.MinOrderQuantity
+		supplierProduct.LeadTimeDays = req.LeadTimeDays
+		supplierProduct.Preferred = req.Preferred
+		return tx.Omit("Supplier", "Product").Save(&supplierProduct).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save supplier product"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Supplier product saved successfully",
+		"supplier_product": supplierProduct,
+	})
+}
+
+func DeleteSupplierProduct(c *gin.Context) {
+	result := database.DB.Where("supplier_id = ? AND product_id = ?", c.Param("id"), c.Param("productId")).
+		Delete(&models.SupplierProduct{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete supplier product"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Supplier product deleted successfully",
+	})
+}
@@ -0,0 +1,618 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WarehouseRequest struct {
+	Code         string `json:"code" binding:"required,max=50"`
+	Name         string `json:"name" binding:"required,max=255"`
+	Address      string `json:"address"`
+	ServiceAreas string `json:"service_areas" binding:"max=500"`
+	Priority     int    `json:"priority"`
+	Active       *bool  `json:"active"`
+	IsDefault    bool   `json:"is_default"`
+}
+
+type StockTransferRequest struct {
+	ProductID       uint   `json:"product_id" binding:"required"`
+	FromWarehouseID uint   `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint   `json:"to_warehouse_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required,gt=0"`
+	Note            string `json:"note" binding:"max=500"`
+}
+
+type warehouseSummary struct {
+	models.Warehouse
+	Units    int `json:"units"`
+	Products int `json:"products"`
+}
+
+// GetWarehouses lists warehouses in priority order with the units and
+// products in stock in each, and the allocation rule in use.
+func GetWarehouses(c *gin.Context) {
+	var warehouses []models.Warehouse
+	if err := database.DB.Order("priority ASC, id ASC").Find(&warehouses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch warehouses"})
+		return
+	}
+
+	var totals []struct {
+		WarehouseID uint
+		Units       int
+		Products    int
+	}
+	database.DB.Model(&models.WarehouseStock{}).
+		Select("warehouse_id, SUM(quantity) AS units, COUNT(*) AS products").
+		Where("quantity > 0").Group("warehouse_id").Scan(&totals)
+
+	summaries := make([]warehouseSummary, len(warehouses))
+	for i, warehouse := range warehouses {
+		summaries[i].Warehouse = warehouse
+		for _, total := range totals {
+			if total.WarehouseID == warehouse.ID {
+				summaries[i].Units = total.Units
+				summaries[i].Products = total.Products
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"warehouses":      summaries,
+		"allocation_rule": allocationRule(),
+	})
+}
+
+func CreateWarehouse(c *gin.Context) {
+	var req WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse := models.Warehouse{Active: true}
+	if !applyWarehouseRequest(c, &warehouse, req) {
+		return
+	}
+
+	if _, err := saveWarehouse(&warehouse); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Warehouse created successfully",
+		"warehouse": warehouse,
+	})
+}
+
+func UpdateWarehouse(c *gin.Context) {
+	id := c.Param("id")
+	warehouseID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	var req WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var warehouse models.Warehouse
+	if err := database.DB.First(&warehouse, warehouseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		return
+	}
+
+	// Another warehouse has to be made the default instead
+	if warehouse.IsDefault && !req.IsDefault {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Make another warehouse the default first"})
+		return
+	}
+	if !applyWarehouseRequest(c, &warehouse, req) {
+		return
+	}
+
+	events, err := saveWarehouse(&warehouse)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update warehouse"})
+		return
+	}
+	notifications.Publish(events...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Warehouse updated successfully",
+		"warehouse": warehouse,
+	})
+}
+
+func applyWarehouseRequest(c *gin.Context, warehouse *models.Warehouse, req WarehouseRequest) bool {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	var count int64
+	database.DB.Model(&models.Warehouse{}).Where("code = ? AND id != ?", code, warehouse.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Warehouse code already exists"})
+		return false
+	}
+
+	warehouse.Code = code
+	warehouse.Name = strings.TrimSpace(req.Name)
+	warehouse.Address = req.Address
+	warehouse.ServiceAreas = req.ServiceAreas
+	warehouse.Priority = req.Priority
+	if req.Active != nil {
+		warehouse.Active = *req.Active
+	}
+	warehouse.IsDefault = req.IsDefault
+
+	// Stock without a warehouse lands in the default one, so it must stay
+	// usable
+	if warehouse.IsDefault && !warehouse.Active {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The default warehouse cannot be deactivated"})
+		return false
+	}
+	return true
+}
+
+// saveWarehouse saves the warehouse, taking the default flag from any other
+// warehouse when it becomes the default. Activating or deactivating it
+// updates the stock of the products it holds, returning the events that
+// triggers.
+func saveWarehouse(warehouse *models.Warehouse) ([]notifications.Event, error) {
+	var events []notifications.Event
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if warehouse.IsDefault {
+			if err := tx.Model(&models.Warehouse{}).Where("is_default = ? AND id != ?", true, warehouse.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		if warehouse.ID != 0 {
+			var current models.Warehouse
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, warehouse.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Save(warehouse).Error; err != nil {
+				return err
+			}
+			if current.Active == warehouse.Active {
+				return nil
+			}
+			var err error
+			events, err = refreshWarehouseProducts(tx, warehouse.ID)
+			return err
+		}
+		if err := tx.Create(warehouse).Error; err != nil {
+			return err
+		}
+		// Create skips false in favour of the column default
+		if !warehouse.Active {
+			return tx.Model(warehouse).Update("active", false).Error
+		}
+		return nil
+	})
+	return events, err
+}
+
+// refreshWarehouseProducts brings the stock of the products a warehouse holds
+// in line with the active warehouses after it is activated or deactivated.
+func refreshWarehouseProducts(tx *gorm.DB, warehouseID uint) ([]notifications.Event, error) {
+	var productIDs []uint
+	if err := tx.Model(&models.WarehouseStock{}).Where("warehouse_id = ? AND quantity <> 0", warehouseID).
+		Order("product_id").Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var events []notifications.Event
+	for _, productID := range productIDs {
+		var product models.Product
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return nil, err
+		}
+		productEvents, err := updateSellableStock(tx, product, product.Stock)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, productEvents...)
+	}
+	return events, nil
+}
+
+// GetWarehouseStock lists the stock levels held in a warehouse, with the
+// product for each.
+func GetWarehouseStock(c *gin.Context) {
+	id := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := (page - 1) * limit
+
+	var warehouse models.Warehouse
+	if err := database.DB.First(&warehouse, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		return
+	}
+
+	query := database.DB.Model(&models.WarehouseStock{}).Where("warehouse_id = ?", warehouse.ID)
+	if c.Query("include_empty") != "true" {
+		query = query.Where("quantity <> 0")
+	}
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var levels []models.WarehouseStock
+	err := query.Preload("Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Order("product_id ASC").Offset(offset).Limit(limit).Find(&levels).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch warehouse stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"warehouse":   warehouse,
+		"stock":       levels,
+		"total":       totalCount,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetProductStockLevels shows a product's stock in each warehouse.
+func GetProductStockLevels(c *gin.Context) {
+	id := c.Param("id")
+
+	var product models.Product
+	if err := database.DB.Unscoped().First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	var levels []models.WarehouseStock
+	if err := database.DB.Preload("Warehouse").Where("product_id = ?", product.ID).
+		Order("warehouse_id ASC").Find(&levels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock levels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": product.ID,
+		"stock":      product.Stock,
+		"levels":     levels,
+	})
+}
+
+// GetStockTransfers lists transfers, newest first, optionally for one product
+// or involving one warehouse.
+func GetStockTransfers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.StockTransfer{})
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		query = query.Where("from_warehouse_id = ? OR to_warehouse_id = ?", warehouseID, warehouseID)
+	}
+
+	var totalCount int64
+	query.Count(&totalCount)
+
+	var transfers []models.StockTransfer
+	err := query.Preload("Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("FromWarehouse").Preload("ToWarehouse").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&transfers).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers":   transfers,
+		"total":       totalCount,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (totalCount + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// CreateStockTransfer moves stock of a product between two warehouses. The
+// product's overall stock does not change.
+func CreateStockTransfer(c *gin.Context) {
+	var req StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.FromWarehouseID == req.ToWarehouseID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination warehouses must differ"})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.Warehouse{}).Where("id IN ?", []uint{req.FromWarehouseID, req.ToWarehouseID}).Count(&count)
+	if count != 2 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	adminID := userID.(uint)
+
+	transfer := models.StockTransfer{
+		ProductID:       req.ProductID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		UserID:          &adminID,
+		Note:            strings.TrimSpace(req.Note),
+	}
+	var events []notifications.Event
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Product", "FromWarehouse", "ToWarehouse").Create(&transfer).Error; err != nil {
+			return err
+		}
+		note := "Transfer #" + strconv.FormatUint(uint64(transfer.ID), 10)
+		if transfer.Note != "" {
+			note += ": " + transfer.Note
+		}
+		out := models.StockMovement{
+			ProductID:   transfer.ProductID,
+			WarehouseID: &transfer.FromWarehouseID,
+			Quantity:    -transfer.Quantity,
+			Reason:      models.StockReasonTransfer,
+			UserID:      &adminID,
+			Note:        note,
+		}
+		outEvents, err := moveStock(tx, out)
+		if err != nil {
+			return err
+		}
+		in := out
+		in.WarehouseID = &transfer.ToWarehouseID
+		in.Quantity = transfer.Quantity
+		inEvents, err := moveStock(tx, in)
+		if err != nil {
+			return err
+		}
+		events = append(outEvents, inEvents...)
+		return nil
+	})
+	if _, ok := err.(inventoryError); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer stock"})
+		return
+	}
+	notifications.Publish(events...)
+
+	database.DB.Preload("FromWarehouse").Preload("ToWarehouse").First(&transfer, transfer.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Stock transferred successfully",
+		"transfer": transfer,
+	})
+}
+
+// GetOrderAllocations lists the warehouses each line of an order is taken
+// from.
+func GetOrderAllocations(c *gin.Context) {
+	id := c.Param("id")
+
+	var order models.Order
+	if err := database.DB.First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	var allocations []models.OrderItemAllocation
+	if err := database.DB.Preload("Warehouse").Where("order_id = ? AND quantity > 0", order.ID).
+		Order("order_item_id ASC, id ASC").Find(&allocations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch allocations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id":    order.ID,
+		"allocations": allocations,
+	})
+}
+
+// allocationPart is a number of units taken from one warehouse.
+type allocationPart struct {
+	WarehouseID uint
+	Quantity    int
+}
+
+// allocateStock takes quantity units for an order line out of stock, from
+// the warehouses the allocation rule picks for the shipping address, and
+// records where they came from.
+func allocateStock(tx *gorm.DB, item models.OrderItem, quantity int, address string) ([]notifications.Event, error) {
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, item.ProductID).Error; err != nil {
+		return nil, err
+	}
+
+	active := tx.Model(&models.Warehouse{}).Select("id").Where("active = ?", true)
+	var levels []models.WarehouseStock
+	if err := tx.Preload("Warehouse").Where("product_id = ? AND quantity > 0 AND warehouse_id IN (?)", product.ID, active).
+		Find(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	parts := planAllocation(levels, quantity, allocationRule(), address)
+	if parts == nil {
+		return nil, inventoryError("Insufficient stock for product: " + product.Name)
+	}
+
+	var events []notifications.Event
+	for _, part := range parts {
+		warehouseID := part.WarehouseID
+		movement := orderStockMovement(item, -part.Quantity, models.StockReasonSale)
+		movement.WarehouseID = &warehouseID
+		stockEvents, err := moveStock(tx, movement)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, stockEvents...)
+
+		var allocation models.OrderItemAllocation
+		err = tx.Where("order_item_id = ? AND warehouse_id = ?", item.ID, warehouseID).First(&allocation).Error
+		if err == gorm.ErrRecordNotFound {
+			allocation = models.OrderItemAllocation{OrderID: item.OrderID, OrderItemID: item.ID, WarehouseID: warehouseID, Quantity: part.Quantity}
+			err = tx.Omit("Warehouse").Create(&allocation).Error
+		} else if err == nil {
+			err = tx.Model(&allocation).Update("quantity", gorm.Expr("quantity + ?", part.Quantity)).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// releaseStock puts quantity units of an order line back into stock, in the
+// warehouses they were most recently allocated from. Units of lines placed
+// before allocations were recorded go back to the default warehouse.
+func releaseStock(tx *gorm.DB, item models.OrderItem, quantity int, reason string) ([]notifications.Event, error) {
+	var allocations []models.OrderItemAllocation
+	if err := tx.Where("order_item_id = ? AND quantity > 0", item.ID).Order("id DESC").Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+
+	var events []notifications.Event
+	remaining := quantity
+	for _, allocation := range allocations {
+		if remaining == 0 {
+			break
+		}
+		released := allocation.Quantity
+		if released > remaining {
+			released = remaining
+		}
+		movement := orderStockMovement(item, released, reason)
+		movement.WarehouseID = &allocation.WarehouseID
+		stockEvents, err := moveStock(tx, movement)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, stockEvents...)
+		if err := tx.Model(&allocation).Update("quantity", allocation.Quantity-released).Error; err != nil {
+			return nil, err
+		}
+		remaining -= released
+	}
+
+	if remaining > 0 {
+		stockEvents, err := moveStock(tx, orderStockMovement(item, remaining, reason))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, stockEvents...)
+	}
+	return events, nil
+}
+
+// returnStock puts returned units of an order line back into stock, in the
+// given warehouse or else the one the line was first allocated from.
+func returnStock(tx *gorm.DB, item models.OrderItem, quantity int, warehouseID *uint) ([]notifications.Event, error) {
+	movement := orderStockMovement(item, quantity, models.StockReasonReturn)
+	movement.WarehouseID = warehouseID
+	if warehouseID != nil {
+		var warehouse models.Warehouse
+		if err := tx.First(&warehouse, *warehouseID).Error; err != nil {
+			return nil, inventoryError("Warehouse not found")
+		}
+	} else {
+		var allocation models.OrderItemAllocation
+		if err := tx.Where("order_item_id = ?", item.ID).Order("id ASC").First(&allocation).Error; err == nil {
+			movement.WarehouseID = &allocation.WarehouseID
+		}
+	}
+	return moveStock(tx, movement)
+}
+
+// planAllocation splits quantity over the warehouses holding stock of a
+// product according to rule, or returns nil when they cannot cover it.
+// Warehouses are tried in order of closeness to address for the closest
+// rule and of priority otherwise; both rules other than split prefer a
+// single warehouse that can ship the whole quantity.
+func planAllocation(levels []models.WarehouseStock, quantity int, rule, address string) []allocationPart {
+	sort.SliceStable(levels, func(i, j int) bool {
+		a, b := levels[i].Warehouse, levels[j].Warehouse
+		if rule == models.AllocationClosest {
+			if ma, mb := a.ServiceAreaMatch(address), b.ServiceAreaMatch(address); ma != mb {
+				return ma > mb
+			}
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.ID < b.ID
+	})
+
+	if rule != models.AllocationSplit {
+		for _, level := range levels {
+			if level.Quantity >= quantity {
+				return []allocationPart{{WarehouseID: level.WarehouseID, Quantity: quantity}}
+			}
+		}
+	}
+
+	var parts []allocationPart
+	remaining := quantity
+	for _, level := range levels {
+		if remaining == 0 {
+			break
+		}
+		taken := level.Quantity
+		if taken > remaining {
+			taken = remaining
+		}
+		parts = append(parts, allocationPart{WarehouseID: level.WarehouseID, Quantity: taken})
+		remaining -= taken
+	}
+	if remaining > 0 {
+		return nil
+	}
+	return parts
+}
+
+// allocationRule is the stock allocation rule from STOCK_ALLOCATION_RULE,
+// priority when unset or unknown.
+func allocationRule() string {
+	rule := os.Getenv("STOCK_ALLOCATION_RULE")
+	if !models.IsValidAllocationRule(rule) {
+		return models.AllocationPriority
+	}
+	return rule
+}
+
+// defaultWarehouse is the warehouse stock changes without a location of
+// their own are recorded in.
+func defaultWarehouse(tx *gorm.DB) (models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := tx.Where("is_default = ?", true).First(&warehouse).Error
+	return warehouse, err
+}
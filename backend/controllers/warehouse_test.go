@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"ecommerce-backend/models"
+)
+
+func TestPlanAllocation(t *testing.T) {
+	// Warehouse 1 is the highest priority, warehouse 3 serves Berlin
+	warehouses := map[uint]*models.Warehouse{
+		1: {ID: 1, Priority: 0, ServiceAreas: "Hamburg"},
+		2: {ID: 2, Priority: 1},
+		3: {ID: 3, Priority: 2, ServiceAreas: "Berlin, 10"},
+	}
+	levels := func(quantities ...int) []models.WarehouseStock {
+		var levels []models.WarehouseStock
+		for i, quantity := range quantities {
+			id := uint(i + 1)
+			levels = append(levels, models.WarehouseStock{WarehouseID: id, Quantity: quantity, Warehouse: warehouses[id]})
+		}
+		return levels
+	}
+	const berlin = "Hauptstr. 1, 10115 Berlin"
+
+	tests := []struct {
+		name     string
+		levels   []models.WarehouseStock
+		quantity int
+		rule     string
+		address  string
+		want     []allocationPart
+	}{
+		{"priority from first warehouse", levels(5, 5, 5), 3, models.AllocationPriority, berlin, []allocationPart{{1, 3}}},
+		{"priority skips warehouse that cannot fill", levels(2, 5, 5), 3, models.AllocationPriority, berlin, []allocationPart{{2, 3}}},
+		{"priority splits when none can fill", levels(2, 1, 2), 4, models.AllocationPriority, berlin, []allocationPart{{1, 2}, {2, 1}, {3, 1}}},
+		{"closest from serving warehouse", levels(5, 5, 5), 3, models.AllocationClosest, berlin, []allocationPart{{3, 3}}},
+		{"closest falls back to priority", levels(5, 5, 2), 3, models.AllocationClosest, berlin, []allocationPart{{1, 3}}},
+		{"closest without a match uses priority", levels(5, 5, 5), 3, models.AllocationClosest, "1 Main St, Springfield", []allocationPart{{1, 3}}},
+		{"closest splits closest first", levels(2, 2, 2), 5, models.AllocationClosest, berlin, []allocationPart{{3, 2}, {1, 2}, {2, 1}}},
+		{"split takes what each has", levels(2, 5, 5), 4, models.AllocationSplit, berlin, []allocationPart{{1, 2}, {2, 2}}},
+		{"split from first when it has enough", levels(5, 5, 5), 4, models.AllocationSplit, berlin, []allocationPart{{1, 4}}},
+		{"exact total", levels(1, 1, 1), 3, models.AllocationPriority, berlin, []allocationPart{{1, 1}, {2, 1}, {3, 1}}},
+		{"not enough stock", levels(1, 1, 1), 4, models.AllocationSplit, berlin, nil},
+		{"no warehouses", nil, 1, models.AllocationPriority, berlin, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planAllocation(tt.levels, tt.quantity, tt.rule, tt.address); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planAllocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanAllocationOrdersByPriority(t *testing.T) {
+	levels := []models.WarehouseStock{
+		{WarehouseID: 4, Quantity: 1, Warehouse: &models.Warehouse{ID: 4, Priority: 2}},
+		{WarehouseID: 9, Quantity: 1, Warehouse: &models.Warehouse{ID: 9, Priority: 1}},
+		{WarehouseID: 6, Quantity: 1, Warehouse: &models.Warehouse{ID: 6, Priority: 1}},
+	}
+	want := []allocationPart{{6, 1}, {9, 1}, {4, 1}}
+	if got := planAllocation(levels, 3, models.AllocationPriority, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("planAllocation() = %v, want %v", got, want)
+	}
+}
+
+func TestAllocationRule(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", models.AllocationPriority},
+		{"closest", models.AllocationClosest},
+		{"split", models.AllocationSplit},
+		{"nearest", models.AllocationPriority},
+	}
+	for _, tt := range tests {
+		t.Setenv("STOCK_ALLOCATION_RULE", tt.value)
+		if got := allocationRule(); got != tt.want {
+			t.Errorf("allocationRule() with %q = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
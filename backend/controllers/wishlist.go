@@ -0,0 +1,513 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateWishlistRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type UpdateWishlistRequest struct {
+	Name string `json:"name" binding:"max=100"`
+}
+
+type AddWishlistItemRequest struct {
+	ProductID         uint   `json:"product_id" binding:"required"`
+	NotifyPriceDrop   bool   `json:"notify_price_drop"`
+	NotifyBackInStock bool   `json:"notify_back_in_stock"`
+	Note              string `json:"note" binding:"max=255"`
+}
+
+type UpdateWishlistItemRequest struct {
+	NotifyPriceDrop   *bool   `json:"notify_price_drop"`
+	NotifyBackInStock *bool   `json:"notify_back_in_stock"`
+	Note              *string `json:"note" binding:"omitempty,max=255"`
+}
+
+type MoveToCartRequest struct {
+	ItemIDs []uint `json:"item_ids"`
+	// KeepInWishlist leaves moved items on the wishlist instead of removing them.
+	KeepInWishlist bool `json:"keep_in_wishlist"`
+}
+
+func GetWishlists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var wishlists []models.Wishlist
+	if err := database.DB.Preload("Items.Product.Category").Where("user_id = ?", userID).
+		Order("created_at ASC").Find(&wishlists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wishlists"})
+		return
+	}
+
+	wishlistResponses := make([]models.WishlistResponse, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		wishlistResponses = append(wishlistResponses, wishlistResponse(wishlist))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wishlists": wishlistResponses,
+	})
+}
+
+func GetWishlist(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, true)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wishlist": wishlistResponse(wishlist),
+	})
+}
+
+func GetSharedWishlist(c *gin.Context) {
+	var wishlist models.Wishlist
+	if err := database.DB.Preload("User").Preload("Items.Product.Category").
+		Where("share_token = ? AND is_public = ?", c.Param("token"), true).First(&wishlist).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist not found"})
+		return
+	}
+
+	// Shared wishlists are read-only and only show products still on sale
+	now := time.Now()
+	visibleItems := wishlist.Items[:0]
+	for _, item := range wishlist.Items {
+		if item.Product.IsVisibleAt(now) {
+			visibleItems = append(visibleItems, item)
+		}
+	}
+	wishlist.Items = visibleItems
+
+	response := wishlist.ToResponse()
+	response.UserID = 0
+
+	c.JSON(http.StatusOK, gin.H{
+		"wishlist": response,
+		"owner":    wishlist.User.FirstName,
+	})
+}
+
+func CreateWishlist(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateWishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existingWishlist models.Wishlist
+	if err := database.DB.Where("user_id = ? AND name = ?", userID, req.Name).First(&existingWishlist).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Wishlist already exists"})
+		return
+	}
+
+	shareToken, err := utils.GenerateToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wishlist"})
+		return
+	}
+
+	wishlist := models.Wishlist{
+		UserID:     userID.(uint),
+		Name:       req.Name,
+		ShareToken: shareToken,
+	}
+
+	if err := database.DB.Create(&wishlist).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Wishlist created successfully",
+		"wishlist": wishlistResponse(wishlist),
+	})
+}
+
+func UpdateWishlist(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	var req UpdateWishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != "" && req.Name != wishlist.Name {
+		var existingWishlist models.Wishlist
+		if err := database.DB.Where("user_id = ? AND name = ? AND id != ?", wishlist.UserID, req.Name, wishlist.ID).
+			First(&existingWishlist).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Wishlist name already exists"})
+			return
+		}
+		wishlist.Name = req.Name
+	}
+
+	if err := database.DB.Omit("Items", "User").Save(&wishlist).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update wishlist"})
+		return
+	}
+
+	database.DB.Preload("Items.Product.Category").First(&wishlist, wishlist.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Wishlist updated successfully",
+		"wishlist": wishlistResponse(wishlist),
+	})
+}
+
+func DeleteWishlist(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", wishlist.ID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&wishlist).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Wishlist deleted successfully",
+	})
+}
+
+func ShareWishlist(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	// Regenerating the token revokes previously shared links
+	updates := map[string]interface{}{"is_public": true}
+	if c.Query("regenerate") == "true" {
+		shareToken, err := utils.GenerateToken(24)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share wishlist"})
+			return
+		}
+		updates["share_token"] = shareToken
+		wishlist.ShareToken = shareToken
+	}
+
+	if err := database.DB.Model(&wishlist).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Wishlist shared successfully",
+		"share_url": wishlistShareURL(wishlist),
+	})
+}
+
+func UnshareWishlist(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Model(&wishlist).Update("is_public", false).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unshare wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Wishlist is no longer shared",
+	})
+}
+
+func AddWishlistItem(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	var req AddWishlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.Scopes(models.VisibleProducts(time.Now())).First(&product, req.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	var existingItem models.WishlistItem
+	if err := database.DB.Where("wishlist_id = ? AND product_id = ?", wishlist.ID, product.ID).First(&existingItem).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product is already in this wishlist"})
+		return
+	}
+
+	item := models.WishlistItem{
+		WishlistID:        wishlist.ID,
+		ProductID:         product.ID,
+		PriceAtAdd:        product.Price,
+		WasOutOfStock:     product.Stock <= 0,
+		NotifyPriceDrop:   req.NotifyPriceDrop,
+		NotifyBackInStock: req.NotifyBackInStock,
+		Note:              req.Note,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		return subscribeWishlistItem(tx, wishlist.UserID, product, item)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to wishlist"})
+		return
+	}
+
+	database.DB.Preload("Product.Category").First(&item, item.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Item added to wishlist successfully",
+		"item":    item.ToResponse(),
+	})
+}
+
+func UpdateWishlistItem(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	item, ok := findWishlistItem(c, wishlist)
+	if !ok {
+		return
+	}
+
+	var req UpdateWishlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only flags switched on by this request create subscriptions
+	enabled := item
+	enabled.NotifyPriceDrop = req.NotifyPriceDrop != nil && *req.NotifyPriceDrop && !item.NotifyPriceDrop
+	enabled.NotifyBackInStock = req.NotifyBackInStock != nil && *req.NotifyBackInStock && !item.NotifyBackInStock
+
+	// Update item fields
+	if req.NotifyPriceDrop != nil {
+		item.NotifyPriceDrop = *req.NotifyPriceDrop
+	}
+	if req.NotifyBackInStock != nil {
+		item.NotifyBackInStock = *req.NotifyBackInStock
+	}
+	if req.Note != nil {
+		item.Note = *req.Note
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Product").Save(&item).Error; err != nil {
+			return err
+		}
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
+			return err
+		}
+		return subscribeWishlistItem(tx, wishlist.UserID, product, enabled)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update wishlist item"})
+		return
+	}
+
+	database.DB.Preload("Product.Category").First(&item, item.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Wishlist item updated successfully",
+		"item":    item.ToResponse(),
+	})
+}
+
+func RemoveWishlistItem(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, false)
+	if !ok {
+		return
+	}
+
+	item, ok := findWishlistItem(c, wishlist)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove item from wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Item removed from wishlist successfully",
+	})
+}
+
+// MoveWishlistToCart adds wishlist items to the cart one unit at a time using
+// the same availability and stock rules as AddToCart. Items that cannot be
+// added are reported and left on the wishlist.
+func MoveWishlistToCart(c *gin.Context) {
+	wishlist, ok := findUserWishlist(c, true)
+	if !ok {
+		return
+	}
+
+	var req MoveToCartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	selected := make(map[uint]bool, len(req.ItemIDs))
+	for _, itemID := range req.ItemIDs {
+		selected[itemID] = true
+	}
+
+	type moveFailure struct {
+		ItemID    uint   `json:"item_id"`
+		ProductID uint   `json:"product_id"`
+		Error     string `json:"error"`
+	}
+	moved := []uint{}
+	failed := []moveFailure{}
+
+	for _, item := range wishlist.Items {
+		if len(selected) > 0 && !selected[item.ID] {
+			continue
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if _, _, err := addProductToCart(tx, cartOwner{UserID: wishlist.UserID}, item.ProductID, 1); err != nil {
+				return err
+			}
+			if req.KeepInWishlist {
+				return nil
+			}
+			return tx.Delete(&item).Error
+		})
+		if err != nil {
+			message := "Failed to add item to cart"
+			if err == errCartProductNotFound || err == errCartInsufficientStock || err == errCartQuantityExceeded {
+				message = err.Error()
+			}
+			failed = append(failed, moveFailure{ItemID: item.ID, ProductID: item.ProductID, Error: message})
+			continue
+		}
+		moved = append(moved, item.ID)
+	}
+
+	database.DB.Preload("Items.Product.Category").First(&wishlist, wishlist.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Wishlist items moved to cart",
+		"moved":    moved,
+		"failed":   failed,
+		"wishlist": wishlistResponse(wishlist),
+	})
+}
+
+func findUserWishlist(c *gin.Context, withItems bool) (models.Wishlist, bool) {
+	var wishlist models.Wishlist
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return wishlist, false
+	}
+
+	wishlistID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlist ID"})
+		return wishlist, false
+	}
+
+	query := database.DB.Where("id = ? AND user_id = ?", wishlistID, userID)
+	if withItems {
+		query = query.Preload("Items.Product.Category")
+	}
+	if err := query.First(&wishlist).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist not found"})
+		return wishlist, false
+	}
+	return wishlist, true
+}
+
+func findWishlistItem(c *gin.Context, wishlist models.Wishlist) (models.WishlistItem, bool) {
+	var item models.WishlistItem
+
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlist item ID"})
+		return item, false
+	}
+
+	if err := database.DB.Where("id = ? AND wishlist_id = ?", itemID, wishlist.ID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist item not found"})
+		return item, false
+	}
+	return item, true
+}
+
+// subscribeWishlistItem turns the item's notify flags into in-app product
+// subscriptions. Clearing a flag leaves the subscription alone; it is managed
+// from the subscriptions endpoints.
+func subscribeWishlistItem(db *gorm.DB, userID uint, product models.Product, item models.WishlistItem) error {
+	if item.NotifyPriceDrop {
+		if _, err := subscribeToProduct(db, userID, product, models.SubscriptionPriceDrop, notifications.ChannelInApp, nil); err != nil {
+			return err
+		}
+	}
+	if item.NotifyBackInStock && product.Stock <= 0 {
+		if _, err := subscribeToProduct(db, userID, product, models.SubscriptionBackInStock, notifications.ChannelInApp, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func wishlistResponse(wishlist models.Wishlist) models.WishlistResponse {
+	response := wishlist.ToResponse()
+	if wishlist.IsPublic {
+		response.ShareURL = wishlistShareURL(wishlist)
+	}
+	return response
+}
+
+func wishlistShareURL(wishlist models.Wishlist) string {
+	return os.Getenv("FRONTEND_URL") + "/wishlists/shared/" + wishlist.ShareToken
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"testing"
+	"ecommerce-backend/models"
+)
+
+func TestWishlistResponseShareURL(t *testing.T) {
+	t.Setenv("FRONTEND_URL", "https://shop.example")
+
+	shared := wishlistResponse(models.Wishlist{Name: "Birthday", IsPublic: true, ShareToken: "abc123"})
+	if want := "https://shop.example/wishlists/shared/abc123"; shared.ShareURL != want {
+		t.Errorf("ShareURL = %q, want %q", shared.ShareURL, want)
+	}
+
+	private := wishlistResponse(models.Wishlist{Name: "Ideas", ShareToken: "def456"})
+	if private.ShareURL != "" {
+		t.Errorf("private wishlist ShareURL = %q, want none", private.ShareURL)
+	}
+	if private.Items == nil {
+		t.Error("Items = nil, want an empty list")
+	}
+}
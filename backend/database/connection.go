@@ -1,10 +1,12 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -21,27 +23,366 @@ func ConnectDB() {
 	)
 
 	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	if err := dropUniqueCategoryNameIndex(); err != nil {
+		log.Fatal("Failed to drop unique category name index:", err)
+	}
+
+	if err := prepareUniqueProductSKUs(); err != nil {
+		log.Fatal("Failed to prepare unique product SKUs:", err)
+	}
+
 	// Auto Migrate
 	err = DB.AutoMigrate(
 		&models.User{},      
 		&models.Category{},
 		&models.Product{},
 		&models.Cart{},
+		&models.GuestCart{},
+		&models.CartNotice{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderEdit{},
+		&models.OrderSubscription{},
+		&models.OrderSubscriptionItem{},
+		&models.Coupon{},
+		&models.CheckoutQuote{},
+		&models.IdempotencyRecord{},
+		&models.AbandonedCart{},
+		&models.Sequence{},
+		&models.AttributeDefinition{},
+		&models.ProductAttributeValue{},
+		&models.Review{},
+		&models.ReviewVote{},
+		&models.Wishlist{},
+		&models.WishlistItem{},
+		&models.ProductSubscription{},
+		&models.Notification{},
+		&models.StockMovement{},
+		&models.Warehouse{},
+		&models.WarehouseStock{},
+		&models.OrderItemAllocation{},
+		&models.StockTransfer{},
+		&models.Supplier{},
+		&models.SupplierProduct{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.ProductCost{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	if err := backfillCategoryHierarchy(); err != nil {
+		log.Fatal("Failed to backfill category hierarchy:", err)
+	}
+
+	if err := backfillOrderNumbers(); err != nil {
+		log.Fatal("Failed to backfill order numbers:", err)
+	}
+
+	if err := backfillOrderItemFulfillment(); err != nil {
+		log.Fatal("Failed to backfill order item fulfillment:", err)
+	}
+
+	if err := backfillStockLedger(); err != nil {
+		log.Fatal("Failed to backfill stock ledger:", err)
+	}
+
+	if err := backfillWarehouses(); err != nil {
+		log.Fatal("Failed to backfill warehouses:", err)
+	}
+
+	if err := syncSellableStock(); err != nil {
+		log.Fatal("Failed to sync sellable stock:", err)
+	}
+
 	log.Println("Database connected and migrated successfully")
 }
 
+// dropUniqueCategoryNameIndex drops the unique index category names had
+// before names only had to be unique among siblings. AutoMigrate never drops
+// indexes, and the plain index replacing it has the same name, so it would
+// otherwise be kept.
+func dropUniqueCategoryNameIndex() error {
+	if !DB.Migrator().HasTable(&models.Category{}) {
+		return nil
+	}
+	indexes, err := DB.Migrator().GetIndexes(&models.Category{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if unique, ok := index.Unique(); ok && unique && index.Name() == "idx_categories_name" {
+			return DB.Migrator().DropIndex(&models.Category{}, index.Name())
+		}
+	}
+	return nil
+}
+
+// prepareUniqueProductSKUs readies existing products for the unique SKU
+// index: products without a SKU get a generated one, products sharing a SKU
+// keep it on the oldest only and the others get their ID appended, and the
+// plain index the unique one replaces is dropped, as AutoMigrate would keep
+// it under the same name.
+func prepareUniqueProductSKUs() error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&models.Product{}) {
+		return nil
+	}
+	if !migrator.HasColumn(&models.Product{}, "SKU") {
+		if err := migrator.AddColumn(&models.Product{}, "SKU"); err != nil {
+			return err
+		}
+	}
+
+	indexes, err := migrator.GetIndexes(&models.Product{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if unique, ok := index.Unique(); ok && !unique && index.Name() == "idx_products_sku" {
+			if err := migrator.DropIndex(&models.Product{}, index.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	var products []models.Product
+	if err := DB.Unscoped().Select("id", "sku").Order("id ASC").Find(&products).Error; err != nil {
+		return err
+	}
+	changed, err := uniqueProductSKUs(products)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range changed {
+		if err := DB.Unscoped().Model(&models.Product{}).Where("id = ?", product.ID).UpdateColumn("sku", product.SKU).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueProductSKUs returns the products, in ID order, whose SKU has to
+// change for every product to have its own.
+func uniqueProductSKUs(products []models.Product) ([]models.Product, error) {
+	used := make(map[string]bool, len(products))
+	for _, product := range products {
+		used[product.SKU] = true
+	}
+
+	seen := make(map[string]bool, len(products))
+	var changed []models.Product
+	for _, product := range products {
+		if product.SKU != "" && !seen[product.SKU] {
+			seen[product.SKU] = true
+			continue
+		}
+		var sku string
+		if product.SKU == "" {
+			for sku == "" || used[sku] {
+				var err error
+				if sku, err = utils.GenerateSKU(); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			base := fmt.Sprintf("%s-%d", product.SKU, product.ID)
+			sku = base
+			for i := 2; used[sku]; i++ {
+				sku = fmt.Sprintf("%s-%d", base, i)
+			}
+		}
+		used[sku] = true
+		product.SKU = sku
+		changed = append(changed, product)
+	}
+	return changed, nil
+}
+
+// backfillCategoryHierarchy fills slug, path and depth for categories created
+// before the hierarchy columns existed. Parents are processed before children.
+func backfillCategoryHierarchy() error {
+	var categories []models.Category
+	if err := DB.Order("id ASC").Find(&categories).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[uint]*models.Category, len(categories))
+	usedSlugs := make(map[string]bool, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+		if categories[i].Slug != "" {
+			usedSlugs[categories[i].Slug] = true
+		}
+	}
+
+	var resolve func(category *models.Category, visiting map[uint]bool) error
+	resolve = func(category *models.Category, visiting map[uint]bool) error {
+		if category.Path != "" && category.Slug != "" {
+			return nil
+		}
+		if visiting[category.ID] {
+			return fmt.Errorf("category %d has a cyclic parent chain", category.ID)
+		}
+		visiting[category.ID] = true
+
+		var parent *models.Category
+		if category.ParentID != nil {
+			parent = byID[*category.ParentID]
+			if parent != nil {
+				if err := resolve(parent, visiting); err != nil {
+					return err
+				}
+			}
+		}
+
+		updates := map[string]interface{}{}
+		if category.Slug == "" {
+			base := utils.Slugify(category.Name)
+			if base == "" {
+				base = "category"
+			}
+			slug := base
+			for i := 2; usedSlugs[slug]; i++ {
+				slug = fmt.Sprintf("%s-%d", base, i)
+			}
+			usedSlugs[slug] = true
+			category.Slug = slug
+			updates["slug"] = slug
+		}
+		if category.Path == "" {
+			category.Path = category.BuildPath(parent)
+			category.Depth = 0
+			if parent != nil {
+				category.Depth = parent.Depth + 1
+			}
+			updates["path"] = category.Path
+			updates["depth"] = category.Depth
+		}
+		return DB.Model(&models.Category{}).Where("id = ?", category.ID).Updates(updates).Error
+	}
+
+	for i := range categories {
+		if err := resolve(&categories[i], map[uint]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// backfillOrderNumbers numbers orders placed before order numbers existed,
+// in the order they were placed, using the same daily sequences as new
+// orders.
+func backfillOrderNumbers() error {
+	var orders []models.Order
+	if err := DB.Unscoped().Select("id", "created_at").Where("order_number = '' OR order_number IS NULL").
+		Order("created_at ASC, id ASC").Find(&orders).Error; err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			sequence, err := models.NextSequenceValue(tx, utils.OrderSequenceName(order.CreatedAt))
+			if err != nil {
+				return err
+			}
+			number := utils.FormatOrderNumber(utils.OrderNumberPrefix(), order.CreatedAt, sequence)
+			return tx.Unscoped().Model(&models.Order{}).Where("id = ?", order.ID).Update("order_number", number).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillOrderItemFulfillment gives lines of orders shipped or cancelled
+// before lines were tracked individually the quantities matching their
+// order's status, so later line changes start from the right place.
+func backfillOrderItemFulfillment() error {
+	shipped := DB.Model(&models.Order{}).Select("id").Where("status IN ?", []string{models.OrderStatusShipped, models.OrderStatusDelivered})
+	err := DB.Model(&models.OrderItem{}).
+		Where("status = ? AND fulfilled_quantity = 0 AND cancelled_quantity = 0 AND order_id IN (?)", models.OrderItemPending, shipped).
+		Updates(map[string]interface{}{"fulfilled_quantity": gorm.Expr("quantity"), "status": models.OrderItemFulfilled}).Error
+	if err != nil {
+		return err
+	}
+
+	cancelled := DB.Model(&models.Order{}).Select("id").Where("status = ?", models.OrderStatusCancelled)
+	return DB.Model(&models.OrderItem{}).
+		Where("status = ? AND fulfilled_quantity = 0 AND cancelled_quantity = 0 AND order_id IN (?)", models.OrderItemPending, cancelled).
+		Updates(map[string]interface{}{"cancelled_quantity": gorm.Expr("quantity"), "status": models.OrderItemCancelled}).Error
+}
+
+// backfillStockLedger opens the inventory ledger for products whose stock was
+// set before stock movements were recorded, so that each product's stock is
+// the sum of its movements.
+func backfillStockLedger() error {
+	ledgered := DB.Model(&models.StockMovement{}).Select("product_id")
+	var products []models.Product
+	if err := DB.Unscoped().Select("id", "stock").Where("stock <> 0 AND id NOT IN (?)", ledgered).Find(&products).Error; err != nil {
+		return err
+	}
+
+	movements := make([]models.StockMovement, 0, len(products))
+	for _, product := range products {
+		movements = append(movements, models.StockMovement{
+			ProductID:    product.ID,
+			Quantity:     product.Stock,
+			Reason:       models.StockReasonAdjustment,
+			BalanceAfter: product.Stock,
+			Note:         "Opening balance",
+		})
+	}
+	if len(movements) == 0 {
+		return nil
+	}
+	return DB.CreateInBatches(&movements, 500).Error
+}
+
+// syncSellableStock sets the stock of products held in inactive warehouses
+// to what their active warehouses hold, as stock changes have since done.
+func syncSellableStock() error {
+	return DB.Exec(`UPDATE products SET stock = (
+			SELECT COALESCE(SUM(warehouse_stocks.quantity), 0) FROM warehouse_stocks
+			JOIN warehouses ON warehouses.id = warehouse_stocks.warehouse_id
+			WHERE warehouse_stocks.product_id = products.id AND warehouses.active = ?)
+		WHERE id IN (
+			SELECT warehouse_stocks.product_id FROM warehouse_stocks
+			JOIN warehouses ON warehouses.id = warehouse_stocks.warehouse_id
+			WHERE warehouses.active = ? AND warehouse_stocks.quantity <> 0)`, true, false).Error
+}
+
+// backfillWarehouses creates the default warehouse on first start and places
+// stock movements recorded without a warehouse in it, rebuilding the
+// per-warehouse stock levels from the ledger when any were moved.
+func backfillWarehouses() error {
+	var warehouse models.Warehouse
+	err := DB.Where("is_default = ?", true).First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		warehouse = models.Warehouse{Code: "MAIN", Name: "Main warehouse", Active: true, IsDefault: true}
+		err = DB.Create(&warehouse).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	result := DB.Model(&models.StockMovement{}).Where("warehouse_id IS NULL").Update("warehouse_id", warehouse.ID)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	return DB.Exec(`INSERT INTO warehouse_stocks (warehouse_id, product_id, quantity, updated_at)
+		SELECT warehouse_id, product_id, SUM(quantity), NOW() FROM stock_movements GROUP BY warehouse_id, product_id
+		ON DUPLICATE KEY UPDATE quantity = VALUES(quantity), updated_at = VALUES(updated_at)`).Error
+}
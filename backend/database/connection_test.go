@@ -0,0 +1,31 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"ecommerce-backend/models"
+)
+
+func TestUniqueProductSKUs(t *testing.T) {
+	products := []models.Product{
+		{ID: 1, SKU: "LAMP"},
+		{ID: 2, SKU: ""},
+		{ID: 3, SKU: "LAMP"},
+		{ID: 4, SKU: "LAMP-3"},
+		{ID: 5, SKU: "CHAIR"},
+	}
+
+	changed, err := uniqueProductSKUs(products)
+	if err != nil {
+		t.Fatalf("uniqueProductSKUs() error = %v", err)
+	}
+	if len(changed) != 2 {
+		t.Fatalf("changed = %+v, want products 2 and 3", changed)
+	}
+	if changed[0].ID != 2 || !strings.HasPrefix(changed[0].SKU, "SKU-") {
+		t.Errorf("changed[0] = %+v, want a generated SKU for product 2", changed[0])
+	}
+	if changed[1].ID != 3 || changed[1].SKU != "LAMP-3-2" {
+		t.Errorf("changed[1] = %+v, want LAMP-3-2 for product 3", changed[1])
+	}
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
+	"gorm.io/gorm"
+)
+
+// recoveryLinkTTL is how long the link in a reminder restores the cart.
+const recoveryLinkTTL = 14 * 24 * time.Hour
+
+// AbandonedCartConfig controls abandoned cart detection. Carts idle for
+// longer than After but not longer than MaxAge are recorded on every run of
+// Interval; older carts are left alone so a first run does not remind
+// customers about carts from months ago.
+type AbandonedCartConfig struct {
+	After    time.Duration
+	MaxAge   time.Duration
+	Interval time.Duration
+	Channel  string
+}
+
+func AbandonedCartConfigFromEnv() AbandonedCartConfig {
+	return AbandonedCartConfig{
+		After:    envDuration("ABANDONED_CART_AFTER", 24*time.Hour),
+		MaxAge:   envDuration("ABANDONED_CART_MAX_AGE", 7*24*time.Hour),
+		Interval: envDuration("ABANDONED_CART_INTERVAL", 15*time.Minute),
+		Channel:  os.Getenv("ABANDONED_CART_CHANNEL"),
+	}
+}
+
+// StartAbandonedCartJob runs DetectAbandonedCarts in the background.
+func StartAbandonedCartJob(db *gorm.DB, config AbandonedCartConfig) {
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			if count, err := DetectAbandonedCarts(db, config, time.Now()); err != nil {
+				log.Printf("Abandoned cart detection failed: %v", err)
+			} else if count > 0 {
+				log.Printf("Recorded %d abandoned carts", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+type idleCart struct {
+	UserID       *uint
+	GuestCartID  *uint
+	LastActivity time.Time
+}
+
+// DetectAbandonedCarts records carts that went idle since the last run and
+// sends a reminder to those that belong to a user. It returns the number of
+// carts recorded.
+func DetectAbandonedCarts(db *gorm.DB, config AbandonedCartConfig, now time.Time) (int, error) {
+	var idle []idleCart
+	err := db.Model(&models.Cart{}).
+		Select("user_id, guest_cart_id, MAX(updated_at) AS last_activity").
+		Where("saved_for_later = ?", false).
+		Group("user_id, guest_cart_id").
+		Having("MAX(updated_at) < ? AND MAX(updated_at) >= ?", now.Add(-config.After), now.Add(-config.MaxAge)).
+		Scan(&idle).Error
+	if err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for _, cart := range idle {
+		ownerScope := db.Where("guest_cart_id = ?", cart.GuestCartID)
+		if cart.UserID != nil {
+			ownerScope = db.Where("user_id = ?", *cart.UserID)
+		}
+
+		// Already recorded for this idle period
+		var existing int64
+		ownerScope.Session(&gorm.Session{}).Model(&models.AbandonedCart{}).
+			Where("last_activity_at >= ?", cart.LastActivity).Count(&existing)
+		if existing > 0 {
+			continue
+		}
+
+		var items []models.Cart
+		if err := ownerScope.Session(&gorm.Session{}).Preload("Product").Where("saved_for_later = ?", false).Find(&items).Error; err != nil {
+			return recorded, err
+		}
+
+		event := models.AbandonedCart{
+			UserID:         cart.UserID,
+			GuestCartID:    cart.GuestCartID,
+			Items:          models.QuoteLines{},
+			LastActivityAt: cart.LastActivity,
+			DetectedAt:     now,
+		}
+		for _, item := range items {
+			lineTotal := item.Product.Price * float64(item.Quantity)
+			event.Items = append(event.Items, models.QuoteLine{
+				ProductID:   item.ProductID,
+				ProductName: item.Product.Name,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.Product.Price,
+				LineTotal:   lineTotal,
+			})
+			event.ItemCount += item.Quantity
+			event.CartValue += lineTotal
+		}
+
+		var user models.User
+		if cart.UserID != nil && db.First(&user, *cart.UserID).Error == nil {
+			event.Email = user.Email
+		}
+
+		if err := db.Omit("User").Create(&event).Error; err != nil {
+			return recorded, err
+		}
+		recorded++
+
+		// Guests left no contact details, so only users get a reminder
+		if event.Email == "" {
+			continue
+		}
+		if err := sendAbandonedCartReminder(event, user, config); err != nil {
+			log.Printf("Failed to send abandoned cart reminder %d: %v", event.ID, err)
+			continue
+		}
+		db.Model(&event).Update("reminder_sent_at", time.Now())
+	}
+
+	return recorded, nil
+}
+
+func sendAbandonedCartReminder(event models.AbandonedCart, user models.User, config AbandonedCartConfig) error {
+	token, err := utils.GenerateRecoveryToken(event.ID, time.Now().Add(recoveryLinkTTL))
+	if err != nil {
+		return err
+	}
+
+	channel := config.Channel
+	if channel == "" {
+		channel = notifications.ChannelEmail
+	}
+
+	return notifications.Send(channel, notifications.Message{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Type:    "cart.abandoned",
+		Subject: "You left something in your cart",
+		Body: fmt.Sprintf("Hi %s, you still have %d item(s) worth %.2f in your cart. Pick up where you left off:",
+			user.FirstName, event.ItemCount, event.CartValue),
+		Link: os.Getenv("FRONTEND_URL") + "/cart/recover?token=" + token,
+		Data: map[string]interface{}{
+			"abandoned_cart_id": event.ID,
+			"items":             event.Items,
+		},
+	})
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"net/url"
+	"testing"
+	"time"
+	"ecommerce-backend/models"
+	"ecommerce-backend/notifications"
+	"ecommerce-backend/utils"
+)
+
+func TestEnvDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"":     time.Hour,
+		"soon": time.Hour,
+		"0s":   time.Hour,
+		"-5m":  time.Hour,
+	}
+	for value, want := range tests {
+		t.Setenv("ABANDONED_CART_TEST", value)
+		if got := envDuration("ABANDONED_CART_TEST", time.Hour); got != want {
+			t.Errorf("envDuration() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestAbandonedCartConfigFromEnv(t *testing.T) {
+	t.Setenv("ABANDONED_CART_AFTER", "2h")
+	t.Setenv("ABANDONED_CART_MAX_AGE", "")
+	t.Setenv("ABANDONED_CART_INTERVAL", "")
+	t.Setenv("ABANDONED_CART_CHANNEL", "webhook")
+
+	want := AbandonedCartConfig{After: 2 * time.Hour, MaxAge: 7 * 24 * time.Hour, Interval: 15 * time.Minute, Channel: "webhook"}
+	if got := AbandonedCartConfigFromEnv(); got != want {
+		t.Errorf("AbandonedCartConfigFromEnv() = %+v, want %+v", got, want)
+	}
+}
+
+type recordingChannel struct {
+	sent []notifications.Message
+}
+
+func (r *recordingChannel) Send(message notifications.Message) error {
+	r.sent = append(r.sent, message)
+	return nil
+}
+
+func TestSendAbandonedCartReminder(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("FRONTEND_URL", "https://shop.example")
+	channel := &recordingChannel{}
+	notifications.RegisterChannel("abandoned-cart-test", channel)
+
+	event := models.AbandonedCart{ID: 23, ItemCount: 3, CartValue: 59.97}
+	user := models.User{ID: 4, Email: "ada@example.com", FirstName: "Ada"}
+	if err := sendAbandonedCartReminder(event, user, AbandonedCartConfig{Channel: "abandoned-cart-test"}); err != nil {
+		t.Fatalf("sendAbandonedCartReminder() error = %v", err)
+	}
+
+	if len(channel.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(channel.sent))
+	}
+	message := channel.sent[0]
+	if message.UserID != 4 || message.Email != "ada@example.com" {
+		t.Errorf("message addressed to %d <%s>, want user 4", message.UserID, message.Email)
+	}
+
+	link, err := url.Parse(message.Link)
+	if err != nil {
+		t.Fatalf("link %q: %v", message.Link, err)
+	}
+	if link.Host != "shop.example" || link.Path != "/cart/recover" {
+		t.Errorf("link = %q, want the storefront recovery page", message.Link)
+	}
+	abandonedCartID, err := utils.ValidateRecoveryToken(link.Query().Get("token"))
+	if err != nil || abandonedCartID != 23 {
+		t.Errorf("link token resolves to %d, %v, want abandoned cart 23", abandonedCartID, err)
+	}
+
+	if err := sendAbandonedCartReminder(event, user, AbandonedCartConfig{Channel: "unregistered"}); err == nil {
+		t.Error("sendAbandonedCartReminder() through an unregistered channel returned no error")
+	}
+}
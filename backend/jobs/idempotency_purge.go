@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"log"
+	"time"
+	"ecommerce-backend/models"
+	"gorm.io/gorm"
+)
+
+// IdempotencyPurgeIntervalFromEnv reads how often expired idempotency
+// records are deleted.
+func IdempotencyPurgeIntervalFromEnv() time.Duration {
+	return envDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour)
+}
+
+// StartIdempotencyPurgeJob runs PurgeExpiredIdempotencyRecords in the
+// background every interval.
+func StartIdempotencyPurgeJob(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if count, err := PurgeExpiredIdempotencyRecords(db, time.Now()); err != nil {
+				log.Printf("Purging idempotency records failed: %v", err)
+			} else if count > 0 {
+				log.Printf("Purged %d expired idempotency records", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// PurgeExpiredIdempotencyRecords deletes the stored responses no longer
+// replayed. The middleware only removes an expired record when its key is
+// sent again, so without this most of them would be kept forever.
+func PurgeExpiredIdempotencyRecords(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Where("expires_at < ?", now).Delete(&models.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyPurgeIntervalFromEnv(t *testing.T) {
+	t.Setenv("IDEMPOTENCY_PURGE_INTERVAL", "")
+	if got := IdempotencyPurgeIntervalFromEnv(); got != time.Hour {
+		t.Errorf("default interval = %v, want 1h", got)
+	}
+	t.Setenv("IDEMPOTENCY_PURGE_INTERVAL", "10m")
+	if got := IdempotencyPurgeIntervalFromEnv(); got != 10*time.Minute {
+		t.Errorf("configured interval = %v, want 10m", got)
+	}
+}
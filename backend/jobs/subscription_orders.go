@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// SubscriptionOrderIntervalFromEnv reads how often due subscription orders
+// are placed.
+func SubscriptionOrderIntervalFromEnv() time.Duration {
+	return envDuration("SUBSCRIPTION_ORDER_INTERVAL", 15*time.Minute)
+}
+
+// StartSubscriptionOrderJob runs placeDue in the background every interval.
+// Order placement lives with the checkout code, so it is passed in.
+func StartSubscriptionOrderJob(interval time.Duration, placeDue func(now time.Time) (int, error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if count, err := placeDue(time.Now()); err != nil {
+				log.Printf("Placing subscription orders failed: %v", err)
+			} else if count > 0 {
+				log.Printf("Placed %d subscription orders", count)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscriptionOrderIntervalFromEnv(t *testing.T) {
+	t.Setenv("SUBSCRIPTION_ORDER_INTERVAL", "")
+	if got := SubscriptionOrderIntervalFromEnv(); got != 15*time.Minute {
+		t.Errorf("default interval = %v, want 15m", got)
+	}
+	t.Setenv("SUBSCRIPTION_ORDER_INTERVAL", "1h")
+	if got := SubscriptionOrderIntervalFromEnv(); got != time.Hour {
+		t.Errorf("configured interval = %v, want 1h", got)
+	}
+}
+
+func TestStartSubscriptionOrderJobRunsImmediately(t *testing.T) {
+	ran := make(chan time.Time, 1)
+	StartSubscriptionOrderJob(time.Hour, func(now time.Time) (int, error) {
+		select {
+		case ran <- now:
+		default:
+		}
+		return 0, nil
+	})
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("due subscription orders were not placed on start")
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"log"
 	"os"
 	"ecommerce-backend/config"
+	"ecommerce-backend/controllers"
 	"ecommerce-backend/database"
+	"ecommerce-backend/jobs"
+	"ecommerce-backend/notifications"
 	"ecommerce-backend/routes"
 )
 
@@ -15,6 +18,14 @@ func main() {
 	// Connect to database
 	database.ConnectDB()
 
+	// Start delivering stock and price alerts
+	notifications.Start(database.DB)
+
+	// Start background jobs
+	jobs.StartAbandonedCartJob(database.DB, jobs.AbandonedCartConfigFromEnv())
+	jobs.StartSubscriptionOrderJob(jobs.SubscriptionOrderIntervalFromEnv(), controllers.PlaceDueSubscriptionOrders)
+	jobs.StartIdempotencyPurgeJob(database.DB, jobs.IdempotencyPurgeIntervalFromEnv())
+
 	// Setup routes
 	r := routes.SetupRoutes()
 
@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	token, err := utils.GenerateJWT(7, "ada@example.com", "customer")
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantUserID interface{}
+	}{
+		{"anonymous", "", http.StatusOK, nil},
+		{"logged in", "Bearer " + token, http.StatusOK, uint(7)},
+		{"invalid token", "Bearer nope", http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID interface{}
+			router := gin.New()
+			router.GET("/cart", OptionalAuthMiddleware(), func(c *gin.Context) {
+				userID, _ = c.Get("user_id")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/cart", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if userID != tt.wantUserID {
+				t.Errorf("user_id = %v, want %v", userID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRejectsCartTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	cartToken, err := utils.GenerateCartToken(7, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+	loginToken, err := utils.GenerateJWT(7, "ada@example.com", "customer")
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		middleware gin.HandlerFunc
+		token      string
+		wantStatus int
+	}{
+		{"login token", AuthMiddleware(), loginToken, http.StatusOK},
+		{"cart token", AuthMiddleware(), cartToken, http.StatusUnauthorized},
+		{"cart token on optional auth", OptionalAuthMiddleware(), cartToken, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/orders", tt.middleware, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
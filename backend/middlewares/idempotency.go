@@ -0,0 +1,164 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"ecommerce-backend/database"
+	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyTTL is how long a stored response is replayed for its key.
+const idempotencyTTL = 24 * time.Hour
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed: a retried request needs the guest cart token or
+// the location of what the first request created as much as the body.
+var replayedHeaders = []string{"Location", "X-Cart-Token"}
+
+// responseRecorder keeps a copy of everything written to the response.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes unsafe endpoints safe to retry. Requests that
+// carry an Idempotency-Key header are fingerprinted and their response is
+// stored; a repeat of the same request replays the stored response, while
+// reusing the key for a different request is rejected. Server errors are not
+// stored so the request can be retried. It must run after authentication so
+// keys are scoped to the caller.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(c)
+		path := c.Request.URL.Path
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		var record models.IdempotencyRecord
+		err = database.DB.Where("scope = ? AND `key` = ?", scope, key).First(&record).Error
+		if err == nil && record.ExpiresAt.Before(time.Now()) {
+			database.DB.Delete(&record)
+			err = fmt.Errorf("expired")
+		}
+		if err == nil {
+			replayIdempotentResponse(c, record, fingerprint)
+			return
+		}
+
+		record = models.IdempotencyRecord{
+			Scope:       scope,
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        path,
+			Fingerprint: fingerprint,
+			State:       models.IdempotencyStateProcessing,
+			ExpiresAt:   time.Now().Add(idempotencyTTL),
+		}
+		// A concurrent request with the same key won the insert
+		if err := database.DB.Create(&record).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already being processed"})
+			c.Abort()
+			return
+		}
+
+		// A panicking handler leaves no response to store; free the key so the
+		// request can be retried instead of blocking it until it expires
+		defer func() {
+			if r := recover(); r != nil {
+				database.DB.Delete(&record)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			database.DB.Delete(&record)
+			return
+		}
+
+		headers := models.ResponseHeaders{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		database.DB.Model(&record).Updates(map[string]interface{}{
+			"state":            models.IdempotencyStateCompleted,
+			"status_code":      status,
+			"content_type":     recorder.Header().Get("Content-Type"),
+			"response_headers": headers,
+			"response_body":    recorder.body.String(),
+		})
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, record models.IdempotencyRecord, fingerprint string) {
+	defer c.Abort()
+
+	if record.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+	if record.State != models.IdempotencyStateCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already being processed"})
+		return
+	}
+
+	for name, value := range record.ResponseHeaders {
+		c.Header(name, value)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.StatusCode, record.ContentType, []byte(record.ResponseBody))
+}
+
+// idempotencyScope identifies the caller: the user when authenticated, else
+// the guest cart token, else the client address.
+func idempotencyScope(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	if token := c.GetHeader("X-Cart-Token"); token != "" {
+		return "cart:" + utils.HashToken(token)[:32]
+	}
+	return "ip:" + c.ClientIP()
+}
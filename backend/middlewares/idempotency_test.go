@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"ecommerce-backend/models"
+	"ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext(req *http.Request) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	return c, w
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	req.RemoteAddr = "203.0.113.9:51234"
+	c, _ := newTestContext(req)
+	if got := idempotencyScope(c); got != "ip:203.0.113.9" {
+		t.Errorf("anonymous scope = %q, want the client address", got)
+	}
+
+	req.Header.Set("X-Cart-Token", "cart-token")
+	if got, want := idempotencyScope(c), "cart:"+utils.HashToken("cart-token")[:32]; got != want {
+		t.Errorf("guest scope = %q, want %q", got, want)
+	}
+
+	c.Set("user_id", uint(7))
+	if got := idempotencyScope(c); got != "user:7" {
+		t.Errorf("user scope = %q, want \"user:7\"", got)
+	}
+}
+
+func TestReplayIdempotentResponse(t *testing.T) {
+	completed := models.IdempotencyRecord{
+		Fingerprint:     "abc",
+		State:           models.IdempotencyStateCompleted,
+		StatusCode:      http.StatusCreated,
+		ContentType:     "application/json; charset=utf-8",
+		ResponseHeaders: models.ResponseHeaders{"X-Cart-Token": "cart-token"},
+		ResponseBody:    `{"order":{"id":1}}`,
+	}
+	processing := completed
+	processing.State = models.IdempotencyStateProcessing
+
+	tests := []struct {
+		name        string
+		record      models.IdempotencyRecord
+		fingerprint string
+		wantStatus  int
+		wantReplay  bool
+	}{
+		{"replays stored response", completed, "abc", http.StatusCreated, true},
+		{"different request", completed, "def", http.StatusUnprocessableEntity, false},
+		{"still processing", processing, "abc", http.StatusConflict, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestContext(httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil))
+			replayIdempotentResponse(c, tt.record, tt.fingerprint)
+
+			if !c.IsAborted() {
+				t.Error("handler chain was not aborted")
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			replayed := w.Header().Get("Idempotent-Replayed") == "true"
+			if replayed != tt.wantReplay {
+				t.Errorf("Idempotent-Replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if tt.wantReplay && w.Body.String() != tt.record.ResponseBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.record.ResponseBody)
+			}
+			if token := w.Header().Get("X-Cart-Token"); tt.wantReplay != (token == "cart-token") {
+				t.Errorf("X-Cart-Token = %q, want it sent only with the replayed response", token)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareWithoutStoredKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handled := false
+	router := gin.New()
+	router.POST("/orders", IdempotencyMiddleware(), func(c *gin.Context) {
+		handled = true
+		c.Status(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{}")))
+	if !handled || w.Code != http.StatusCreated {
+		t.Errorf("request without a key: handled = %v, status = %d, want it passed through", handled, w.Code)
+	}
+
+	handled = false
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{}"))
+	req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if handled || w.Code != http.StatusBadRequest {
+		t.Errorf("overlong key: handled = %v, status = %d, want it rejected", handled, w.Code)
+	}
+}
+
+func TestResponseRecorderKeepsBody(t *testing.T) {
+	c, w := newTestContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	recorder.Write([]byte("hello "))
+	recorder.WriteString("world")
+
+	if recorder.body.String() != "hello world" || w.Body.String() != "hello world" {
+		t.Errorf("recorded %q and wrote %q, want both \"hello world\"", recorder.body.String(), w.Body.String())
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// AbandonedCart records one idle period of a cart. A new record is created
+// each time a cart goes idle again after further activity. Items snapshots
+// the cart so the reminder link can restore it even if it was emptied since.
+type AbandonedCart struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         *uint      `json:"user_id" gorm:"index"`
+	GuestCartID    *uint      `json:"guest_cart_id" gorm:"index"`
+	Email          string     `json:"email" gorm:"type:varchar(255)"`
+	Items          QuoteLines `json:"items" gorm:"type:text"`
+	ItemCount      int        `json:"item_count"`
+	CartValue      float64    `json:"cart_value" gorm:"type:decimal(10,2)"`
+	LastActivityAt time.Time  `json:"last_activity_at" gorm:"index"`
+	DetectedAt     time.Time  `json:"detected_at" gorm:"index"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at"`
+	RestoredAt     *time.Time `json:"restored_at"`
+	RecoveredAt    *time.Time `json:"recovered_at" gorm:"index"`
+	OrderID        *uint      `json:"order_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
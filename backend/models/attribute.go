@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+	"gorm.io/gorm"
+)
+
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeEnum    = "enum"
+	AttributeTypeBoolean = "boolean"
+)
+
+// StringList is stored as a JSON array in a text column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for StringList")
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+type AttributeDefinition struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CategoryID uint           `json:"category_id" gorm:"not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(100);not null"`
+	Code       string         `json:"code" gorm:"type:varchar(100);not null;index"`
+	Type       string         `json:"type" gorm:"type:varchar(20);not null"`
+	Options    StringList     `json:"options" gorm:"type:text"`
+	Unit       string         `json:"unit" gorm:"type:varchar(20)"`
+	Required   bool           `json:"required" gorm:"default:false"`
+	Filterable bool           `json:"filterable" gorm:"default:true"`
+	SortOrder  int            `json:"sort_order" gorm:"default:0"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Category Category `json:"-" gorm:"foreignKey:CategoryID"`
+}
+
+type ProductAttributeValue struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProductID   uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_product_attribute"`
+	AttributeID uint      `json:"attribute_id" gorm:"not null;uniqueIndex:idx_product_attribute"`
+	ValueText   string    `json:"value_text" gorm:"type:varchar(255);index"`
+	ValueNumber *float64  `json:"value_number" gorm:"index"`
+	ValueBool   *bool     `json:"value_bool"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Attribute AttributeDefinition `json:"-" gorm:"foreignKey:AttributeID"`
+}
+
+type ProductAttributeResponse struct {
+	AttributeID uint        `json:"attribute_id"`
+	Code        string      `json:"code"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Unit        string      `json:"unit,omitempty"`
+	Value       interface{} `json:"value"`
+}
+
+func IsValidAttributeType(attributeType string) bool {
+	switch attributeType {
+	case AttributeTypeText, AttributeTypeNumber, AttributeTypeEnum, AttributeTypeBoolean:
+		return true
+	}
+	return false
+}
+
+// TypedValue returns the stored value in the representation matching the
+// attribute type.
+func (v *ProductAttributeValue) TypedValue() interface{} {
+	switch v.Attribute.Type {
+	case AttributeTypeNumber:
+		if v.ValueNumber != nil {
+			return *v.ValueNumber
+		}
+		return nil
+	case AttributeTypeBoolean:
+		if v.ValueBool != nil {
+			return *v.ValueBool
+		}
+		return nil
+	default:
+		return v.ValueText
+	}
+}
+
+func (v *ProductAttributeValue) ToResponse() ProductAttributeResponse {
+	return ProductAttributeResponse{
+		AttributeID: v.AttributeID,
+		Code:        v.Attribute.Code,
+		Name:        v.Attribute.Name,
+		Type:        v.Attribute.Type,
+		Unit:        v.Attribute.Unit,
+		Value:       v.TypedValue(),
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringListValue(t *testing.T) {
+	tests := []struct {
+		name string
+		list StringList
+		want string
+	}{
+		{"nil", nil, "[]"},
+		{"empty", StringList{}, "[]"},
+		{"values", StringList{"red", "blue"}, `["red","blue"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.list.Value()
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Value() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringListScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    StringList
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"empty bytes", []byte{}, nil, false},
+		{"bytes", []byte(`["S","M"]`), StringList{"S", "M"}, false},
+		{"string", `["XL"]`, StringList{"XL"}, false},
+		{"invalid json", "not json", nil, true},
+		{"unsupported type", 42, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StringList
+			err := got.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidAttributeType(t *testing.T) {
+	for _, attributeType := range []string{AttributeTypeText, AttributeTypeNumber, AttributeTypeEnum, AttributeTypeBoolean} {
+		if !IsValidAttributeType(attributeType) {
+			t.Errorf("IsValidAttributeType(%q) = false, want true", attributeType)
+		}
+	}
+	for _, attributeType := range []string{"", "date", "Number"} {
+		if IsValidAttributeType(attributeType) {
+			t.Errorf("IsValidAttributeType(%q) = true, want false", attributeType)
+		}
+	}
+}
+
+func TestProductAttributeValueTypedValue(t *testing.T) {
+	number := 12.5
+	flag := false
+
+	tests := []struct {
+		name  string
+		value ProductAttributeValue
+		want  interface{}
+	}{
+		{"number", ProductAttributeValue{ValueNumber: &number, Attribute: AttributeDefinition{Type: AttributeTypeNumber}}, 12.5},
+		{"missing number", ProductAttributeValue{Attribute: AttributeDefinition{Type: AttributeTypeNumber}}, nil},
+		{"boolean", ProductAttributeValue{ValueBool: &flag, Attribute: AttributeDefinition{Type: AttributeTypeBoolean}}, false},
+		{"missing boolean", ProductAttributeValue{Attribute: AttributeDefinition{Type: AttributeTypeBoolean}}, nil},
+		{"enum", ProductAttributeValue{ValueText: "red", Attribute: AttributeDefinition{Type: AttributeTypeEnum}}, "red"},
+		{"text", ProductAttributeValue{ValueText: "cotton", Attribute: AttributeDefinition{Type: AttributeTypeText}}, "cotton"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.value.TypedValue(); got != tt.want {
+				t.Errorf("TypedValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,43 +1,161 @@
 package models
 
 import (
+	"fmt"
 	"time"
 	"gorm.io/gorm"
 )
 
+// Cart rows belong either to a user or, for anonymous visitors, to a guest
+// cart identified by a signed cart token. Lines saved for later are kept out
+// of totals and checkout; Note holds gift or personalization text that is
+// copied to the order item.
 type Cart struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    uint           `json:"user_id" gorm:"not null"`
-	ProductID uint           `json:"product_id" gorm:"not null"`
-	Quantity  int            `json:"quantity" gorm:"not null;default:1"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-	
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	UserID        *uint          `json:"user_id" gorm:"index"`
+	GuestCartID   *uint          `json:"guest_cart_id,omitempty" gorm:"index"`
+	ProductID     uint           `json:"product_id" gorm:"not null"`
+	Quantity      int            `json:"quantity" gorm:"not null;default:1"`
+	PriceAtAdd    float64        `json:"price_at_add" gorm:"type:decimal(10,2)"`
+	SavedForLater bool           `json:"saved_for_later" gorm:"default:false;index"`
+	Note          string         `json:"note" gorm:"type:varchar(500)"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
 	// Relations
-	User    User    `json:"user" gorm:"foreignKey:UserID"`
+	User    *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Product Product `json:"product" gorm:"foreignKey:ProductID"`
 }
 
-type CartResponse struct {     
-	ID        uint            `json:"id"`
-	UserID    uint            `json:"user_id"`
-	ProductID uint            `json:"product_id"`
-	Quantity  int             `json:"quantity"`
-	Product   ProductResponse `json:"product"`
-	CreatedAt time.Time       `json:"created_at"`
-	Subtotal  float64         `json:"subtotal"`
+// GuestCart owns the cart rows of a visitor who has not logged in. It is
+// merged into the user's cart and deleted on login or registration.
+type GuestCart struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	CartNoticeProductUnavailable = "product_unavailable"
+	CartNoticeQuantityAdjusted   = "quantity_adjusted"
+)
+
+// CartNotice tells a customer about a change made to their cart on their
+// behalf, such as an item removed because the product was archived.
+type CartNotice struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      *uint      `json:"user_id" gorm:"index"`
+	GuestCartID *uint      `json:"guest_cart_id,omitempty" gorm:"index"`
+	ProductID   uint       `json:"product_id" gorm:"not null"`
+	ProductName string     `json:"product_name" gorm:"type:varchar(255)"`
+	Type        string     `json:"type" gorm:"type:varchar(50);not null"`
+	Message     string     `json:"message" gorm:"type:varchar(500);not null"`
+	DismissedAt *time.Time `json:"dismissed_at" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CartResponse struct {
+	ID            uint            `json:"id"`
+	UserID        *uint           `json:"user_id"`
+	ProductID     uint            `json:"product_id"`
+	Quantity      int             `json:"quantity"`
+	PriceAtAdd    float64         `json:"price_at_add"`
+	SavedForLater bool            `json:"saved_for_later"`
+	Note          string          `json:"note"`
+	Product       ProductResponse `json:"product"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Subtotal      float64         `json:"subtotal"`
+}
+
+const (
+	CartWarningPriceIncreased = "price_increased"
+	CartWarningPriceDecreased = "price_decreased"
+	CartWarningStockReduced   = "stock_reduced"
+	CartWarningOutOfStock     = "out_of_stock"
+	CartWarningUnavailable    = "unavailable"
+)
+
+// CartWarning describes how a cart line no longer matches the catalog.
+// Adjusted is set when the line was corrected on the customer's behalf.
+type CartWarning struct {
+	CartItemID        uint    `json:"cart_item_id"`
+	ProductID         uint    `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	Type              string  `json:"type"`
+	Message           string  `json:"message"`
+	PreviousPrice     float64 `json:"previous_price,omitempty"`
+	CurrentPrice      float64 `json:"current_price,omitempty"`
+	RequestedQuantity int     `json:"requested_quantity,omitempty"`
+	AvailableQuantity int     `json:"available_quantity"`
+	Adjusted          bool    `json:"adjusted"`
+}
+
+// Blocking reports whether the warning prevents the line from being ordered.
+func (w CartWarning) Blocking() bool {
+	return w.Type == CartWarningStockReduced || w.Type == CartWarningOutOfStock || w.Type == CartWarningUnavailable
+}
+
+// Warnings compares the line against its product as loaded. Products that
+// were deleted are not preloaded, which leaves Product.ID at zero.
+func (c *Cart) Warnings(now time.Time) []CartWarning {
+	warning := CartWarning{
+		CartItemID:        c.ID,
+		ProductID:         c.ProductID,
+		ProductName:       c.Product.Name,
+		RequestedQuantity: c.Quantity,
+		AvailableQuantity: c.Product.Stock,
+	}
+
+	if c.Product.ID == 0 || !c.Product.IsVisibleAt(now) {
+		warning.Type = CartWarningUnavailable
+		warning.AvailableQuantity = 0
+		warning.Message = "This product is no longer available"
+		return []CartWarning{warning}
+	}
+
+	var warnings []CartWarning
+	if c.PriceAtAdd > 0 && c.Product.Price != c.PriceAtAdd {
+		priceWarning := warning
+		priceWarning.PreviousPrice = c.PriceAtAdd
+		priceWarning.CurrentPrice = c.Product.Price
+		if c.Product.Price > c.PriceAtAdd {
+			priceWarning.Type = CartWarningPriceIncreased
+			priceWarning.Message = fmt.Sprintf("The price went up from %.2f to %.2f", c.PriceAtAdd, c.Product.Price)
+		} else {
+			priceWarning.Type = CartWarningPriceDecreased
+			priceWarning.Message = fmt.Sprintf("The price went down from %.2f to %.2f", c.PriceAtAdd, c.Product.Price)
+		}
+		warnings = append(warnings, priceWarning)
+	}
+
+	if c.Product.Stock <= 0 {
+		warning.Type = CartWarningOutOfStock
+		warning.AvailableQuantity = 0
+		warning.Message = "This product is out of stock"
+		warnings = append(warnings, warning)
+	} else if c.Product.Stock < c.Quantity {
+		warning.Type = CartWarningStockReduced
+		warning.Message = fmt.Sprintf("Only %d left in stock", c.Product.Stock)
+		warnings = append(warnings, warning)
+	}
+
+	return warnings
 }
 
 func (c *Cart) ToResponse() CartResponse {
 	subtotal := c.Product.Price * float64(c.Quantity)
 	return CartResponse{
-		ID:        c.ID,
-		UserID:    c.UserID,
-		ProductID: c.ProductID,
-		Quantity:  c.Quantity,
-		Product:   c.Product.ToResponse(),
-		CreatedAt: c.CreatedAt,
-		Subtotal:  subtotal,
+		ID:            c.ID,
+		UserID:        c.UserID,
+		ProductID:     c.ProductID,
+		Quantity:      c.Quantity,
+		PriceAtAdd:    c.PriceAtAdd,
+		SavedForLater: c.SavedForLater,
+		Note:          c.Note,
+		Product:       c.Product.ToResponse(),
+		CreatedAt:     c.CreatedAt,
+		Subtotal:      subtotal,
 	}
 }
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCartWarnings(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	product := func(price float64, stock int) Product {
+		return Product{ID: 9, Name: "Lamp", Price: price, Stock: stock, Status: ProductStatusActive}
+	}
+
+	tests := []struct {
+		name          string
+		item          Cart
+		want          []string
+		wantAvailable int
+	}{
+		{"matches catalog", Cart{Quantity: 2, PriceAtAdd: 10, Product: product(10, 5)}, nil, 0},
+		{"no snapshot price", Cart{Quantity: 2, Product: product(10, 5)}, nil, 0},
+		{"price increased", Cart{Quantity: 2, PriceAtAdd: 10, Product: product(12, 5)}, []string{CartWarningPriceIncreased}, 5},
+		{"price decreased", Cart{Quantity: 2, PriceAtAdd: 10, Product: product(8, 5)}, []string{CartWarningPriceDecreased}, 5},
+		{"stock reduced", Cart{Quantity: 4, PriceAtAdd: 10, Product: product(10, 3)}, []string{CartWarningStockReduced}, 3},
+		{"out of stock", Cart{Quantity: 1, PriceAtAdd: 10, Product: product(10, 0)}, []string{CartWarningOutOfStock}, 0},
+		{"cheaper and short", Cart{Quantity: 4, PriceAtAdd: 10, Product: product(8, 1)}, []string{CartWarningPriceDecreased, CartWarningStockReduced}, 1},
+		{"deleted product", Cart{ProductID: 9, Quantity: 1, PriceAtAdd: 10}, []string{CartWarningUnavailable}, 0},
+		{"archived product", Cart{Quantity: 1, PriceAtAdd: 10, Product: Product{ID: 9, Price: 12, Stock: 5, Status: ProductStatusArchived}}, []string{CartWarningUnavailable}, 0},
+		{"scheduled product", Cart{Quantity: 1, Product: Product{ID: 9, Stock: 5, Status: ProductStatusActive, PublishAt: &later}}, []string{CartWarningUnavailable}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := tt.item.Warnings(now)
+			var got []string
+			for _, warning := range warnings {
+				got = append(got, warning.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("warning types = %v, want %v", got, tt.want)
+			}
+			if len(warnings) > 0 {
+				last := warnings[len(warnings)-1]
+				if last.AvailableQuantity != tt.wantAvailable {
+					t.Errorf("AvailableQuantity = %d, want %d", last.AvailableQuantity, tt.wantAvailable)
+				}
+				if last.Message == "" {
+					t.Error("warning has no message")
+				}
+			}
+		})
+	}
+}
+
+func TestCartWarningBlocking(t *testing.T) {
+	tests := map[string]bool{
+		CartWarningPriceIncreased: false,
+		CartWarningPriceDecreased: false,
+		CartWarningStockReduced:   true,
+		CartWarningOutOfStock:     true,
+		CartWarningUnavailable:    true,
+	}
+	for warningType, want := range tests {
+		if got := (CartWarning{Type: warningType}).Blocking(); got != want {
+			t.Errorf("Blocking() for %s = %v, want %v", warningType, got, want)
+		}
+	}
+}
+
+func TestCartToResponse(t *testing.T) {
+	item := Cart{ID: 4, Quantity: 3, PriceAtAdd: 9, SavedForLater: true, Note: "Happy birthday!", Product: Product{Price: 10}}
+	response := item.ToResponse()
+	if !response.SavedForLater || response.Note != "Happy birthday!" {
+		t.Errorf("response = %+v, want the saved flag and note", response)
+	}
+	if response.Subtotal != 30 {
+		t.Errorf("Subtotal = %v, want 30 at the current price", response.Subtotal)
+	}
+}
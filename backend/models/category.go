@@ -1,19 +1,133 @@
 package models
 
 import (
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 	"gorm.io/gorm"
 )
 
 type Category struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Name        string         `json:"name" gorm:"type:varchar(100);not null;index"`
+	Slug        string         `json:"slug" gorm:"type:varchar(150);index"`
 	Description string         `json:"description" gorm:"type:text"`
 	Image       string         `json:"image" gorm:"type:varchar(255)"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"`
+	Path        string         `json:"-" gorm:"type:varchar(255);index"`
+	Depth       int            `json:"depth" gorm:"default:0"`
+	SortOrder   int            `json:"sort_order" gorm:"default:0"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	
+
 	// Relations
-	Products []Product `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
+	Parent   *Category  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	Products []Product  `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+type CategoryTreeNode struct {
+	ID          uint               `json:"id"`
+	Name        string             `json:"name"`
+	Slug        string             `json:"slug"`
+	Description string             `json:"description"`
+	Image       string             `json:"image"`
+	ParentID    *uint              `json:"parent_id"`
+	Depth       int                `json:"depth"`
+	SortOrder   int                `json:"sort_order"`
+	Children    []CategoryTreeNode `json:"children"`
+}
+
+type Breadcrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// BuildPath returns the materialized path for a category placed under parent.
+// Paths look like "/1/4/9/" so descendants can be matched with a LIKE prefix.
+func (c *Category) BuildPath(parent *Category) string {
+	if parent == nil {
+		return "/" + strconv.FormatUint(uint64(c.ID), 10) + "/"
+	}
+	return parent.Path + strconv.FormatUint(uint64(c.ID), 10) + "/"
+}
+
+// LineageIDs returns the IDs on the category's path from the root down to and
+// including the category itself.
+func (c *Category) LineageIDs() []uint {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(c.Path, "/"), "/") {
+		if id, err := strconv.ParseUint(part, 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) == 0 {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// BuildCategoryTree arranges a flat list of categories into nested nodes,
+// ordered by sort_order and then name at every level.
+func BuildCategoryTree(categories []Category) []CategoryTreeNode {
+	byParent := make(map[uint][]Category)
+	for _, category := range categories {
+		var parentID uint
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		byParent[parentID] = append(byParent[parentID], category)
+	}
+
+	var build func(parentID uint) []CategoryTreeNode
+	build = func(parentID uint) []CategoryTreeNode {
+		children := byParent[parentID]
+		sort.SliceStable(children, func(i, j int) bool {
+			if children[i].SortOrder != children[j].SortOrder {
+				return children[i].SortOrder < children[j].SortOrder
+			}
+			return children[i].Name < children[j].Name
+		})
+
+		nodes := make([]CategoryTreeNode, 0, len(children))
+		for _, child := range children {
+			nodes = append(nodes, CategoryTreeNode{
+				ID:          child.ID,
+				Name:        child.Name,
+				Slug:        child.Slug,
+				Description: child.Description,
+				Image:       child.Image,
+				ParentID:    child.ParentID,
+				Depth:       child.Depth,
+				SortOrder:   child.SortOrder,
+				Children:    build(child.ID),
+			})
+		}
+		return nodes
+	}
+
+	return build(0)
+}
+
+// BuildBreadcrumbs walks from categoryID up to the root using the given
+// lookup and returns the trail ordered from root to leaf.
+func BuildBreadcrumbs(categoryID uint, lookup map[uint]Category) []Breadcrumb {
+	var trail []Breadcrumb
+	seen := make(map[uint]bool)
+	for id := categoryID; id != 0 && !seen[id]; {
+		category, ok := lookup[id]
+		if !ok {
+			break
+		}
+		seen[id] = true
+		trail = append([]Breadcrumb{{ID: category.ID, Name: category.Name, Slug: category.Slug}}, trail...)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return trail
 }
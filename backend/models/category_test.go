@@ -0,0 +1,130 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestCategoryBuildPath(t *testing.T) {
+	root := Category{ID: 1, Path: "/1/"}
+	child := Category{ID: 4, Path: "/1/4/"}
+
+	tests := []struct {
+		name     string
+		category Category
+		parent   *Category
+		want     string
+	}{
+		{"root", Category{ID: 1}, nil, "/1/"},
+		{"child of root", Category{ID: 4}, &root, "/1/4/"},
+		{"grandchild", Category{ID: 9}, &child, "/1/4/9/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.category.BuildPath(tt.parent); got != tt.want {
+				t.Errorf("BuildPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	categories := []Category{
+		{ID: 1, Name: "Garden", SortOrder: 2},
+		{ID: 2, Name: "Kitchen", SortOrder: 1},
+		{ID: 3, Name: "Tools", ParentID: uintPtr(1), SortOrder: 0},
+		{ID: 4, Name: "Plants", ParentID: uintPtr(1), SortOrder: 0},
+		{ID: 5, Name: "Seeds", ParentID: uintPtr(4), SortOrder: 0},
+		{ID: 6, Name: "Appliances", SortOrder: 1},
+	}
+
+	tree := BuildCategoryTree(categories)
+
+	var names func(nodes []CategoryTreeNode) []string
+	names = func(nodes []CategoryTreeNode) []string {
+		out := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			out = append(out, node.Name)
+		}
+		return out
+	}
+
+	if got, want := names(tree), []string{"Appliances", "Kitchen", "Garden"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("roots = %v, want %v", got, want)
+	}
+	garden := tree[2]
+	if got, want := names(garden.Children), []string{"Plants", "Tools"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("garden children = %v, want %v", got, want)
+	}
+	if got, want := names(garden.Children[0].Children), []string{"Seeds"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("plants children = %v, want %v", got, want)
+	}
+	if tree[0].Children == nil || len(tree[0].Children) != 0 {
+		t.Errorf("leaf children = %#v, want an empty list", tree[0].Children)
+	}
+	if got := BuildCategoryTree(nil); got == nil || len(got) != 0 {
+		t.Errorf("BuildCategoryTree(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestBuildBreadcrumbs(t *testing.T) {
+	lookup := map[uint]Category{
+		1:  {ID: 1, Name: "Garden", Slug: "garden"},
+		4:  {ID: 4, Name: "Plants", Slug: "plants", ParentID: uintPtr(1)},
+		9:  {ID: 9, Name: "Seeds", Slug: "seeds", ParentID: uintPtr(4)},
+		7:  {ID: 7, Name: "Orphan", Slug: "orphan", ParentID: uintPtr(99)},
+		20: {ID: 20, Name: "Loop A", Slug: "loop-a", ParentID: uintPtr(21)},
+		21: {ID: 21, Name: "Loop B", Slug: "loop-b", ParentID: uintPtr(20)},
+	}
+
+	tests := []struct {
+		name       string
+		categoryID uint
+		want       []uint
+	}{
+		{"root", 1, []uint{1}},
+		{"leaf", 9, []uint{1, 4, 9}},
+		{"missing parent", 7, []uint{7}},
+		{"cycle", 20, []uint{21, 20}},
+		{"unknown category", 42, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint
+			for _, crumb := range BuildBreadcrumbs(tt.categoryID, lookup) {
+				got = append(got, crumb.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildBreadcrumbs(%d) = %v, want %v", tt.categoryID, got, tt.want)
+			}
+		})
+	}
+
+	trail := BuildBreadcrumbs(9, lookup)
+	if trail[1].Name != "Plants" || trail[1].Slug != "plants" {
+		t.Errorf("breadcrumb = %+v, want Plants/plants", trail[1])
+	}
+}
+
+func TestCategoryLineageIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		category Category
+		want     []uint
+	}{
+		{"root", Category{ID: 1, Path: "/1/"}, []uint{1}},
+		{"nested", Category{ID: 9, Path: "/1/4/9/"}, []uint{1, 4, 9}},
+		{"no path yet", Category{ID: 5}, []uint{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.category.LineageIDs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LineageIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
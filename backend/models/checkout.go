@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// QuoteLine is a priced cart line in a checkout breakdown.
+type QuoteLine struct {
+	ProductID   uint    `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	LineTotal   float64 `json:"line_total"`
+}
+
+// QuoteLines is stored as a JSON array in a text column.
+type QuoteLines []QuoteLine
+
+func (l QuoteLines) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]QuoteLine(l))
+	return string(data), err
+}
+
+func (l *QuoteLines) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for QuoteLines")
+	}
+	return json.Unmarshal(data, l)
+}
+
+// CheckoutBreakdown is the itemized price of a cart at checkout. Quotes and
+// orders are both computed from it.
+type CheckoutBreakdown struct {
+	Lines          QuoteLines `json:"lines"`
+	Subtotal       float64    `json:"subtotal"`
+	Discount       float64    `json:"discount"`
+	Shipping       float64    `json:"shipping"`
+	TaxRate        float64    `json:"tax_rate"`
+	Tax            float64    `json:"tax"`
+	Total          float64    `json:"total"`
+	ShippingMethod string     `json:"shipping_method"`
+	CouponCode     string     `json:"coupon_code,omitempty"`
+}
+
+// CheckoutQuote locks a breakdown for a short time so the order created from
+// it is charged exactly what was quoted. CartFingerprint ties the quote to
+// the cart contents it was computed from.
+type CheckoutQuote struct {
+	ID              uint       `json:"-" gorm:"primaryKey"`
+	Reference       string     `json:"id" gorm:"type:varchar(64);uniqueIndex;not null"`
+	UserID          *uint      `json:"-" gorm:"index"`
+	GuestCartID     *uint      `json:"-" gorm:"index"`
+	CartFingerprint string     `json:"-" gorm:"type:varchar(64);not null"`
+	ShippingAddress string     `json:"shipping_address" gorm:"type:text"`
+	ShippingMethod  string     `json:"shipping_method" gorm:"type:varchar(30)"`
+	CouponCode      string     `json:"coupon_code" gorm:"type:varchar(50)"`
+	Lines           QuoteLines `json:"lines" gorm:"type:text"`
+	Subtotal        float64    `json:"subtotal" gorm:"type:decimal(10,2)"`
+	Discount        float64    `json:"discount" gorm:"type:decimal(10,2)"`
+	Shipping        float64    `json:"shipping" gorm:"type:decimal(10,2)"`
+	TaxRate         float64    `json:"tax_rate" gorm:"type:decimal(6,4)"`
+	Tax             float64    `json:"tax" gorm:"type:decimal(10,2)"`
+	Total           float64    `json:"total" gorm:"type:decimal(10,2)"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	UsedAt          *time.Time `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func (q *CheckoutQuote) Breakdown() CheckoutBreakdown {
+	return CheckoutBreakdown{
+		Lines:          q.Lines,
+		Subtotal:       q.Subtotal,
+		Discount:       q.Discount,
+		Shipping:       q.Shipping,
+		TaxRate:        q.TaxRate,
+		Tax:            q.Tax,
+		Total:          q.Total,
+		ShippingMethod: q.ShippingMethod,
+		CouponCode:     q.CouponCode,
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuoteLinesRoundTrip(t *testing.T) {
+	lines := QuoteLines{
+		{ProductID: 1, ProductName: "Mug", Quantity: 3, UnitPrice: 3.33, LineTotal: 9.99},
+		{ProductID: 2, ProductName: "Lamp", Quantity: 1, UnitPrice: 20, LineTotal: 20},
+	}
+	value, err := lines.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	var scanned QuoteLines
+	if err := scanned.Scan([]byte(value.(string))); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if !reflect.DeepEqual(scanned, lines) {
+		t.Errorf("round trip = %+v, want %+v", scanned, lines)
+	}
+}
+
+func TestQuoteLinesValueAndScanEdges(t *testing.T) {
+	value, err := QuoteLines(nil).Value()
+	if err != nil || value != "[]" {
+		t.Errorf("nil Value() = %v, %v, want \"[]\"", value, err)
+	}
+
+	lines := QuoteLines{{ProductID: 1}}
+	if err := lines.Scan(nil); err != nil || lines != nil {
+		t.Errorf("Scan(nil) = %v, %v, want nil lines", lines, err)
+	}
+	if err := lines.Scan(`[{"product_id":4}]`); err != nil || len(lines) != 1 || lines[0].ProductID != 4 {
+		t.Errorf("Scan(string) = %v, %v, want one line for product 4", lines, err)
+	}
+	if err := lines.Scan(12); err == nil {
+		t.Error("Scan(int) returned no error")
+	}
+}
+
+func TestCheckoutQuoteBreakdown(t *testing.T) {
+	quote := CheckoutQuote{
+		Reference:      "q_1",
+		Lines:          QuoteLines{{ProductID: 1, Quantity: 1, UnitPrice: 10, LineTotal: 10}},
+		Subtotal:       10,
+		Discount:       1,
+		Shipping:       5,
+		TaxRate:        0.1,
+		Tax:            1.4,
+		Total:          15.4,
+		ShippingMethod: "standard",
+		CouponCode:     "TENOFF",
+	}
+	want := CheckoutBreakdown{
+		Lines:          quote.Lines,
+		Subtotal:       10,
+		Discount:       1,
+		Shipping:       5,
+		TaxRate:        0.1,
+		Tax:            1.4,
+		Total:          15.4,
+		ShippingMethod: "standard",
+		CouponCode:     "TENOFF",
+	}
+	if got := quote.Breakdown(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Breakdown() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+const (
+	CouponTypePercent      = "percent"
+	CouponTypeFixed        = "fixed"
+	CouponTypeFreeShipping = "free_shipping"
+)
+
+type Coupon struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Code        string         `json:"code" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Description string         `json:"description" gorm:"type:varchar(255)"`
+	Type        string         `json:"type" gorm:"type:varchar(20);not null"`
+	Value       float64        `json:"value" gorm:"type:decimal(10,2)"`
+	MinSubtotal float64        `json:"min_subtotal" gorm:"type:decimal(10,2)"`
+	StartsAt    *time.Time     `json:"starts_at"`
+	ExpiresAt   *time.Time     `json:"expires_at"`
+	UsageLimit  int            `json:"usage_limit"`
+	UsedCount   int            `json:"used_count" gorm:"default:0"`
+	Active      bool           `json:"active" gorm:"default:true"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func IsValidCouponType(couponType string) bool {
+	switch couponType {
+	case CouponTypePercent, CouponTypeFixed, CouponTypeFreeShipping:
+		return true
+	}
+	return false
+}
+
+// IsRedeemableAt reports whether the coupon is active, inside its validity
+// window and under its usage limit (zero means unlimited).
+func (c *Coupon) IsRedeemableAt(now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return false
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return false
+	}
+	return c.UsageLimit == 0 || c.UsedCount < c.UsageLimit
+}
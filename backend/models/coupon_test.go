@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsValidCouponType(t *testing.T) {
+	for _, couponType := range []string{CouponTypePercent, CouponTypeFixed, CouponTypeFreeShipping} {
+		if !IsValidCouponType(couponType) {
+			t.Errorf("IsValidCouponType(%q) = false, want true", couponType)
+		}
+	}
+	if IsValidCouponType("bogo") {
+		t.Error("IsValidCouponType(\"bogo\") = true, want false")
+	}
+}
+
+func TestCouponIsRedeemableAt(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		coupon Coupon
+		want   bool
+	}{
+		{"active", Coupon{Active: true}, true},
+		{"inactive", Coupon{}, false},
+		{"started", Coupon{Active: true, StartsAt: &earlier}, true},
+		{"starts right now", Coupon{Active: true, StartsAt: &now}, true},
+		{"not started", Coupon{Active: true, StartsAt: &later}, false},
+		{"expires later", Coupon{Active: true, ExpiresAt: &later}, true},
+		{"expires right now", Coupon{Active: true, ExpiresAt: &now}, false},
+		{"expired", Coupon{Active: true, ExpiresAt: &earlier}, false},
+		{"unlimited", Coupon{Active: true, UsedCount: 500}, true},
+		{"under limit", Coupon{Active: true, UsageLimit: 3, UsedCount: 2}, true},
+		{"limit reached", Coupon{Active: true, UsageLimit: 3, UsedCount: 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.IsRedeemableAt(now); got != tt.want {
+				t.Errorf("IsRedeemableAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	IdempotencyStateProcessing = "processing"
+	IdempotencyStateCompleted  = "completed"
+)
+
+// ResponseHeaders is stored as a JSON object in a text column.
+type ResponseHeaders map[string]string
+
+func (h ResponseHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(h))
+	return string(data), err
+}
+
+func (h *ResponseHeaders) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for ResponseHeaders")
+	}
+	if len(data) == 0 {
+		*h = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]string)(h))
+}
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key so retries of the same request get the same response.
+// Scope keeps keys from different callers apart.
+type IdempotencyRecord struct {
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	Scope           string          `json:"scope" gorm:"type:varchar(100);not null;uniqueIndex:idx_idempotency_scope_key"`
+	Key             string          `json:"key" gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_scope_key"`
+	Method          string          `json:"method" gorm:"type:varchar(10);not null"`
+	Path            string          `json:"path" gorm:"type:varchar(255);not null"`
+	Fingerprint     string          `json:"fingerprint" gorm:"type:varchar(64);not null"`
+	State           string          `json:"state" gorm:"type:varchar(20);not null"`
+	StatusCode      int             `json:"status_code"`
+	ContentType     string          `json:"content_type" gorm:"type:varchar(100)"`
+	ResponseHeaders ResponseHeaders `json:"-" gorm:"type:text"`
+	ResponseBody    string          `json:"-" gorm:"type:mediumtext"`
+	ExpiresAt       time.Time       `json:"expires_at" gorm:"index"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SubscriptionBackInStock = "back_in_stock"
+	SubscriptionPriceDrop   = "price_drop"
+)
+
+// ProductSubscription asks for an alert when a product comes back in stock or
+// drops in price. Back-in-stock subscriptions are deactivated once delivered;
+// price-drop subscriptions move their reference price down with every alert.
+type ProductSubscription struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_subscription_user_product_type"`
+	ProductID      uint       `json:"product_id" gorm:"not null;uniqueIndex:idx_subscription_user_product_type;index"`
+	Type           string     `json:"type" gorm:"type:varchar(30);not null;uniqueIndex:idx_subscription_user_product_type"`
+	Channel        string     `json:"channel" gorm:"type:varchar(30);not null"`
+	ReferencePrice float64    `json:"reference_price" gorm:"type:decimal(10,2)"`
+	TargetPrice    *float64   `json:"target_price" gorm:"type:decimal(10,2)"`
+	Active         bool       `json:"active" gorm:"default:true;index"`
+	NotifiedAt     *time.Time `json:"notified_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	User    User    `json:"-" gorm:"foreignKey:UserID"`
+	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// Notification is a message delivered through the in-app channel.
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Type      string     `json:"type" gorm:"type:varchar(50);not null"`
+	Title     string     `json:"title" gorm:"type:varchar(255);not null"`
+	Body      string     `json:"body" gorm:"type:text"`
+	Link      string     `json:"link" gorm:"type:varchar(500)"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func IsValidSubscriptionType(subscriptionType string) bool {
+	return subscriptionType == SubscriptionBackInStock || subscriptionType == SubscriptionPriceDrop
+}
@@ -5,10 +5,28 @@ import (
 	"gorm.io/gorm"
 )
 
+// Order belongs to a user, or to a guest identified by GuestEmail until the
+// guest claims it into an account registered with the same email.
 type Order struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
-	UserID        uint           `json:"user_id" gorm:"not null"`
+	OrderNumber   string         `json:"order_number" gorm:"type:varchar(40);index"`
+	InvoiceNumber string         `json:"invoice_number" gorm:"type:varchar(40);index"`
+	InvoicedAt    *time.Time     `json:"invoiced_at"`
+	UserID        *uint          `json:"user_id" gorm:"index"`
+	GuestEmail    string         `json:"guest_email" gorm:"type:varchar(255);index"`
+	GuestName     string         `json:"guest_name" gorm:"type:varchar(200)"`
+	LookupToken   string         `json:"-" gorm:"type:varchar(64);index"`
+	ClaimedAt     *time.Time     `json:"claimed_at"`
+	SubscriptionID *uint         `json:"subscription_id" gorm:"index"`
+	Subtotal      float64        `json:"subtotal" gorm:"type:decimal(10,2)"`
+	DiscountAmount float64       `json:"discount_amount" gorm:"type:decimal(10,2)"`
+	ShippingAmount float64       `json:"shipping_amount" gorm:"type:decimal(10,2)"`
+	TaxRate       float64        `json:"tax_rate" gorm:"type:decimal(6,4)"`
+	TaxAmount     float64        `json:"tax_amount" gorm:"type:decimal(10,2)"`
 	TotalAmount   float64        `json:"total_amount" gorm:"type:decimal(10,2);not null"`
+	RefundedAmount float64       `json:"refunded_amount" gorm:"type:decimal(10,2);default:0"`
+	CouponCode    string         `json:"coupon_code" gorm:"type:varchar(50)"`
+	ShippingMethod string        `json:"shipping_method" gorm:"type:varchar(30)"`
 	Status        string         `json:"status" gorm:"type:varchar(50);default:pending"`
 	ShippingAddress string       `json:"shipping_address" gorm:"type:text;not null"`
 	PaymentMethod string         `json:"payment_method" gorm:"type:varchar(50);not null"`
@@ -17,7 +35,7 @@ type Order struct {
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 	
 	// Relations
-	User       User        `json:"user" gorm:"foreignKey:UserID"`
+	User       *User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	OrderItems []OrderItem `json:"order_items" gorm:"foreignKey:OrderID"`
 }
 
@@ -27,6 +45,13 @@ type OrderItem struct {
 	ProductID uint           `json:"product_id" gorm:"not null"`
 	Quantity  int            `json:"quantity" gorm:"not null"`
 	Price     float64        `json:"price" gorm:"type:decimal(10,2);not null"`
+	UnitCost  *float64       `json:"-" gorm:"type:decimal(10,2)"`
+	Note      string         `json:"note" gorm:"type:varchar(500)"`
+	Status    string         `json:"status" gorm:"type:varchar(30);default:pending"`
+	FulfilledQuantity int    `json:"fulfilled_quantity" gorm:"default:0"`
+	CancelledQuantity int    `json:"cancelled_quantity" gorm:"default:0"`
+	ReturnedQuantity  int    `json:"returned_quantity" gorm:"default:0"`
+	RefundedAmount float64   `json:"refunded_amount" gorm:"type:decimal(10,2);default:0"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -36,10 +61,100 @@ type OrderItem struct {
 	Product Product `json:"product" gorm:"foreignKey:ProductID"`
 }
 
+// Order line statuses. A line's status follows from its quantities; see
+// OrderItem.DeriveStatus.
+const (
+	OrderItemPending            = "pending"
+	OrderItemPartiallyFulfilled = "partially_fulfilled"
+	OrderItemFulfilled          = "fulfilled"
+	OrderItemPartiallyReturned  = "partially_returned"
+	OrderItemReturned           = "returned"
+	OrderItemCancelled          = "cancelled"
+)
+
+// Order statuses set from the lines once fulfillment starts. Pending,
+// confirmed and processing are still set by hand before anything ships.
+const (
+	OrderStatusPartiallyShipped = "partially_shipped"
+	OrderStatusShipped          = "shipped"
+	OrderStatusDelivered        = "delivered"
+	OrderStatusCancelled        = "cancelled"
+	OrderStatusReturned         = "returned"
+)
+
+// OpenQuantity is how many units of the line are neither shipped nor
+// cancelled.
+func (oi *OrderItem) OpenQuantity() int {
+	return oi.Quantity - oi.FulfilledQuantity - oi.CancelledQuantity
+}
+
+// DeriveStatus works out the line's status from its quantities.
+func (oi *OrderItem) DeriveStatus() string {
+	switch {
+	case oi.CancelledQuantity >= oi.Quantity:
+		return OrderItemCancelled
+	case oi.ReturnedQuantity > 0 && oi.ReturnedQuantity >= oi.FulfilledQuantity && oi.OpenQuantity() == 0:
+		return OrderItemReturned
+	case oi.ReturnedQuantity > 0:
+		return OrderItemPartiallyReturned
+	case oi.FulfilledQuantity > 0 && oi.OpenQuantity() == 0:
+		return OrderItemFulfilled
+	case oi.FulfilledQuantity > 0:
+		return OrderItemPartiallyFulfilled
+	}
+	return OrderItemPending
+}
+
+// DeriveStatus works out the order's status from its lines. Until something
+// ships the current, hand-set status is kept; a fully shipped order that was
+// already marked delivered stays delivered.
+func (o *Order) DeriveStatus() string {
+	if len(o.OrderItems) == 0 {
+		return o.Status
+	}
+
+	open, fulfilled, returned, cancelled := 0, 0, 0, 0
+	for _, item := range o.OrderItems {
+		open += item.OpenQuantity()
+		fulfilled += item.FulfilledQuantity
+		returned += item.ReturnedQuantity
+		if item.DeriveStatus() == OrderItemCancelled {
+			cancelled++
+		}
+	}
+
+	switch {
+	case cancelled == len(o.OrderItems):
+		return OrderStatusCancelled
+	case fulfilled == 0:
+		return o.Status
+	case open > 0:
+		return OrderStatusPartiallyShipped
+	case returned >= fulfilled:
+		return OrderStatusReturned
+	case o.Status == OrderStatusDelivered:
+		return OrderStatusDelivered
+	}
+	return OrderStatusShipped
+}
+
 type OrderResponse struct {
 	ID              uint                `json:"id"`
-	UserID          uint                `json:"user_id"`
+	OrderNumber     string              `json:"order_number"`
+	InvoiceNumber   string              `json:"invoice_number,omitempty"`
+	UserID          *uint               `json:"user_id"`
+	GuestEmail      string              `json:"guest_email,omitempty"`
+	GuestName       string              `json:"guest_name,omitempty"`
+	IsGuest         bool                `json:"is_guest"`
+	SubscriptionID  *uint               `json:"subscription_id,omitempty"`
+	Subtotal        float64             `json:"subtotal"`
+	DiscountAmount  float64             `json:"discount_amount"`
+	ShippingAmount  float64             `json:"shipping_amount"`
+	TaxAmount       float64             `json:"tax_amount"`
 	TotalAmount     float64             `json:"total_amount"`
+	RefundedAmount  float64             `json:"refunded_amount"`
+	CouponCode      string              `json:"coupon_code,omitempty"`
+	ShippingMethod  string              `json:"shipping_method"`
 	Status          string              `json:"status"`
 	ShippingAddress string              `json:"shipping_address"`
 	PaymentMethod   string              `json:"payment_method"`
@@ -52,6 +167,13 @@ type OrderItemResponse struct {
 	ProductID uint            `json:"product_id"`
 	Quantity  int             `json:"quantity"`
 	Price     float64         `json:"price"`
+	Note      string          `json:"note,omitempty"`
+	Status    string          `json:"status"`
+	FulfilledQuantity int     `json:"fulfilled_quantity"`
+	CancelledQuantity int     `json:"cancelled_quantity"`
+	ReturnedQuantity  int     `json:"returned_quantity"`
+	OpenQuantity      int     `json:"open_quantity"`
+	RefundedAmount    float64 `json:"refunded_amount"`
 	Product   ProductResponse `json:"product"`
 	Subtotal  float64         `json:"subtotal"`
 }
@@ -64,8 +186,21 @@ func (o *Order) ToResponse() OrderResponse {
 	
 	return OrderResponse{
 		ID:              o.ID,
+		OrderNumber:     o.OrderNumber,
+		InvoiceNumber:   o.InvoiceNumber,
 		UserID:          o.UserID,
+		GuestEmail:      o.GuestEmail,
+		GuestName:       o.GuestName,
+		IsGuest:         o.UserID == nil,
+		SubscriptionID:  o.SubscriptionID,
+		Subtotal:        o.Subtotal,
+		DiscountAmount:  o.DiscountAmount,
+		ShippingAmount:  o.ShippingAmount,
+		TaxAmount:       o.TaxAmount,
 		TotalAmount:     o.TotalAmount,
+		RefundedAmount:  o.RefundedAmount,
+		CouponCode:      o.CouponCode,
+		ShippingMethod:  o.ShippingMethod,
 		Status:          o.Status,
 		ShippingAddress: o.ShippingAddress,
 		PaymentMethod:   o.PaymentMethod,
@@ -81,6 +216,13 @@ func (oi *OrderItem) ToResponse() OrderItemResponse {
 		ProductID: oi.ProductID,
 		Quantity:  oi.Quantity,
 		Price:     oi.Price,
+		Note:      oi.Note,
+		Status:    oi.Status,
+		FulfilledQuantity: oi.FulfilledQuantity,
+		CancelledQuantity: oi.CancelledQuantity,
+		ReturnedQuantity:  oi.ReturnedQuantity,
+		OpenQuantity:      oi.OpenQuantity(),
+		RefundedAmount:    oi.RefundedAmount,
 		Product:   oi.Product.ToResponse(),
 		Subtotal:  subtotal,
 	}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Order edit actions recorded in the audit trail.
+const (
+	OrderEditItemAdded       = "item_added"
+	OrderEditItemRemoved     = "item_removed"
+	OrderEditQuantityChanged = "quantity_changed"
+	OrderEditPriceAdjusted   = "price_adjusted"
+	OrderEditAddressChanged  = "address_changed"
+)
+
+// OrderEdit records one change an admin made to an order after it was
+// placed, with the values before and after and the order total it left.
+type OrderEdit struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OrderID     uint      `json:"order_id" gorm:"not null;index"`
+	OrderItemID *uint     `json:"order_item_id"`
+	ProductID   *uint     `json:"product_id"`
+	AdminID     uint      `json:"admin_id" gorm:"not null"`
+	Action      string    `json:"action" gorm:"type:varchar(30);not null"`
+	OldValue    string    `json:"old_value" gorm:"type:text"`
+	NewValue    string    `json:"new_value" gorm:"type:text"`
+	Reason      string    `json:"reason" gorm:"type:varchar(500)"`
+	OldTotal    float64   `json:"old_total" gorm:"type:decimal(10,2)"`
+	NewTotal    float64   `json:"new_total" gorm:"type:decimal(10,2)"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relations
+	Admin *User `json:"admin,omitempty" gorm:"foreignKey:AdminID"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SubscriptionFrequencyWeekly  = "weekly"
+	SubscriptionFrequencyMonthly = "monthly"
+)
+
+const (
+	OrderSubscriptionActive    = "active"
+	OrderSubscriptionPaused    = "paused"
+	OrderSubscriptionCancelled = "cancelled"
+)
+
+// OrderSubscription places the same order for a user on a schedule. The
+// scheduler places an order once NextRunAt has passed and moves NextRunAt on
+// by one period.
+type OrderSubscription struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	Frequency       string     `json:"frequency" gorm:"type:varchar(20);not null"`
+	Status          string     `json:"status" gorm:"type:varchar(20);default:active;index"`
+	ShippingAddress string     `json:"shipping_address" gorm:"type:text;not null"`
+	PaymentMethod   string     `json:"payment_method" gorm:"type:varchar(50);not null"`
+	ShippingMethod  string     `json:"shipping_method" gorm:"type:varchar(30)"`
+	NextRunAt       time.Time  `json:"next_run_at" gorm:"index"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastOrderID     *uint      `json:"last_order_id"`
+	LastError       string     `json:"last_error" gorm:"type:varchar(500)"`
+	CancelledAt     *time.Time `json:"cancelled_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Items []OrderSubscriptionItem `json:"items" gorm:"foreignKey:SubscriptionID"`
+}
+
+type OrderSubscriptionItem struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint    `json:"subscription_id" gorm:"not null;index"`
+	ProductID      uint    `json:"product_id" gorm:"not null"`
+	Quantity       int     `json:"quantity" gorm:"not null"`
+	Product        Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+func IsValidSubscriptionFrequency(frequency string) bool {
+	return frequency == SubscriptionFrequencyWeekly || frequency == SubscriptionFrequencyMonthly
+}
+
+// NextRunAfter returns the run one period after t.
+func (s *OrderSubscription) NextRunAfter(t time.Time) time.Time {
+	if s.Frequency == SubscriptionFrequencyMonthly {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 7)
+}
+
+// NextRunFrom returns the first run on the schedule that is after now, so
+// runs missed while paused or while the scheduler was down are not made up.
+func (s *OrderSubscription) NextRunFrom(now time.Time) time.Time {
+	next := s.NextRunAt
+	for !next.After(now) {
+		next = s.NextRunAfter(next)
+	}
+	return next
+}
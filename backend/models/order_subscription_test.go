@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsValidSubscriptionFrequency(t *testing.T) {
+	tests := map[string]bool{
+		SubscriptionFrequencyWeekly:  true,
+		SubscriptionFrequencyMonthly: true,
+		"daily":                      false,
+		"":                           false,
+	}
+	for frequency, want := range tests {
+		if got := IsValidSubscriptionFrequency(frequency); got != want {
+			t.Errorf("IsValidSubscriptionFrequency(%q) = %v, want %v", frequency, got, want)
+		}
+	}
+}
+
+func TestOrderSubscriptionNextRunAfter(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		frequency string
+		want      time.Time
+	}{
+		{SubscriptionFrequencyWeekly, time.Date(2026, 3, 17, 9, 0, 0, 0, time.UTC)},
+		{SubscriptionFrequencyMonthly, time.Date(2026, 4, 10, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		subscription := OrderSubscription{Frequency: tt.frequency}
+		if got := subscription.NextRunAfter(start); !got.Equal(tt.want) {
+			t.Errorf("%s NextRunAfter() = %v, want %v", tt.frequency, got, tt.want)
+		}
+	}
+}
+
+func TestOrderSubscriptionNextRunFrom(t *testing.T) {
+	nextRun := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		frequency string
+		now       time.Time
+		want      time.Time
+	}{
+		{"not due yet", SubscriptionFrequencyWeekly, nextRun.Add(-time.Hour), nextRun},
+		{"due right now", SubscriptionFrequencyWeekly, nextRun, time.Date(2026, 3, 17, 9, 0, 0, 0, time.UTC)},
+		{"one run missed", SubscriptionFrequencyWeekly, nextRun.AddDate(0, 0, 3), time.Date(2026, 3, 17, 9, 0, 0, 0, time.UTC)},
+		{"several runs missed", SubscriptionFrequencyWeekly, nextRun.AddDate(0, 0, 20), time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC)},
+		{"monthly after a long pause", SubscriptionFrequencyMonthly, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 10, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := OrderSubscription{Frequency: tt.frequency, NextRunAt: nextRun}
+			if got := subscription.NextRunFrom(tt.now); !got.Equal(tt.want) {
+				t.Errorf("NextRunFrom() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
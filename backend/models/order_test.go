@@ -0,0 +1,81 @@
+package models
+
+import "testing"
+
+func TestOrderToResponseGuest(t *testing.T) {
+	userID := uint(3)
+	guest := Order{GuestEmail: "ada@example.com", GuestName: "Ada"}
+	if response := guest.ToResponse(); !response.IsGuest || response.GuestEmail != "ada@example.com" {
+		t.Errorf("guest response = %+v, want a guest order", response)
+	}
+	owned := Order{UserID: &userID}
+	if response := owned.ToResponse(); response.IsGuest {
+		t.Error("IsGuest = true for an order with a user")
+	}
+}
+
+func TestOrderItemDeriveStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		item OrderItem
+		open int
+		want string
+	}{
+		{"nothing shipped", OrderItem{Quantity: 3}, 3, OrderItemPending},
+		{"partly shipped", OrderItem{Quantity: 3, FulfilledQuantity: 1}, 2, OrderItemPartiallyFulfilled},
+		{"shipped", OrderItem{Quantity: 3, FulfilledQuantity: 3}, 0, OrderItemFulfilled},
+		{"shipped rest cancelled", OrderItem{Quantity: 3, FulfilledQuantity: 2, CancelledQuantity: 1}, 0, OrderItemFulfilled},
+		{"cancelled", OrderItem{Quantity: 3, CancelledQuantity: 3}, 0, OrderItemCancelled},
+		{"partly cancelled", OrderItem{Quantity: 3, CancelledQuantity: 1}, 2, OrderItemPending},
+		{"partly returned", OrderItem{Quantity: 3, FulfilledQuantity: 3, ReturnedQuantity: 1}, 0, OrderItemPartiallyReturned},
+		{"returned", OrderItem{Quantity: 3, FulfilledQuantity: 3, ReturnedQuantity: 3}, 0, OrderItemReturned},
+		{"returned while rest open", OrderItem{Quantity: 3, FulfilledQuantity: 1, ReturnedQuantity: 1}, 2, OrderItemPartiallyReturned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.OpenQuantity(); got != tt.open {
+				t.Errorf("OpenQuantity() = %d, want %d", got, tt.open)
+			}
+			if got := tt.item.DeriveStatus(); got != tt.want {
+				t.Errorf("DeriveStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderDeriveStatus(t *testing.T) {
+	pending := OrderItem{Quantity: 2}
+	shipped := OrderItem{Quantity: 2, FulfilledQuantity: 2}
+	partly := OrderItem{Quantity: 2, FulfilledQuantity: 1}
+	cancelled := OrderItem{Quantity: 2, CancelledQuantity: 2}
+	returned := OrderItem{Quantity: 2, FulfilledQuantity: 2, ReturnedQuantity: 2}
+	partlyReturned := OrderItem{Quantity: 2, FulfilledQuantity: 2, ReturnedQuantity: 1}
+
+	tests := []struct {
+		name   string
+		status string
+		items  []OrderItem
+		want   string
+	}{
+		{"no lines keeps status", "processing", nil, "processing"},
+		{"nothing shipped keeps status", "confirmed", []OrderItem{pending, pending}, "confirmed"},
+		{"some lines cancelled keeps status", "confirmed", []OrderItem{pending, cancelled}, "confirmed"},
+		{"all cancelled", "confirmed", []OrderItem{cancelled, cancelled}, OrderStatusCancelled},
+		{"partly shipped line", "processing", []OrderItem{partly}, OrderStatusPartiallyShipped},
+		{"one of two lines shipped", "processing", []OrderItem{shipped, pending}, OrderStatusPartiallyShipped},
+		{"shipped", "processing", []OrderItem{shipped, shipped}, OrderStatusShipped},
+		{"shipped rest cancelled", "processing", []OrderItem{shipped, cancelled}, OrderStatusShipped},
+		{"delivered stays delivered", OrderStatusDelivered, []OrderItem{shipped}, OrderStatusDelivered},
+		{"partly returned", OrderStatusDelivered, []OrderItem{partlyReturned}, OrderStatusDelivered},
+		{"returned", OrderStatusDelivered, []OrderItem{returned, returned}, OrderStatusReturned},
+		{"returned rest cancelled", OrderStatusShipped, []OrderItem{returned, cancelled}, OrderStatusReturned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{Status: tt.status, OrderItems: tt.items}
+			if got := order.DeriveStatus(); got != tt.want {
+				t.Errorf("DeriveStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
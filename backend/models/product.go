@@ -1,50 +1,160 @@
 package models
 
 import (
+	"math"
+	"sort"
 	"time"
 	"gorm.io/gorm"
 )
 
+const (
+	ProductStatusDraft    = "draft"
+	ProductStatusActive   = "active"
+	ProductStatusArchived = "archived"
+)
+
 type Product struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
+	SKU         string         `json:"sku" gorm:"type:varchar(100);uniqueIndex"`
 	Name        string         `json:"name" gorm:"type:varchar(255);not null"`
 	Description string         `json:"description" gorm:"type:text"`
 	Price       float64        `json:"price" gorm:"type:decimal(10,2);not null"`
 	Stock       int            `json:"stock" gorm:"default:0"`
 	Image       string         `json:"image" gorm:"type:varchar(255)"`
 	CategoryID  uint           `json:"category_id" gorm:"not null"`
+	Status      string         `json:"status" gorm:"type:varchar(20);default:active;index"`
+	PublishAt   *time.Time     `json:"publish_at"`
+	UnpublishAt *time.Time     `json:"unpublish_at"`
+	ArchivedAt  *time.Time     `json:"archived_at"`
+	RatingSum   int            `json:"-" gorm:"default:0"`
+	RatingCount int            `json:"rating_count" gorm:"default:0"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	
+
+	// LowStockThreshold overrides the store-wide low-stock level for this
+	// product; stock at or below it raises a low-stock alert.
+	LowStockThreshold *int `json:"low_stock_threshold"`
+
+	// Cost is what a unit of the product currently costs the store, null
+	// until set. It is kept out of the product JSON customers see.
+	Cost *float64 `json:"-" gorm:"type:decimal(10,2)"`
+
 	// Relations
 	Category Category    `json:"category" gorm:"foreignKey:CategoryID"`
 	Carts    []Cart      `json:"-" gorm:"foreignKey:ProductID"`
 	Orders   []OrderItem `json:"-" gorm:"foreignKey:ProductID"`
+
+	AttributeValues []ProductAttributeValue `json:"-" gorm:"foreignKey:ProductID"`
 }
 
 type ProductResponse struct {
-	ID          uint     `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Price       float64  `json:"price"`
-	Stock       int      `json:"stock"`
-	Image       string   `json:"image"`
-	CategoryID  uint     `json:"category_id"`
-	Category    Category `json:"category"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID                uint                       `json:"id"`
+	SKU               string                     `json:"sku"`
+	Name              string                     `json:"name"`
+	Description       string                     `json:"description"`
+	Price             float64                    `json:"price"`
+	Stock             int                        `json:"stock"`
+	LowStockThreshold *int                       `json:"low_stock_threshold,omitempty"`
+	Cost              *float64                   `json:"cost,omitempty"`
+	Image             string                     `json:"image"`
+	CategoryID        uint                       `json:"category_id"`
+	Category          Category                   `json:"category"`
+	Status            string                     `json:"status"`
+	PublishAt         *time.Time                 `json:"publish_at"`
+	UnpublishAt       *time.Time                 `json:"unpublish_at"`
+	ArchivedAt        *time.Time                 `json:"archived_at,omitempty"`
+	AverageRating     float64                    `json:"average_rating"`
+	ReviewCount       int                        `json:"review_count"`
+	Breadcrumbs       []Breadcrumb               `json:"breadcrumbs,omitempty"`
+	Attributes        []ProductAttributeResponse `json:"attributes,omitempty"`
+	CreatedAt         time.Time                  `json:"created_at"`
 }
 
 func (p *Product) ToResponse() ProductResponse {
+	var attributes []ProductAttributeResponse
+	sort.SliceStable(p.AttributeValues, func(i, j int) bool {
+		return p.AttributeValues[i].Attribute.SortOrder < p.AttributeValues[j].Attribute.SortOrder
+	})
+	for _, value := range p.AttributeValues {
+		attributes = append(attributes, value.ToResponse())
+	}
+
 	return ProductResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		Stock:       p.Stock,
-		Image:       p.Image,
-		CategoryID:  p.CategoryID,
-		Category:    p.Category,
-		CreatedAt:   p.CreatedAt,
+		ID:                p.ID,
+		SKU:               p.SKU,
+		Name:              p.Name,
+		Description:       p.Description,
+		Price:             p.Price,
+		Stock:             p.Stock,
+		LowStockThreshold: p.LowStockThreshold,
+		Image:             p.Image,
+		CategoryID:        p.CategoryID,
+		Category:          p.Category,
+		Status:            p.Status,
+		PublishAt:         p.PublishAt,
+		UnpublishAt:       p.UnpublishAt,
+		ArchivedAt:        p.ArchivedAt,
+		AverageRating:     p.AverageRating(),
+		ReviewCount:       p.RatingCount,
+		Attributes:        attributes,
+		CreatedAt:         p.CreatedAt,
+	}
+}
+
+// ToAdminResponse is the product as admins see it, with its cost.
+func (p *Product) ToAdminResponse() ProductResponse {
+	response := p.ToResponse()
+	response.Cost = p.Cost
+	return response
+}
+
+// AverageRating is derived from the incrementally maintained rating sum and
+// count of approved reviews, rounded to one decimal.
+func (p *Product) AverageRating() float64 {
+	if p.RatingCount == 0 {
+		return 0
+	}
+	return math.Round(float64(p.RatingSum)/float64(p.RatingCount)*10) / 10
+}
+
+// LowStockLevel is the stock level at or below which the product counts as
+// low on stock: its own threshold, or defaultThreshold when it has none.
+func (p *Product) LowStockLevel(defaultThreshold int) int {
+	if p.LowStockThreshold != nil {
+		return *p.LowStockThreshold
+	}
+	return defaultThreshold
+}
+
+func IsValidProductStatus(status string) bool {
+	switch status {
+	case ProductStatusDraft, ProductStatusActive, ProductStatusArchived:
+		return true
+	}
+	return false
+}
+
+// IsVisibleAt reports whether the product is shown on the storefront at the
+// given time: it must be active and inside its publish window, if any.
+func (p *Product) IsVisibleAt(now time.Time) bool {
+	if p.Status != ProductStatusActive {
+		return false
+	}
+	if p.PublishAt != nil && p.PublishAt.After(now) {
+		return false
+	}
+	if p.UnpublishAt != nil && !p.UnpublishAt.After(now) {
+		return false
+	}
+	return true
+}
+
+// VisibleProducts is a query scope matching the rules of IsVisibleAt.
+func VisibleProducts(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("products.status = ?", ProductStatusActive).
+			Where("products.publish_at IS NULL OR products.publish_at <= ?", now).
+			Where("products.unpublish_at IS NULL OR products.unpublish_at > ?", now)
 	}
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Where a product cost change came from.
+const (
+	ProductCostSourceManual        = "manual"
+	ProductCostSourcePurchaseOrder = "purchase_order"
+)
+
+// ProductCost is a change to a product's unit cost. The latest one is the
+// product's current cost, which order lines snapshot when the product sells.
+type ProductCost struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ProductID       uint      `json:"product_id" gorm:"not null;index"`
+	Cost            float64   `json:"cost" gorm:"type:decimal(10,2);not null"`
+	PreviousCost    *float64  `json:"previous_cost" gorm:"type:decimal(10,2)"`
+	Source          string    `json:"source" gorm:"type:varchar(30);not null"`
+	PurchaseOrderID *uint     `json:"purchase_order_id"`
+	UserID          *uint     `json:"user_id"`
+	Note            string    `json:"note" gorm:"type:varchar(500)"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsValidProductStatus(t *testing.T) {
+	for _, status := range []string{ProductStatusDraft, ProductStatusActive, ProductStatusArchived} {
+		if !IsValidProductStatus(status) {
+			t.Errorf("IsValidProductStatus(%q) = false, want true", status)
+		}
+	}
+	for _, status := range []string{"", "published", "Active"} {
+		if IsValidProductStatus(status) {
+			t.Errorf("IsValidProductStatus(%q) = true, want false", status)
+		}
+	}
+}
+
+func TestProductIsVisibleAt(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		product Product
+		want    bool
+	}{
+		{"active", Product{Status: ProductStatusActive}, true},
+		{"draft", Product{Status: ProductStatusDraft}, false},
+		{"archived", Product{Status: ProductStatusArchived}, false},
+		{"published earlier", Product{Status: ProductStatusActive, PublishAt: &earlier}, true},
+		{"published right now", Product{Status: ProductStatusActive, PublishAt: &now}, true},
+		{"scheduled", Product{Status: ProductStatusActive, PublishAt: &later}, false},
+		{"unpublished later", Product{Status: ProductStatusActive, UnpublishAt: &later}, true},
+		{"unpublished right now", Product{Status: ProductStatusActive, UnpublishAt: &now}, false},
+		{"inside window", Product{Status: ProductStatusActive, PublishAt: &earlier, UnpublishAt: &later}, true},
+		{"draft inside window", Product{Status: ProductStatusDraft, PublishAt: &earlier, UnpublishAt: &later}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.IsVisibleAt(now); got != tt.want {
+				t.Errorf("IsVisibleAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductAverageRating(t *testing.T) {
+	tests := []struct {
+		sum, count int
+		want       float64
+	}{
+		{0, 0, 0},
+		{5, 1, 5},
+		{9, 2, 4.5},
+		{13, 3, 4.3},
+		{14, 3, 4.7},
+	}
+	for _, tt := range tests {
+		product := Product{RatingSum: tt.sum, RatingCount: tt.count}
+		if got := product.AverageRating(); got != tt.want {
+			t.Errorf("AverageRating() with sum %d and count %d = %v, want %v", tt.sum, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestProductLowStockLevel(t *testing.T) {
+	own, zero := 12, 0
+	tests := []struct {
+		name      string
+		threshold *int
+		want      int
+	}{
+		{"store default", nil, 5},
+		{"own threshold", &own, 12},
+		{"own threshold of zero", &zero, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := Product{LowStockThreshold: tt.threshold}
+			if got := product.LowStockLevel(5); got != tt.want {
+				t.Errorf("LowStockLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductCostIsAdminOnly(t *testing.T) {
+	cost := 4.5
+	product := Product{ID: 7, Name: "Lamp", Cost: &cost}
+
+	admin := product.ToAdminResponse()
+	if admin.Cost == nil || *admin.Cost != 4.5 {
+		t.Errorf("ToAdminResponse().Cost = %v, want 4.5", admin.Cost)
+	}
+	if admin.ID != 7 || admin.Name != "Lamp" {
+		t.Errorf("ToAdminResponse() = %+v, want the product's fields", admin)
+	}
+
+	for name, value := range map[string]interface{}{
+		"ToResponse()": product.ToResponse(),
+		"Product":      product,
+		"OrderItem":    OrderItem{ProductID: 7, UnitCost: &cost},
+	} {
+		body, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("json.Marshal(%s) error = %v", name, err)
+		}
+		if strings.Contains(string(body), "cost") {
+			t.Errorf("%s JSON = %s, want no cost", name, body)
+		}
+	}
+}
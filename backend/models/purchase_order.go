@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+)
+
+// Purchase order statuses. A draft can still be edited; once ordered, goods
+// are received against it until every line is in or the rest is written off
+// by closing it.
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderOrdered           = "ordered"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderClosed            = "closed"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+// PurchaseOrder is an order placed with a supplier for delivery to a
+// warehouse.
+type PurchaseOrder struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Number      string     `json:"number" gorm:"type:varchar(50);uniqueIndex"`
+	SupplierID  uint       `json:"supplier_id" gorm:"not null;index"`
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null"`
+	Status      string     `json:"status" gorm:"type:varchar(30);default:draft;index"`
+	Total       float64    `json:"total" gorm:"type:decimal(12,2);default:0"`
+	Notes       string     `json:"notes" gorm:"type:text"`
+	ExpectedAt  *time.Time `json:"expected_at"`
+	OrderedAt   *time.Time `json:"ordered_at"`
+	ReceivedAt  *time.Time `json:"received_at"`
+	CreatedByID uint       `json:"created_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relations
+	Supplier  *Supplier           `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
+	Warehouse *Warehouse          `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	Lines     []PurchaseOrderLine `json:"lines" gorm:"foreignKey:PurchaseOrderID"`
+}
+
+// PurchaseOrderLine is a quantity of one product on a purchase order, at the
+// supplier's unit cost, and how much of it has arrived.
+type PurchaseOrderLine struct {
+	ID               uint    `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID  uint    `json:"purchase_order_id" gorm:"not null;index"`
+	ProductID        uint    `json:"product_id" gorm:"not null;index"`
+	SupplierSKU      string  `json:"supplier_sku" gorm:"type:varchar(100)"`
+	Quantity         int     `json:"quantity" gorm:"not null"`
+	ReceivedQuantity int     `json:"received_quantity" gorm:"default:0"`
+	UnitCost         float64 `json:"unit_cost" gorm:"type:decimal(10,2);not null"`
+
+	// Relations
+	Product *Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// OutstandingQuantity is how many units of the line are still to arrive.
+func (l *PurchaseOrderLine) OutstandingQuantity() int {
+	if outstanding := l.Quantity - l.ReceivedQuantity; outstanding > 0 {
+		return outstanding
+	}
+	return 0
+}
+
+// DeriveStatus follows from the lines once the order has been placed:
+// received when every line is in full, partially received when anything has
+// arrived. Drafts, closed and cancelled orders keep their status.
+func (po *PurchaseOrder) DeriveStatus() string {
+	switch po.Status {
+	case PurchaseOrderDraft, PurchaseOrderClosed, PurchaseOrderCancelled:
+		return po.Status
+	}
+	received, outstanding := 0, 0
+	for _, line := range po.Lines {
+		received += line.ReceivedQuantity
+		outstanding += line.OutstandingQuantity()
+	}
+	switch {
+	case outstanding == 0:
+		return PurchaseOrderReceived
+	case received > 0:
+		return PurchaseOrderPartiallyReceived
+	}
+	return PurchaseOrderOrdered
+}
+
+// IsOpen reports whether goods are still expected against the order.
+func (po *PurchaseOrder) IsOpen() bool {
+	return po.Status == PurchaseOrderOrdered || po.Status == PurchaseOrderPartiallyReceived
+}
@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+func TestPurchaseOrderLineOutstandingQuantity(t *testing.T) {
+	tests := []struct {
+		quantity, received int
+		want               int
+	}{
+		{10, 0, 10},
+		{10, 4, 6},
+		{10, 10, 0},
+		{10, 12, 0},
+	}
+	for _, tt := range tests {
+		line := PurchaseOrderLine{Quantity: tt.quantity, ReceivedQuantity: tt.received}
+		if got := line.OutstandingQuantity(); got != tt.want {
+			t.Errorf("OutstandingQuantity() of %d with %d received = %d, want %d", tt.quantity, tt.received, got, tt.want)
+		}
+	}
+}
+
+func TestPurchaseOrderDeriveStatus(t *testing.T) {
+	lines := func(received ...int) []PurchaseOrderLine {
+		var lines []PurchaseOrderLine
+		for _, quantity := range received {
+			lines = append(lines, PurchaseOrderLine{Quantity: 5, ReceivedQuantity: quantity})
+		}
+		return lines
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		lines  []PurchaseOrderLine
+		want   string
+	}{
+		{"nothing received", PurchaseOrderOrdered, lines(0, 0), PurchaseOrderOrdered},
+		{"one line partly in", PurchaseOrderOrdered, lines(2, 0), PurchaseOrderPartiallyReceived},
+		{"one line in full", PurchaseOrderPartiallyReceived, lines(5, 0), PurchaseOrderPartiallyReceived},
+		{"everything in", PurchaseOrderPartiallyReceived, lines(5, 5), PurchaseOrderReceived},
+		{"more than ordered", PurchaseOrderOrdered, lines(5, 7), PurchaseOrderReceived},
+		{"draft keeps status", PurchaseOrderDraft, lines(5, 5), PurchaseOrderDraft},
+		{"closed keeps status", PurchaseOrderClosed, lines(2, 0), PurchaseOrderClosed},
+		{"cancelled keeps status", PurchaseOrderCancelled, lines(0, 0), PurchaseOrderCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := PurchaseOrder{Status: tt.status, Lines: tt.lines}
+			if got := order.DeriveStatus(); got != tt.want {
+				t.Errorf("DeriveStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurchaseOrderIsOpen(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{PurchaseOrderDraft, false},
+		{PurchaseOrderOrdered, true},
+		{PurchaseOrderPartiallyReceived, true},
+		{PurchaseOrderReceived, false},
+		{PurchaseOrderClosed, false},
+		{PurchaseOrderCancelled, false},
+	}
+	for _, tt := range tests {
+		order := PurchaseOrder{Status: tt.status}
+		if got := order.IsOpen(); got != tt.want {
+			t.Errorf("IsOpen() for %q = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+type Review struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	ProductID      uint           `json:"product_id" gorm:"not null;uniqueIndex:idx_review_product_user"`
+	UserID         uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_review_product_user"`
+	OrderItemID    uint           `json:"order_item_id" gorm:"not null"`
+	Rating         int            `json:"rating" gorm:"not null"`
+	Title          string         `json:"title" gorm:"type:varchar(255)"`
+	Body           string         `json:"body" gorm:"type:text"`
+	Images         StringList     `json:"images" gorm:"type:text"`
+	Status         string         `json:"status" gorm:"type:varchar(20);default:pending;index"`
+	ModerationNote string         `json:"moderation_note" gorm:"type:varchar(500)"`
+	HelpfulCount   int            `json:"helpful_count" gorm:"default:0"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Relations
+	User    User    `json:"-" gorm:"foreignKey:UserID"`
+	Product Product `json:"-" gorm:"foreignKey:ProductID"`
+}
+
+type ReviewVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"review_id" gorm:"not null;uniqueIndex:idx_review_vote"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_review_vote"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReviewResponse struct {
+	ID               uint       `json:"id"`
+	ProductID        uint       `json:"product_id"`
+	UserID           uint       `json:"user_id"`
+	AuthorName       string     `json:"author_name"`
+	Rating           int        `json:"rating"`
+	Title            string     `json:"title"`
+	Body             string     `json:"body"`
+	Images           StringList `json:"images"`
+	Status           string     `json:"status"`
+	ModerationNote   string     `json:"moderation_note,omitempty"`
+	HelpfulCount     int        `json:"helpful_count"`
+	VerifiedPurchase bool       `json:"verified_purchase"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func IsValidReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (r *Review) ToResponse() ReviewResponse {
+	// Only show the last name initial to keep reviewers reasonably anonymous
+	authorName := r.User.FirstName
+	if lastName := []rune(r.User.LastName); len(lastName) > 0 {
+		authorName += " " + string(lastName[0]) + "."
+	}
+
+	images := r.Images
+	if images == nil {
+		images = StringList{}
+	}
+
+	return ReviewResponse{
+		ID:               r.ID,
+		ProductID:        r.ProductID,
+		UserID:           r.UserID,
+		AuthorName:       authorName,
+		Rating:           r.Rating,
+		Title:            r.Title,
+		Body:             r.Body,
+		Images:           images,
+		Status:           r.Status,
+		ModerationNote:   r.ModerationNote,
+		HelpfulCount:     r.HelpfulCount,
+		VerifiedPurchase: r.OrderItemID != 0,
+		CreatedAt:        r.CreatedAt,
+	}
+}
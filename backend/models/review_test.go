@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestReviewToResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		review     Review
+		wantAuthor string
+		wantBuyer  bool
+	}{
+		{"initial of last name", Review{User: User{FirstName: "Ada", LastName: "Lovelace"}, OrderItemID: 3}, "Ada L.", true},
+		{"multibyte initial", Review{User: User{FirstName: "Zoë", LastName: "Øster"}}, "Zoë Ø.", false},
+		{"no last name", Review{User: User{FirstName: "Cher"}}, "Cher", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := tt.review.ToResponse()
+			if response.AuthorName != tt.wantAuthor {
+				t.Errorf("AuthorName = %q, want %q", response.AuthorName, tt.wantAuthor)
+			}
+			if response.VerifiedPurchase != tt.wantBuyer {
+				t.Errorf("VerifiedPurchase = %v, want %v", response.VerifiedPurchase, tt.wantBuyer)
+			}
+			if response.Images == nil {
+				t.Error("Images = nil, want an empty list")
+			}
+		})
+	}
+}
+
+func TestIsValidReviewStatus(t *testing.T) {
+	for _, status := range []string{ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected} {
+		if !IsValidReviewStatus(status) {
+			t.Errorf("IsValidReviewStatus(%q) = false, want true", status)
+		}
+	}
+	if IsValidReviewStatus("hidden") {
+		t.Error("IsValidReviewStatus(\"hidden\") = true, want false")
+	}
+}
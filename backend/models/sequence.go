@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sequence is a named counter used to hand out gap-free numbers such as
+// order and invoice numbers.
+type Sequence struct {
+	Name  string `json:"name" gorm:"type:varchar(100);primaryKey"`
+	Value int64  `json:"value" gorm:"not null;default:0"`
+}
+
+// NextSequenceValue increments the named sequence and returns the new value.
+// The row is locked until tx ends, so tx must be a transaction.
+func NextSequenceValue(tx *gorm.DB, name string) (int64, error) {
+	var sequence Sequence
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&sequence).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sequence = Sequence{Name: name, Value: 1}
+		if err := tx.Create(&sequence).Error; err != nil {
+			return 0, err
+		}
+		return sequence.Value, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	sequence.Value++
+	if err := tx.Model(&sequence).Update("value", sequence.Value).Error; err != nil {
+		return 0, err
+	}
+	return sequence.Value, nil
+}
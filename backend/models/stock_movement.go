@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Reasons a product's stock changed.
+const (
+	StockReasonSale         = "sale"
+	StockReasonCancellation = "cancellation"
+	StockReasonRestock      = "restock"
+	StockReasonAdjustment   = "adjustment"
+	StockReasonReturn       = "return"
+	StockReasonTransfer     = "transfer"
+)
+
+// StockMovement is one entry in the inventory ledger. A product's stock is
+// the sum of its movements; Product.Stock holds that sum so it can be read
+// and filtered on without adding up the ledger. Every movement happens in a
+// warehouse, whose level for the product WarehouseStock holds the same way.
+type StockMovement struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ProductID       uint      `json:"product_id" gorm:"not null;index"`
+	WarehouseID     *uint     `json:"warehouse_id" gorm:"index"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	Reason          string    `json:"reason" gorm:"type:varchar(20);not null;index"`
+	BalanceAfter    int       `json:"balance_after"`
+	OrderID         *uint     `json:"order_id" gorm:"index"`
+	OrderItemID     *uint     `json:"order_item_id"`
+	PurchaseOrderID *uint     `json:"purchase_order_id" gorm:"index"`
+	UserID          *uint     `json:"user_id"`
+	Note            string    `json:"note" gorm:"type:varchar(500)"`
+	CreatedAt       time.Time `json:"created_at" gorm:"index"`
+
+	// Relations
+	Product   *Product   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Warehouse *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func IsValidStockReason(reason string) bool {
+	switch reason {
+	case StockReasonSale, StockReasonCancellation, StockReasonRestock, StockReasonAdjustment, StockReasonReturn,
+		StockReasonTransfer:
+		return true
+	}
+	return false
+}
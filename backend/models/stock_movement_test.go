@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestIsValidStockReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   bool
+	}{
+		{StockReasonSale, true},
+		{StockReasonCancellation, true},
+		{StockReasonRestock, true},
+		{StockReasonAdjustment, true},
+		{StockReasonReturn, true},
+		{StockReasonTransfer, true},
+		{"", false},
+		{"theft", false},
+		{"Sale", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidStockReason(tt.reason); got != tt.want {
+			t.Errorf("IsValidStockReason(%q) = %v, want %v", tt.reason, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// Supplier is a company products are bought from. LeadTimeDays is how long
+// its deliveries usually take to arrive.
+type Supplier struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"type:varchar(255);not null"`
+	ContactName  string         `json:"contact_name" gorm:"type:varchar(255)"`
+	Email        string         `json:"email" gorm:"type:varchar(255)"`
+	Phone        string         `json:"phone" gorm:"type:varchar(50)"`
+	Address      string         `json:"address" gorm:"type:text"`
+	LeadTimeDays int            `json:"lead_time_days" gorm:"default:7"`
+	Notes        string         `json:"notes" gorm:"type:text"`
+	Active       bool           `json:"active" gorm:"default:true"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// SupplierProduct is a product as a supplier sells it: under its own SKU, at
+// its cost, in multiples of MinOrderQuantity. Preferred marks the supplier
+// reorder suggestions buy the product from.
+type SupplierProduct struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	SupplierID       uint      `json:"supplier_id" gorm:"not null;uniqueIndex:idx_supplier_product"`
+	ProductID        uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_supplier_product;index"`
+	SupplierSKU      string    `json:"supplier_sku" gorm:"type:varchar(100)"`
+	Cost             float64   `json:"cost" gorm:"type:decimal(10,2);not null"`
+	MinOrderQuantity int       `json:"min_order_quantity" gorm:"default:1"`
+	LeadTimeDays     *int      `json:"lead_time_days"`
+	Preferred        bool      `json:"preferred" gorm:"default:false"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relations
+	Supplier *Supplier `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
+	Product  *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// LeadTime is the product's own lead time from this supplier, or the
+// supplier's.
+func (sp *SupplierProduct) LeadTime(supplier Supplier) int {
+	if sp.LeadTimeDays != nil {
+		return *sp.LeadTimeDays
+	}
+	return supplier.LeadTimeDays
+}
@@ -0,0 +1,26 @@
+package models
+
+import "testing"
+
+func TestSupplierProductLeadTime(t *testing.T) {
+	own, none := 3, 0
+	supplier := Supplier{LeadTimeDays: 10}
+
+	tests := []struct {
+		name     string
+		leadTime *int
+		want     int
+	}{
+		{"supplier lead time", nil, 10},
+		{"own lead time", &own, 3},
+		{"own lead time of zero", &none, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := SupplierProduct{LeadTimeDays: tt.leadTime}
+			if got := product.LeadTime(supplier); got != tt.want {
+				t.Errorf("LeadTime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Stock allocation rules, choosing the warehouses an order line ships from.
+const (
+	// AllocationPriority ships each line from the highest priority warehouse
+	// that can fill it on its own, splitting only when none can.
+	AllocationPriority = "priority"
+	// AllocationClosest ships each line from the warehouse serving the
+	// shipping address that can fill it on its own, splitting only when none
+	// can.
+	AllocationClosest = "closest"
+	// AllocationSplit fills each line from warehouses in priority order,
+	// taking what each one has.
+	AllocationSplit = "split"
+)
+
+// Warehouse is a location stock is held in. Lower Priority values are
+// preferred. ServiceAreas lists, comma separated, the postal code prefixes,
+// cities, regions or countries the warehouse is closest to.
+type Warehouse struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Code         string    `json:"code" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name         string    `json:"name" gorm:"type:varchar(255);not null"`
+	Address      string    `json:"address" gorm:"type:text"`
+	ServiceAreas string    `json:"service_areas" gorm:"type:varchar(500)"`
+	Priority     int       `json:"priority" gorm:"default:0"`
+	Active       bool      `json:"active" gorm:"default:true"`
+	IsDefault    bool      `json:"is_default" gorm:"default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// WarehouseStock is a product's stock level in one warehouse: the sum of the
+// product's stock movements there.
+type WarehouseStock struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_warehouse_product"`
+	ProductID   uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_warehouse_product;index"`
+	Quantity    int       `json:"quantity" gorm:"default:0"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Warehouse *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	Product   *Product   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// OrderItemAllocation records how many units of an order line are taken from
+// a warehouse. Cancelled units are released back to the warehouses they were
+// allocated from.
+type OrderItemAllocation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OrderID     uint      `json:"order_id" gorm:"not null;index"`
+	OrderItemID uint      `json:"order_item_id" gorm:"not null;index"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Warehouse *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+}
+
+// StockTransfer moves stock of a product from one warehouse to another. It is
+// recorded in the ledger as a pair of transfer movements.
+type StockTransfer struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ProductID       uint      `json:"product_id" gorm:"not null;index"`
+	FromWarehouseID uint      `json:"from_warehouse_id" gorm:"not null"`
+	ToWarehouseID   uint      `json:"to_warehouse_id" gorm:"not null"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	UserID          *uint     `json:"user_id"`
+	Note            string    `json:"note" gorm:"type:varchar(500)"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Relations
+	Product       *Product   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	FromWarehouse *Warehouse `json:"from_warehouse,omitempty" gorm:"foreignKey:FromWarehouseID"`
+	ToWarehouse   *Warehouse `json:"to_warehouse,omitempty" gorm:"foreignKey:ToWarehouseID"`
+}
+
+func IsValidAllocationRule(rule string) bool {
+	switch rule {
+	case AllocationPriority, AllocationClosest, AllocationSplit:
+		return true
+	}
+	return false
+}
+
+// ServiceAreaMatch scores how closely the warehouse serves address: the
+// length of its longest service area found in the address, 0 for none.
+// Numeric areas are postal code prefixes and match the start of a number in
+// the address; other areas match whole words, case insensitively.
+func (w *Warehouse) ServiceAreaMatch(address string) int {
+	normalize := func(s string) string {
+		return strings.Join(strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}), " ")
+	}
+	words := " " + normalize(address) + " "
+
+	best := 0
+	for _, area := range strings.Split(w.ServiceAreas, ",") {
+		area = normalize(area)
+		if area == "" {
+			continue
+		}
+		matched := false
+		if strings.IndexFunc(area, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 {
+			matched = strings.Contains(words, " "+area)
+		} else {
+			matched = strings.Contains(words, " "+area+" ")
+		}
+		if matched && len(area) > best {
+			best = len(area)
+		}
+	}
+	return best
+}
@@ -0,0 +1,50 @@
+package models
+
+import "testing"
+
+func TestIsValidAllocationRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		want bool
+	}{
+		{AllocationPriority, true},
+		{AllocationClosest, true},
+		{AllocationSplit, true},
+		{"", false},
+		{"nearest", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidAllocationRule(tt.rule); got != tt.want {
+			t.Errorf("IsValidAllocationRule(%q) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestWarehouseServiceAreaMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		areas   string
+		address string
+		want    int
+	}{
+		{"no areas", "", "1 Main St, Springfield", 0},
+		{"no match", "Berlin, 10", "1 Main St, Springfield", 0},
+		{"city", "Berlin", "Hauptstr. 1, 10115 Berlin", 6},
+		{"city case insensitive", "BERLIN", "hauptstr. 1, 10115 berlin", 6},
+		{"city is a whole word", "Berlin", "Berliner Str. 5, Hamburg", 0},
+		{"postal code prefix", "101", "Hauptstr. 1, 10115 Berlin", 3},
+		{"postal code prefix starts a number", "115", "Hauptstr. 1, 10115 Berlin", 0},
+		{"longest area wins", "10, Berlin, 1011", "Hauptstr. 1, 10115 Berlin", 6},
+		{"longest postal prefix wins", "10, 1011", "Hauptstr. 1, 10115 Potsdam", 4},
+		{"multi-word area", "New York", "5th Ave, New York, NY", 8},
+		{"punctuation ignored", " san-francisco ,", "Market St, San Francisco CA", 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warehouse := Warehouse{ServiceAreas: tt.areas}
+			if got := warehouse.ServiceAreaMatch(tt.address); got != tt.want {
+				t.Errorf("ServiceAreaMatch() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
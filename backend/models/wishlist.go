@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+type Wishlist struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(100);not null"`
+	IsPublic   bool           `json:"is_public" gorm:"default:false"`
+	ShareToken string         `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	User  User           `json:"-" gorm:"foreignKey:UserID"`
+	Items []WishlistItem `json:"items" gorm:"foreignKey:WishlistID"`
+}
+
+type WishlistItem struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	WishlistID        uint      `json:"wishlist_id" gorm:"not null;uniqueIndex:idx_wishlist_product"`
+	ProductID         uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_wishlist_product"`
+	PriceAtAdd        float64   `json:"price_at_add" gorm:"type:decimal(10,2);not null"`
+	WasOutOfStock     bool      `json:"was_out_of_stock" gorm:"default:false"`
+	NotifyPriceDrop   bool      `json:"notify_price_drop" gorm:"default:false"`
+	NotifyBackInStock bool      `json:"notify_back_in_stock" gorm:"default:false"`
+	Note              string    `json:"note" gorm:"type:varchar(255)"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Relations
+	Product Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+type WishlistResponse struct {
+	ID        uint                   `json:"id"`
+	UserID    uint                   `json:"user_id,omitempty"`
+	Name      string                 `json:"name"`
+	IsPublic  bool                   `json:"is_public"`
+	ShareURL  string                 `json:"share_url,omitempty"`
+	Items     []WishlistItemResponse `json:"items"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type WishlistItemResponse struct {
+	ID                uint            `json:"id"`
+	ProductID         uint            `json:"product_id"`
+	Product           ProductResponse `json:"product"`
+	PriceAtAdd        float64         `json:"price_at_add"`
+	CurrentPrice      float64         `json:"current_price"`
+	PriceDropped      bool            `json:"price_dropped"`
+	InStock           bool            `json:"in_stock"`
+	BackInStock       bool            `json:"back_in_stock"`
+	Available         bool            `json:"available"`
+	NotifyPriceDrop   bool            `json:"notify_price_drop"`
+	NotifyBackInStock bool            `json:"notify_back_in_stock"`
+	Note              string          `json:"note"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+func (w *Wishlist) ToResponse() WishlistResponse {
+	items := make([]WishlistItemResponse, 0, len(w.Items))
+	for _, item := range w.Items {
+		items = append(items, item.ToResponse())
+	}
+
+	return WishlistResponse{
+		ID:        w.ID,
+		UserID:    w.UserID,
+		Name:      w.Name,
+		IsPublic:  w.IsPublic,
+		Items:     items,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func (wi *WishlistItem) ToResponse() WishlistItemResponse {
+	inStock := wi.Product.Stock > 0
+	return WishlistItemResponse{
+		ID:                wi.ID,
+		ProductID:         wi.ProductID,
+		Product:           wi.Product.ToResponse(),
+		PriceAtAdd:        wi.PriceAtAdd,
+		CurrentPrice:      wi.Product.Price,
+		PriceDropped:      wi.Product.Price < wi.PriceAtAdd,
+		InStock:           inStock,
+		BackInStock:       wi.WasOutOfStock && inStock,
+		Available:         wi.Product.IsVisibleAt(time.Now()),
+		NotifyPriceDrop:   wi.NotifyPriceDrop,
+		NotifyBackInStock: wi.NotifyBackInStock,
+		Note:              wi.Note,
+		CreatedAt:         wi.CreatedAt,
+	}
+}
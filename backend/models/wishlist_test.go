@@ -0,0 +1,71 @@
+package models
+
+import "testing"
+
+func TestWishlistItemToResponse(t *testing.T) {
+	tests := []struct {
+		name             string
+		item             WishlistItem
+		wantPriceDropped bool
+		wantInStock      bool
+		wantBackInStock  bool
+		wantAvailable    bool
+	}{
+		{
+			name:          "unchanged",
+			item:          WishlistItem{PriceAtAdd: 20, Product: Product{Price: 20, Stock: 3, Status: ProductStatusActive}},
+			wantInStock:   true,
+			wantAvailable: true,
+		},
+		{
+			name:             "price dropped",
+			item:             WishlistItem{PriceAtAdd: 20, Product: Product{Price: 15, Stock: 3, Status: ProductStatusActive}},
+			wantPriceDropped: true,
+			wantInStock:      true,
+			wantAvailable:    true,
+		},
+		{
+			name:          "price raised",
+			item:          WishlistItem{PriceAtAdd: 20, Product: Product{Price: 25, Stock: 3, Status: ProductStatusActive}},
+			wantInStock:   true,
+			wantAvailable: true,
+		},
+		{
+			name:            "back in stock",
+			item:            WishlistItem{PriceAtAdd: 20, WasOutOfStock: true, Product: Product{Price: 20, Stock: 1, Status: ProductStatusActive}},
+			wantInStock:     true,
+			wantBackInStock: true,
+			wantAvailable:   true,
+		},
+		{
+			name:          "still out of stock",
+			item:          WishlistItem{PriceAtAdd: 20, WasOutOfStock: true, Product: Product{Price: 20, Status: ProductStatusActive}},
+			wantAvailable: true,
+		},
+		{
+			name:        "archived",
+			item:        WishlistItem{PriceAtAdd: 20, Product: Product{Price: 20, Stock: 3, Status: ProductStatusArchived}},
+			wantInStock: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := tt.item.ToResponse()
+			if response.PriceDropped != tt.wantPriceDropped {
+				t.Errorf("PriceDropped = %v, want %v", response.PriceDropped, tt.wantPriceDropped)
+			}
+			if response.InStock != tt.wantInStock {
+				t.Errorf("InStock = %v, want %v", response.InStock, tt.wantInStock)
+			}
+			if response.BackInStock != tt.wantBackInStock {
+				t.Errorf("BackInStock = %v, want %v", response.BackInStock, tt.wantBackInStock)
+			}
+			if response.Available != tt.wantAvailable {
+				t.Errorf("Available = %v, want %v", response.Available, tt.wantAvailable)
+			}
+			if response.CurrentPrice != tt.item.Product.Price {
+				t.Errorf("CurrentPrice = %v, want %v", response.CurrentPrice, tt.item.Product.Price)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"time"
+	"ecommerce-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelInApp   = "in_app"
+)
+
+// Message is a rendered alert addressed to a single user.
+type Message struct {
+	UserID  uint                   `json:"user_id"`
+	Email   string                 `json:"email"`
+	Type    string                 `json:"type"`
+	Subject string                 `json:"subject"`
+	Body    string                 `json:"body"`
+	Link    string                 `json:"link"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// Channel delivers messages to users. Implementations must be safe for use
+// from the notifier goroutine.
+type Channel interface {
+	Send(message Message) error
+}
+
+// EmailChannel sends plain text email through SMTP. When no SMTP host is
+// configured, messages are written to the log instead.
+type EmailChannel struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewEmailChannelFromEnv() *EmailChannel {
+	return &EmailChannel{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func (e *EmailChannel) Send(message Message) error {
+	if message.Email == "" {
+		return fmt.Errorf("user %d has no email address", message.UserID)
+	}
+	if e.Host == "" {
+		log.Printf("Email to %s: %s\n%s\n%s", message.Email, message.Subject, message.Body, message.Link)
+		return nil
+	}
+
+	body := message.Body
+	if message.Link != "" {
+		body += "\r\n\r\n" + message.Link
+	}
+	msg := "From: " + e.From + "\r\n" +
+		"To: " + message.Email + "\r\n" +
+		"Subject: " + message.Subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		body + "\r\n"
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	return smtp.SendMail(e.Host+":"+e.Port, auth, e.From, []string{message.Email}, []byte(msg))
+}
+
+// WebhookChannel posts messages as JSON to a fixed URL, for example a
+// messaging service that fans out SMS or push notifications.
+type WebhookChannel struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookChannel(url string) *WebhookChannel {
+	return &WebhookChannel{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *WebhookChannel) Send(message Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// InAppChannel stores messages as notifications shown in the storefront.
+type InAppChannel struct {
+	DB *gorm.DB
+}
+
+func (i *InAppChannel) Send(message Message) error {
+	notification := models.Notification{
+		UserID: message.UserID,
+		Type:   message.Type,
+		Title:  message.Subject,
+		Body:   message.Body,
+		Link:   message.Link,
+	}
+	return i.DB.Create(&notification).Error
+}
@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmailChannelWithoutSMTP(t *testing.T) {
+	channel := &EmailChannel{}
+	if err := channel.Send(Message{UserID: 1, Email: "ada@example.com", Subject: "Hi"}); err != nil {
+		t.Errorf("Send() without an SMTP host error = %v, want the message logged", err)
+	}
+	if err := channel.Send(Message{UserID: 1, Subject: "Hi"}); err == nil {
+		t.Error("Send() to a user without an email address returned no error")
+	}
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var received Message
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(server.URL)
+	message := Message{UserID: 4, Type: EventPriceDrop, Subject: "Lamp dropped in price", Link: "https://shop.example/products/7"}
+	if err := channel.Send(message); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if received.UserID != 4 || received.Type != EventPriceDrop || received.Link != message.Link {
+		t.Errorf("webhook received %+v, want %+v", received, message)
+	}
+
+	status = http.StatusBadGateway
+	if err := channel.Send(message); err == nil {
+		t.Error("Send() returned no error for a failed webhook response")
+	}
+}
@@ -0,0 +1,236 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"ecommerce-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	EventBackInStock = "product.back_in_stock"
+	EventPriceDrop   = "product.price_drop"
+	EventLowStock    = "product.low_stock"
+)
+
+// Event describes a catalog change that subscribers may want to hear about.
+type Event struct {
+	Type       string
+	ProductID  uint
+	OldPrice   float64
+	NewPrice   float64
+	OldStock   int
+	NewStock   int
+	Threshold  int
+	OccurredAt time.Time
+}
+
+var (
+	mu       sync.RWMutex
+	channels = map[string]Channel{}
+	events   chan Event
+	db       *gorm.DB
+)
+
+// RegisterChannel makes a delivery channel available to subscriptions under
+// the given name, replacing any channel previously registered with it.
+func RegisterChannel(name string, channel Channel) {
+	mu.Lock()
+	defer mu.Unlock()
+	channels[name] = channel
+}
+
+func HasChannel(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := channels[name]
+	return ok
+}
+
+// Send delivers a message through the named channel.
+func Send(channelName string, message Message) error {
+	mu.RLock()
+	channel, ok := channels[channelName]
+	mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("notification channel %q is not registered", channelName)
+	}
+	return channel.Send(message)
+}
+
+// Start registers the built-in channels and starts the goroutine that turns
+// published events into alerts for matching subscriptions.
+func Start(database *gorm.DB) {
+	db = database
+
+	RegisterChannel(ChannelEmail, NewEmailChannelFromEnv())
+	RegisterChannel(ChannelInApp, &InAppChannel{DB: database})
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		RegisterChannel(ChannelWebhook, NewWebhookChannel(url))
+	}
+
+	events = make(chan Event, 256)
+	go func() {
+		for event := range events {
+			if err := process(event); err != nil {
+				log.Printf("Failed to process %s event for product %d: %v", event.Type, event.ProductID, err)
+			}
+		}
+	}()
+}
+
+// Publish queues events for delivery without blocking the caller. Events are
+// dropped with a log line when the notifier is not running or is saturated.
+func Publish(batch ...Event) {
+	for _, event := range batch {
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+		if events == nil {
+			log.Printf("Notifier not started, dropping %s event for product %d", event.Type, event.ProductID)
+			continue
+		}
+		select {
+		case events <- event:
+		default:
+			log.Printf("Notifier queue full, dropping %s event for product %d", event.Type, event.ProductID)
+		}
+	}
+}
+
+// ProductChangeEvents compares a product before and after an update and
+// returns the alerts the change should trigger.
+func ProductChangeEvents(productID uint, oldPrice, newPrice float64, oldStock, newStock int) []Event {
+	var batch []Event
+	if oldStock <= 0 && newStock > 0 {
+		batch = append(batch, Event{Type: EventBackInStock, ProductID: productID, OldStock: oldStock, NewStock: newStock, NewPrice: newPrice})
+	}
+	if newPrice > 0 && newPrice < oldPrice {
+		batch = append(batch, Event{Type: EventPriceDrop, ProductID: productID, OldPrice: oldPrice, NewPrice: newPrice, NewStock: newStock})
+	}
+	return batch
+}
+
+// LowStockEvent returns the alert for a stock change that takes a product
+// from above threshold to at or below it, if it does.
+func LowStockEvent(productID uint, oldStock, newStock, threshold int) []Event {
+	if oldStock > threshold && newStock <= threshold {
+		return []Event{{Type: EventLowStock, ProductID: productID, OldStock: oldStock, NewStock: newStock, Threshold: threshold}}
+	}
+	return nil
+}
+
+func process(event Event) error {
+	if event.Type == EventLowStock {
+		return processLowStock(event)
+	}
+
+	var product models.Product
+	if err := db.First(&product, event.ProductID).Error; err != nil {
+		return err
+	}
+	// Hidden products cannot be bought, so there is nothing to alert about yet
+	if !product.IsVisibleAt(time.Now()) {
+		return nil
+	}
+
+	query := db.Preload("User").Where("product_id = ? AND active = ?", product.ID, true)
+	switch event.Type {
+	case EventBackInStock:
+		query = query.Where("type = ?", models.SubscriptionBackInStock)
+	case EventPriceDrop:
+		query = query.Where("type = ? AND reference_price > ?", models.SubscriptionPriceDrop, product.Price).
+			Where("target_price IS NULL OR target_price >= ?", product.Price)
+	default:
+		return fmt.Errorf("unknown event type %s", event.Type)
+	}
+
+	var subscriptions []models.ProductSubscription
+	if err := query.Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	link := os.Getenv("FRONTEND_URL") + fmt.Sprintf("/products/%d", product.ID)
+	for _, subscription := range subscriptions {
+		message := Message{
+			UserID: subscription.UserID,
+			Email:  subscription.User.Email,
+			Type:   event.Type,
+			Link:   link,
+			Data: map[string]interface{}{
+				"product_id": product.ID,
+				"price":      product.Price,
+				"stock":      product.Stock,
+			},
+		}
+		if event.Type == EventBackInStock {
+			message.Subject = product.Name + " is back in stock"
+			message.Body = fmt.Sprintf("Good news, %s! %s is available again.", subscription.User.FirstName, product.Name)
+		} else {
+			message.Subject = product.Name + " dropped in price"
+			message.Body = fmt.Sprintf("Good news, %s! %s is now %.2f (was %.2f).", subscription.User.FirstName, product.Name, product.Price, subscription.ReferencePrice)
+			message.Data["previous_price"] = subscription.ReferencePrice
+		}
+
+		if err := Send(subscription.Channel, message); err != nil {
+			log.Printf("Failed to send %s alert to user %d via %s: %v", event.Type, subscription.UserID, subscription.Channel, err)
+			continue
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"notified_at": now}
+		if event.Type == EventBackInStock {
+			updates["active"] = false
+		} else {
+			updates["reference_price"] = product.Price
+		}
+		db.Model(&models.ProductSubscription{}).Where("id = ?", subscription.ID).Updates(updates)
+	}
+	return nil
+}
+
+// processLowStock alerts every admin that a product is running low, through
+// the channel named by LOW_STOCK_ALERT_CHANNEL (email by default).
+func processLowStock(event Event) error {
+	var product models.Product
+	if err := db.Unscoped().First(&product, event.ProductID).Error; err != nil {
+		return err
+	}
+	// Archived products are not restocked, so running out is expected
+	if product.Status == models.ProductStatusArchived || product.DeletedAt.Valid {
+		return nil
+	}
+
+	var admins []models.User
+	if err := db.Where("role = ?", "admin").Find(&admins).Error; err != nil {
+		return err
+	}
+
+	channel := os.Getenv("LOW_STOCK_ALERT_CHANNEL")
+	if channel == "" {
+		channel = ChannelEmail
+	}
+	for _, admin := range admins {
+		message := Message{
+			UserID:  admin.ID,
+			Email:   admin.Email,
+			Type:    event.Type,
+			Subject: product.Name + " is low on stock",
+			Body:    fmt.Sprintf("%s (SKU %s) is down to %d in stock, at or below its threshold of %d.", product.Name, product.SKU, event.NewStock, event.Threshold),
+			Link:    os.Getenv("FRONTEND_URL") + "/admin/inventory",
+			Data: map[string]interface{}{
+				"product_id": product.ID,
+				"sku":        product.SKU,
+				"stock":      event.NewStock,
+				"threshold":  event.Threshold,
+			},
+		}
+		if err := Send(channel, message); err != nil {
+			log.Printf("Failed to send %s alert to admin %d via %s: %v", event.Type, admin.ID, channel, err)
+		}
+	}
+	return nil
+}
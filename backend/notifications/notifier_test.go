@@ -0,0 +1,114 @@
+package notifications
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProductChangeEvents(t *testing.T) {
+	tests := []struct {
+		name               string
+		oldPrice, newPrice float64
+		oldStock, newStock int
+		want               []string
+	}{
+		{"no change", 10, 10, 5, 5, nil},
+		{"restocked", 10, 10, 0, 3, []string{EventBackInStock}},
+		{"restocked from oversold", 10, 10, -2, 1, []string{EventBackInStock}},
+		{"still out of stock", 10, 10, 0, 0, nil},
+		{"stock added while in stock", 10, 10, 2, 8, nil},
+		{"price dropped", 10, 8, 5, 5, []string{EventPriceDrop}},
+		{"price raised", 8, 10, 5, 5, nil},
+		{"price cleared", 10, 0, 5, 5, nil},
+		{"restocked and cheaper", 10, 8, 0, 4, []string{EventBackInStock, EventPriceDrop}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := ProductChangeEvents(7, tt.oldPrice, tt.newPrice, tt.oldStock, tt.newStock)
+			if len(batch) != len(tt.want) {
+				t.Fatalf("got %d events, want %v", len(batch), tt.want)
+			}
+			for i, event := range batch {
+				if event.Type != tt.want[i] {
+					t.Errorf("event %d type = %q, want %q", i, event.Type, tt.want[i])
+				}
+				if event.ProductID != 7 || event.NewPrice != tt.newPrice || event.NewStock != tt.newStock {
+					t.Errorf("event %d = %+v, want product 7 at the new price and stock", i, event)
+				}
+			}
+		})
+	}
+}
+
+func TestLowStockEvent(t *testing.T) {
+	tests := []struct {
+		name               string
+		oldStock, newStock int
+		want               bool
+	}{
+		{"drops to threshold", 8, 5, true},
+		{"drops below threshold", 8, 2, true},
+		{"drops to zero", 6, 0, true},
+		{"stays above threshold", 9, 6, false},
+		{"already low", 4, 2, false},
+		{"already at threshold", 5, 5, false},
+		{"restocked", 2, 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := LowStockEvent(7, tt.oldStock, tt.newStock, 5)
+			if (len(batch) == 1) != tt.want || len(batch) > 1 {
+				t.Fatalf("got %d events, want alert %v", len(batch), tt.want)
+			}
+			if tt.want {
+				event := batch[0]
+				if event.Type != EventLowStock || event.ProductID != 7 || event.OldStock != tt.oldStock ||
+					event.NewStock != tt.newStock || event.Threshold != 5 {
+					t.Errorf("event = %+v, want a low-stock alert for product 7", event)
+				}
+			}
+		})
+	}
+}
+
+type recordingChannel struct {
+	sent []Message
+	err  error
+}
+
+func (r *recordingChannel) Send(message Message) error {
+	r.sent = append(r.sent, message)
+	return r.err
+}
+
+func TestSendUsesRegisteredChannel(t *testing.T) {
+	channel := &recordingChannel{}
+	RegisterChannel("test", channel)
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(channels, "test")
+		mu.Unlock()
+	})
+
+	if !HasChannel("test") {
+		t.Fatal("HasChannel(\"test\") = false after registering it")
+	}
+	if err := Send("test", Message{UserID: 3, Subject: "Hello"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(channel.sent) != 1 || channel.sent[0].UserID != 3 {
+		t.Errorf("sent = %+v, want one message for user 3", channel.sent)
+	}
+
+	channel.err = errors.New("unreachable")
+	if err := Send("test", Message{UserID: 3}); err == nil {
+		t.Error("Send() returned no error from a failing channel")
+	}
+
+	if HasChannel("pager") {
+		t.Error("HasChannel(\"pager\") = true for an unregistered channel")
+	}
+	if err := Send("pager", Message{UserID: 3}); err == nil {
+		t.Error("Send() to an unregistered channel returned no error")
+	}
+}
@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		size float64
+		want float64
+	}{
+		{"", 10, 0},
+		{"i", 10, 2.22},
+		{"W", 10, 9.44},
+		{"Hello", 12, 27.336},
+		{"日", 10, 5.56},
+	}
+	for _, tt := range tests {
+		if got := TextWidth(tt.s, tt.size); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("TextWidth(%q, %v) = %v, want %v", tt.s, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width float64
+		want  []string
+	}{
+		{"fits", "Main Street 1", 200, []string{"Main Street 1"}},
+		{"breaks on spaces", "Main Street 1 Springfield", 60, []string{"Main Street 1", "Springfield"}},
+		{"keeps line breaks", "Main Street 1\r\nSpringfield", 200, []string{"Main Street 1", "Springfield"}},
+		{"long word stays whole", "Supercalifragilistic", 20, []string{"Supercalifragilistic"}},
+		{"empty", "", 100, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Wrap(tt.s, 10, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Wrap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeAndEscape(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Total (incl. tax)", `Total \(incl. tax\)`},
+		{`C:\path`, `C:\\path`},
+		{"Café", `Caf\351`},
+		{"5 €", `5 \200`},
+		{"a\tb\nc", "a bc"},
+		{"日本", "??"},
+	}
+	for _, tt := range tests {
+		if got := escape(encode(tt.s)); got != tt.want {
+			t.Errorf("escape(encode(%q)) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentWriteTo(t *testing.T) {
+	doc := New("Invoice INV-000001")
+	doc.AddPage().Text(50, 70, 12, true, "Page one")
+	doc.AddPage().TextRight(545, 70, 12, false, "Page two")
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	out := buf.String()
+	if n != int64(len(out)) {
+		t.Errorf("WriteTo() = %d bytes, wrote %d", n, len(out))
+	}
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatalf("output is not framed as a PDF: %q...", out[:20])
+	}
+	if !strings.Contains(out, "/Count 2") || !strings.Contains(out, "(Page one) Tj") {
+		t.Error("output is missing the pages")
+	}
+
+	// Every xref entry must point at the start of its object
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out, -1)
+	if len(xref) != 9 {
+		t.Fatalf("xref has %d objects, want 9", len(xref))
+	}
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := strconv.Itoa(i+1) + " 0 obj"; !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[offset:offset+10], want)
+		}
+	}
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	if offset, _ := strconv.Atoi(startxref[1]); !strings.HasPrefix(out[offset:], "xref\n") {
+		t.Errorf("startxref %d does not point at the xref table", offset)
+	}
+}
+
+func TestEmptyDocumentHasAPage(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := New("Empty").WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "/Count 1") {
+		t.Error("empty document was written without a page")
+	}
+}
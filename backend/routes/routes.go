@@ -15,7 +15,8 @@ func SetupRoutes() *gin.Engine {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:5173"}
 	config.AllowCredentials = true
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Cart-Token", "Idempotency-Key"}
+	config.ExposeHeaders = []string{"X-Cart-Token", "Idempotent-Replayed"}
 	r.Use(cors.New(config))
 
 	// Serve static files for uploads
@@ -37,7 +38,9 @@ func SetupRoutes() *gin.Engine {
 	categories := api.Group("/categories")
 	{
 		categories.GET("", controllers.GetCategories)
+		categories.GET("/tree", controllers.GetCategoryTree)
 		categories.GET("/:id", controllers.GetCategory)
+		categories.GET("/:id/attributes", controllers.GetCategoryAttributes)
 
 		// Admin only routes
 		adminCategories := categories.Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
@@ -45,6 +48,9 @@ func SetupRoutes() *gin.Engine {
 			adminCategories.POST("", controllers.CreateCategory)
 			adminCategories.PUT("/:id", controllers.UpdateCategory)
 			adminCategories.DELETE("/:id", controllers.DeleteCategory)
+			adminCategories.POST("/:id/attributes", controllers.CreateCategoryAttribute)
+			adminCategories.PUT("/:id/attributes/:attributeId", controllers.UpdateCategoryAttribute)
+			adminCategories.DELETE("/:id/attributes/:attributeId", controllers.DeleteCategoryAttribute)
 		}
 	}
 
@@ -53,6 +59,9 @@ func SetupRoutes() *gin.Engine {
 	{
 		products.GET("", controllers.GetProducts)
 		products.GET("/:id", controllers.GetProduct)
+		products.GET("/:id/reviews", controllers.GetProductReviews)
+		products.POST("/:id/reviews", middlewares.AuthMiddleware(), controllers.CreateReview)
+		products.POST("/:id/subscriptions", middlewares.AuthMiddleware(), controllers.CreateSubscription)
 
 		// Admin only routes
 		adminProducts := products.Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
@@ -60,17 +69,84 @@ func SetupRoutes() *gin.Engine {
 			adminProducts.POST("", controllers.CreateProduct)
 			adminProducts.PUT("/:id", controllers.UpdateProduct)
 			adminProducts.DELETE("/:id", controllers.DeleteProduct)
+			adminProducts.POST("/:id/archive", controllers.ArchiveProduct)
+			adminProducts.POST("/:id/restore", controllers.RestoreProduct)
 		}
 	}
 
-	// Cart routes (authenticated users only)
-	cart := api.Group("/cart").Use(middlewares.AuthMiddleware())
+	// Reviews routes (authenticated users only)
+	reviews := api.Group("/reviews").Use(middlewares.AuthMiddleware())
+	{
+		reviews.PUT("/:id", controllers.UpdateReview)
+		reviews.DELETE("/:id", controllers.DeleteReview)
+		reviews.POST("/:id/helpful", controllers.VoteReviewHelpful)
+		reviews.DELETE("/:id/helpful", controllers.RemoveReviewVote)
+	}
+
+	// Wishlist routes (shared links are public, everything else requires authentication)
+	wishlists := api.Group("/wishlists")
+	{
+		wishlists.GET("/shared/:token", controllers.GetSharedWishlist)
+
+		userWishlists := wishlists.Use(middlewares.AuthMiddleware())
+		{
+			userWishlists.GET("", controllers.GetWishlists)
+			userWishlists.POST("", controllers.CreateWishlist)
+			userWishlists.GET("/:id", controllers.GetWishlist)
+			userWishlists.PUT("/:id", controllers.UpdateWishlist)
+			userWishlists.DELETE("/:id", controllers.DeleteWishlist)
+			userWishlists.POST("/:id/share", controllers.ShareWishlist)
+			userWishlists.DELETE("/:id/share", controllers.UnshareWishlist)
+			userWishlists.POST("/:id/items", controllers.AddWishlistItem)
+			userWishlists.PUT("/:id/items/:itemId", controllers.UpdateWishlistItem)
+			userWishlists.DELETE("/:id/items/:itemId", controllers.RemoveWishlistItem)
+			userWishlists.POST("/:id/move-to-cart", controllers.MoveWishlistToCart)
+		}
+	}
+
+	// Cart routes (users, or guests identified by the X-Cart-Token header)
+	cart := api.Group("/cart").Use(middlewares.OptionalAuthMiddleware())
 	{
 		cart.GET("", controllers.GetCart)
-		cart.POST("/add", controllers.AddToCart)
+		cart.POST("/adjust", controllers.AdjustCart)
+		cart.POST("/add", middlewares.IdempotencyMiddleware(), controllers.AddToCart)
 		cart.PUT("/item/:id", controllers.UpdateCartItem)
 		cart.DELETE("/item/:id", controllers.RemoveFromCart)
+		cart.POST("/item/:id/save-for-later", controllers.SaveCartItemForLater)
+		cart.POST("/item/:id/move-to-cart", controllers.MoveCartItemToCart)
 		cart.DELETE("/clear", controllers.ClearCart)
+		cart.DELETE("/notices", controllers.DismissCartNotices)
+		cart.POST("/recover", controllers.RecoverCart)
+	}
+
+	// Subscriptions routes (authenticated users only)
+	subscriptions := api.Group("/subscriptions").Use(middlewares.AuthMiddleware())
+	{
+		subscriptions.GET("", controllers.GetSubscriptions)
+		subscriptions.DELETE("/:id", controllers.DeleteSubscription)
+	}
+
+	// Notifications routes (authenticated users only)
+	notifications := api.Group("/notifications").Use(middlewares.AuthMiddleware())
+	{
+		notifications.GET("", controllers.GetNotifications)
+		notifications.PUT("/read-all", controllers.MarkAllNotificationsRead)
+		notifications.PUT("/:id/read", controllers.MarkNotificationRead)
+	}
+
+	// Checkout routes (users, or guests identified by the X-Cart-Token header)
+	checkout := api.Group("/checkout").Use(middlewares.OptionalAuthMiddleware())
+	{
+		checkout.GET("/shipping-methods", controllers.GetShippingMethods)
+		checkout.POST("/quote", middlewares.IdempotencyMiddleware(), controllers.CreateCheckoutQuote)
+	}
+
+	// Guest checkout routes (identified by the X-Cart-Token header and lookup tokens)
+	guestOrders := api.Group("/guest-orders")
+	{
+		guestOrders.POST("", middlewares.IdempotencyMiddleware(), controllers.GuestCheckout)
+		guestOrders.GET("/:token", controllers.LookupGuestOrder)
+		guestOrders.GET("/:token/invoice.pdf", controllers.GetGuestOrderInvoice)
 	}
 
 	// Orders routes (authenticated users only)
@@ -78,16 +154,41 @@ func SetupRoutes() *gin.Engine {
 	{
 		orders.GET("", controllers.GetOrders)
 		orders.GET("/:id", controllers.GetOrder)
-		orders.POST("", controllers.CreateOrder)
+		orders.GET("/:id/invoice.pdf", controllers.GetOrderInvoice)
+		orders.POST("", middlewares.IdempotencyMiddleware(), controllers.CreateOrder)
+		orders.POST("/claim", middlewares.IdempotencyMiddleware(), controllers.ClaimGuestOrder)
+		orders.POST("/:id/reorder", middlewares.IdempotencyMiddleware(), controllers.ReorderOrder)
 
 		// Admin only routes
 		adminOrders := orders.Use(middlewares.AdminMiddleware())
 		{
 			adminOrders.GET("/all", controllers.GetAllOrders)
-			adminOrders.PUT("/:id/status", controllers.UpdateOrderStatus)
+			adminOrders.GET("/export", controllers.ExportOrders)
+			adminOrders.PUT("/:id/status", middlewares.IdempotencyMiddleware(), controllers.UpdateOrderStatus)
+			adminOrders.GET("/:id/edits", controllers.GetOrderEdits)
+			adminOrders.GET("/:id/allocations", controllers.GetOrderAllocations)
+			adminOrders.PUT("/:id/shipping-address", controllers.UpdateOrderShippingAddress)
+			adminOrders.POST("/:id/items", middlewares.IdempotencyMiddleware(), controllers.AddOrderItem)
+			adminOrders.PUT("/:id/items/:itemId", controllers.UpdateOrderItem)
+			adminOrders.DELETE("/:id/items/:itemId", controllers.RemoveOrderItem)
+			adminOrders.POST("/:id/items/:itemId/fulfill", middlewares.IdempotencyMiddleware(), controllers.FulfillOrderItem)
+			adminOrders.POST("/:id/items/:itemId/cancel", middlewares.IdempotencyMiddleware(), controllers.CancelOrderItem)
+			adminOrders.POST("/:id/items/:itemId/return", middlewares.IdempotencyMiddleware(), controllers.ReturnOrderItem)
 		}
 	}
 
+	// Recurring order subscriptions (authenticated users only)
+	orderSubscriptions := api.Group("/order-subscriptions").Use(middlewares.AuthMiddleware())
+	{
+		orderSubscriptions.GET("", controllers.GetOrderSubscriptions)
+		orderSubscriptions.POST("", middlewares.IdempotencyMiddleware(), controllers.CreateOrderSubscription)
+		orderSubscriptions.GET("/:id", controllers.GetOrderSubscription)
+		orderSubscriptions.POST("/:id/pause", controllers.PauseOrderSubscription)
+		orderSubscriptions.POST("/:id/resume", controllers.ResumeOrderSubscription)
+		orderSubscriptions.POST("/:id/skip", middlewares.IdempotencyMiddleware(), controllers.SkipOrderSubscription)
+		orderSubscriptions.POST("/:id/cancel", controllers.CancelOrderSubscription)
+	}
+
 	// Users routes (admin only)
 	users := api.Group("/users").Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
 	{
@@ -97,6 +198,60 @@ func SetupRoutes() *gin.Engine {
 		users.DELETE("/:id", controllers.DeleteUser)
 	}
 
+	// Admin tools
+	admin := api.Group("/admin").Use(middlewares.AuthMiddleware(), middlewares.AdminMiddleware())
+	{
+		admin.POST("/catalog/import", controllers.ImportCatalog)
+		admin.GET("/catalog/export", controllers.ExportCatalog)
+		admin.GET("/products", controllers.GetAdminProducts)
+		admin.GET("/products/:id", controllers.PreviewProduct)
+		admin.PUT("/products/:id/low-stock-threshold", controllers.UpdateLowStockThreshold)
+		admin.GET("/products/:id/costs", controllers.GetProductCosts)
+		admin.PUT("/products/:id/cost", controllers.UpdateProductCost)
+		admin.GET("/inventory/low-stock", controllers.GetLowStockReport)
+		admin.GET("/inventory/movements", controllers.GetStockMovements)
+		admin.POST("/inventory/adjustments", middlewares.IdempotencyMiddleware(), controllers.AdjustStock)
+		admin.GET("/products/:id/stock-levels", controllers.GetProductStockLevels)
+		admin.GET("/warehouses", controllers.GetWarehouses)
+		admin.POST("/warehouses", controllers.CreateWarehouse)
+		admin.PUT("/warehouses/:id", controllers.UpdateWarehouse)
+		admin.GET("/warehouses/:id/stock", controllers.GetWarehouseStock)
+		admin.GET("/stock-transfers", controllers.GetStockTransfers)
+		admin.POST("/stock-transfers", middlewares.IdempotencyMiddleware(), controllers.CreateStockTransfer)
+		admin.GET("/inventory/reorder-suggestions", controllers.GetReorderSuggestions)
+		admin.GET("/suppliers", controllers.GetSuppliers)
+		admin.POST("/suppliers", controllers.CreateSupplier)
+		admin.PUT("/suppliers/:id", controllers.UpdateSupplier)
+		admin.DELETE("/suppliers/:id", controllers.DeleteSupplier)
+		admin.GET("/suppliers/:id/products", controllers.GetSupplierProducts)
+		admin.PUT("/suppliers/:id/products/:productId", controllers.SetSupplierProduct)
+		admin.DELETE("/suppliers/:id/products/:productId", controllers.DeleteSupplierProduct)
+		admin.GET("/products/:id/suppliers", controllers.GetProductSuppliers)
+		admin.GET("/purchase-orders", controllers.GetPurchaseOrders)
+		admin.POST("/purchase-orders", middlewares.IdempotencyMiddleware(), controllers.CreatePurchaseOrder)
+		admin.GET("/purchase-orders/:id", controllers.GetPurchaseOrder)
+		admin.PUT("/purchase-orders/:id", controllers.UpdatePurchaseOrder)
+		admin.POST("/purchase-orders/:id/submit", middlewares.IdempotencyMiddleware(), controllers.SubmitPurchaseOrder)
+		admin.POST("/purchase-orders/:id/receive", middlewares.IdempotencyMiddleware(), controllers.ReceivePurchaseOrder)
+		admin.POST("/purchase-orders/:id/cancel", middlewares.IdempotencyMiddleware(), controllers.CancelPurchaseOrder)
+		admin.GET("/abandoned-carts", controllers.GetAbandonedCarts)
+		admin.GET("/abandoned-carts/report", controllers.GetAbandonedCartReport)
+		admin.GET("/coupons", controllers.GetCoupons)
+		admin.POST("/coupons", controllers.CreateCoupon)
+		admin.PUT("/coupons/:id", controllers.UpdateCoupon)
+		admin.DELETE("/coupons/:id", controllers.DeleteCoupon)
+		admin.GET("/reports/revenue", controllers.GetRevenueReport)
+		admin.GET("/reports/orders-by-status", controllers.GetOrdersByStatusReport)
+		admin.GET("/reports/average-order-value", controllers.GetAverageOrderValueReport)
+		admin.GET("/reports/top-products", controllers.GetTopProductsReport)
+		admin.GET("/reports/top-categories", controllers.GetTopCategoriesReport)
+		admin.GET("/reports/order-margins", controllers.GetOrderMarginsReport)
+		admin.GET("/reports/customers", controllers.GetCustomersReport)
+		admin.GET("/reports/cart-conversion", controllers.GetCartConversionReport)
+		admin.GET("/reviews", controllers.GetAllReviews)
+		admin.PUT("/reviews/:id/moderate", controllers.ModerateReview)
+	}
+
 	// Health check
 	api.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const cartTokenSubject = "guest_cart"
+
+type CartClaims struct {
+	GuestCartID uint `json:"guest_cart_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateCartToken signs a token identifying an anonymous visitor's cart.
+func GenerateCartToken(guestCartID uint, expiresAt time.Time) (string, error) {
+	claims := &CartClaims{
+		GuestCartID: guestCartID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   cartTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+func ValidateCartToken(tokenString string) (uint, error) {
+	claims := &CartClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid || claims.Subject != cartTokenSubject || claims.GuestCartID == 0 {
+		return 0, fmt.Errorf("invalid cart token")
+	}
+
+	return claims.GuestCartID, nil
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCartTokenRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	token, err := GenerateCartToken(42, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+	guestCartID, err := ValidateCartToken(token)
+	if err != nil {
+		t.Fatalf("ValidateCartToken() error = %v", err)
+	}
+	if guestCartID != 42 {
+		t.Errorf("ValidateCartToken() = %d, want 42", guestCartID)
+	}
+}
+
+func TestValidateCartTokenRejects(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	expired, err := GenerateCartToken(42, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+	noCart, err := GenerateCartToken(0, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+	userToken, err := GenerateJWT(42, "ada@example.com", "customer")
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	otherSecret, err := GenerateCartToken(42, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"empty":            "",
+		"garbage":          "not-a-token",
+		"expired":          expired,
+		"no guest cart":    noCart,
+		"user login token": userToken,
+	}
+	for name, token := range tests {
+		if _, err := ValidateCartToken(token); err == nil {
+			t.Errorf("%s: ValidateCartToken() returned no error", name)
+		}
+	}
+
+	t.Setenv("JWT_SECRET", "rotated-secret")
+	if _, err := ValidateCartToken(otherSecret); err == nil {
+		t.Error("ValidateCartToken() accepted a token signed with another secret")
+	}
+}
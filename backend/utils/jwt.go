@@ -7,6 +7,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// authTokenSubject marks login tokens. Cart and recovery tokens are signed
+// with the same secret, so the subject is what keeps them from being used to
+// log in.
+const authTokenSubject = "auth"
+
 type Claims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
@@ -20,6 +25,7 @@ func GenerateJWT(userID uint, email, role string) (string, error) {
 		Email:  email,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   authTokenSubject,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -47,7 +53,7 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if !token.Valid {
+	if !token.Valid || claims.Subject != authTokenSubject || claims.UserID == 0 {
 		return nil, fmt.Errorf("invalid token")
 	}
 
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJWTRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	token, err := GenerateJWT(7, "ada@example.com", "admin")
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	claims, err := ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if claims.UserID != 7 || claims.Email != "ada@example.com" || claims.Role != "admin" {
+		t.Errorf("ValidateJWT() = %+v, want user 7", claims)
+	}
+}
+
+func TestValidateJWTRejectsOtherTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	cartToken, err := GenerateCartToken(7, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+	recoveryToken, err := GenerateRecoveryToken(7, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateRecoveryToken() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"empty":          "",
+		"garbage":        "not-a-token",
+		"cart token":     cartToken,
+		"recovery token": recoveryToken,
+	}
+	for name, token := range tests {
+		if _, err := ValidateJWT(token); err == nil {
+			t.Errorf("%s: ValidateJWT() returned no error", name)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// OrderNumberPrefix is read from ORDER_NUMBER_PREFIX.
+func OrderNumberPrefix() string {
+	if prefix := os.Getenv("ORDER_NUMBER_PREFIX"); prefix != "" {
+		return prefix
+	}
+	return "ORD"
+}
+
+// InvoiceNumberPrefix is read from INVOICE_NUMBER_PREFIX.
+func InvoiceNumberPrefix() string {
+	if prefix := os.Getenv("INVOICE_NUMBER_PREFIX"); prefix != "" {
+		return prefix
+	}
+	return "INV"
+}
+
+// PurchaseOrderNumberPrefix is read from PURCHASE_ORDER_NUMBER_PREFIX.
+func PurchaseOrderNumberPrefix() string {
+	if prefix := os.Getenv("PURCHASE_ORDER_NUMBER_PREFIX"); prefix != "" {
+		return prefix
+	}
+	return "PO"
+}
+
+// OrderSequenceName is the sequence order numbers are drawn from. It restarts
+// every day because the date is part of the number.
+func OrderSequenceName(date time.Time) string {
+	return "order:" + date.Format("20060102")
+}
+
+// FormatOrderNumber builds numbers like ORD-20240131-000042-7 from the
+// prefix, the order date, the daily sequence and a Luhn check digit over the
+// date and sequence digits, which catches most typos when customers quote it.
+func FormatOrderNumber(prefix string, date time.Time, sequence int64) string {
+	digits := fmt.Sprintf("%s%06d", date.Format("20060102"), sequence)
+	return fmt.Sprintf("%s-%s-%06d-%d", prefix, date.Format("20060102"), sequence, LuhnCheckDigit(digits))
+}
+
+// ValidOrderNumber reports whether the check digit of an order number in the
+// FormatOrderNumber layout matches.
+func ValidOrderNumber(number string) bool {
+	parts := strings.Split(number, "-")
+	if len(parts) < 4 {
+		return false
+	}
+	n := len(parts)
+	check := parts[n-1]
+	digits := parts[n-3] + parts[n-2]
+	return len(check) == 1 && check == fmt.Sprint(LuhnCheckDigit(digits))
+}
+
+// FormatInvoiceNumber builds sequential invoice numbers like INV-000123.
+func FormatInvoiceNumber(prefix string, sequence int64) string {
+	return fmt.Sprintf("%s-%06d", prefix, sequence)
+}
+
+// FormatPurchaseOrderNumber builds sequential purchase order numbers like
+// PO-000045.
+func FormatPurchaseOrderNumber(prefix string, sequence int64) string {
+	return fmt.Sprintf("%s-%06d", prefix, sequence)
+}
+
+// LuhnCheckDigit returns the digit that makes digits+check pass the Luhn
+// algorithm. Non-digit characters are ignored.
+func LuhnCheckDigit(digits string) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if d < '0' || d > '9' {
+			continue
+		}
+		n := int(d - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLuhnCheckDigit(t *testing.T) {
+	tests := map[string]int{
+		"7992739871":     3,
+		"20240131000042": 3,
+		"20261019000001": 0,
+		"7992-7398-71":   3,
+		"":               0,
+	}
+	for digits, want := range tests {
+		if got := LuhnCheckDigit(digits); got != want {
+			t.Errorf("LuhnCheckDigit(%q) = %d, want %d", digits, got, want)
+		}
+	}
+}
+
+func TestFormatOrderNumber(t *testing.T) {
+	date := time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		prefix   string
+		sequence int64
+		want     string
+	}{
+		{"ORD", 42, "ORD-20240131-000042-3"},
+		{"SHOP-EU", 42, "SHOP-EU-20240131-000042-3"},
+		{"ORD", 1, "ORD-20240131-000001-" + strconv.Itoa(LuhnCheckDigit("20240131000001"))},
+	}
+	for _, tt := range tests {
+		got := FormatOrderNumber(tt.prefix, date, tt.sequence)
+		if got != tt.want {
+			t.Errorf("FormatOrderNumber(%q, %d) = %q, want %q", tt.prefix, tt.sequence, got, tt.want)
+		}
+		if !ValidOrderNumber(got) {
+			t.Errorf("ValidOrderNumber(%q) = false for a generated number", got)
+		}
+	}
+}
+
+func TestValidOrderNumber(t *testing.T) {
+	tests := map[string]bool{
+		"ORD-20240131-000042-3":     true,
+		"SHOP-EU-20240131-000042-3": true,
+		"ORD-20240131-000042-4":     false,
+		"ORD-20240131-000024-3":     false,
+		"ORD-20240113-000042-3":     false,
+		"ORD-20240131-000042-33":    false,
+		"ORD-20240131-000042":       false,
+		"":                          false,
+	}
+	for number, want := range tests {
+		if got := ValidOrderNumber(number); got != want {
+			t.Errorf("ValidOrderNumber(%q) = %v, want %v", number, got, want)
+		}
+	}
+}
+
+func TestOrderSequenceName(t *testing.T) {
+	date := time.Date(2024, 1, 31, 23, 59, 0, 0, time.UTC)
+	if got := OrderSequenceName(date); got != "order:20240131" {
+		t.Errorf("OrderSequenceName() = %q, want \"order:20240131\"", got)
+	}
+	if OrderSequenceName(date) == OrderSequenceName(date.Add(time.Minute)) {
+		t.Error("the order sequence does not restart on a new day")
+	}
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	if got := FormatInvoiceNumber("INV", 123); got != "INV-000123" {
+		t.Errorf("FormatInvoiceNumber() = %q, want \"INV-000123\"", got)
+	}
+	if got := FormatInvoiceNumber("INV", 1234567); got != "INV-1234567" {
+		t.Errorf("FormatInvoiceNumber() = %q, want \"INV-1234567\"", got)
+	}
+}
+
+func TestNumberPrefixes(t *testing.T) {
+	t.Setenv("ORDER_NUMBER_PREFIX", "")
+	t.Setenv("INVOICE_NUMBER_PREFIX", "")
+	if OrderNumberPrefix() != "ORD" || InvoiceNumberPrefix() != "INV" {
+		t.Errorf("default prefixes = %q and %q, want ORD and INV", OrderNumberPrefix(), InvoiceNumberPrefix())
+	}
+
+	t.Setenv("ORDER_NUMBER_PREFIX", "SHOP")
+	t.Setenv("INVOICE_NUMBER_PREFIX", "BILL")
+	if OrderNumberPrefix() != "SHOP" || InvoiceNumberPrefix() != "BILL" {
+		t.Errorf("configured prefixes = %q and %q, want SHOP and BILL", OrderNumberPrefix(), InvoiceNumberPrefix())
+	}
+}
+
+func TestFormatPurchaseOrderNumber(t *testing.T) {
+	if got := FormatPurchaseOrderNumber("PO", 45); got != "PO-000045" {
+		t.Errorf("FormatPurchaseOrderNumber() = %q, want \"PO-000045\"", got)
+	}
+	if got := FormatPurchaseOrderNumber("BUY", 1000000); got != "BUY-1000000" {
+		t.Errorf("FormatPurchaseOrderNumber() = %q, want \"BUY-1000000\"", got)
+	}
+}
+
+func TestPurchaseOrderNumberPrefix(t *testing.T) {
+	t.Setenv("PURCHASE_ORDER_NUMBER_PREFIX", "")
+	if got := PurchaseOrderNumberPrefix(); got != "PO" {
+		t.Errorf("PurchaseOrderNumberPrefix() = %q, want \"PO\"", got)
+	}
+	t.Setenv("PURCHASE_ORDER_NUMBER_PREFIX", "BUY")
+	if got := PurchaseOrderNumberPrefix(); got != "BUY" {
+		t.Errorf("PurchaseOrderNumberPrefix() = %q, want \"BUY\"", got)
+	}
+}
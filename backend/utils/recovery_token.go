@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const recoveryTokenSubject = "cart_recovery"
+
+type RecoveryClaims struct {
+	AbandonedCartID uint `json:"abandoned_cart_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateRecoveryToken signs the token carried by abandoned cart reminder
+// links.
+func GenerateRecoveryToken(abandonedCartID uint, expiresAt time.Time) (string, error) {
+	claims := &RecoveryClaims{
+		AbandonedCartID: abandonedCartID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   recoveryTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+func ValidateRecoveryToken(tokenString string) (uint, error) {
+	claims := &RecoveryClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid || claims.Subject != recoveryTokenSubject || claims.AbandonedCartID == 0 {
+		return 0, fmt.Errorf("invalid recovery token")
+	}
+
+	return claims.AbandonedCartID, nil
+}
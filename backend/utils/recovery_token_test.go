@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecoveryTokenRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	token, err := GenerateRecoveryToken(17, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateRecoveryToken() error = %v", err)
+	}
+	abandonedCartID, err := ValidateRecoveryToken(token)
+	if err != nil {
+		t.Fatalf("ValidateRecoveryToken() error = %v", err)
+	}
+	if abandonedCartID != 17 {
+		t.Errorf("ValidateRecoveryToken() = %d, want 17", abandonedCartID)
+	}
+}
+
+func TestValidateRecoveryTokenRejects(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	expired, err := GenerateRecoveryToken(17, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GenerateRecoveryToken() error = %v", err)
+	}
+	cartToken, err := GenerateCartToken(17, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateCartToken() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"empty":      "",
+		"expired":    expired,
+		"cart token": cartToken,
+	}
+	for name, token := range tests {
+		if _, err := ValidateRecoveryToken(token); err == nil {
+			t.Errorf("%s: ValidateRecoveryToken() returned no error", name)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+func Slugify(s string) string {
+	var b strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			lastDash = false
+		case r == '&':
+			if !lastDash {
+				b.WriteRune('-')
+			}
+			b.WriteString("and-")
+			lastDash = true
+		default:
+			if !lastDash {
+				b.WriteRune('-')
+				lastDash = true
+			}
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"lowercases words", "Home Office", "home-office"},
+		{"keeps digits", "4K TVs", "4k-tvs"},
+		{"collapses separators", "Shoes  --  Boots", "shoes-boots"},
+		{"trims edges", "  ~Garden!  ", "garden"},
+		{"spells out ampersand", "Bath & Body", "bath-and-body"},
+		{"ampersand without spaces", "Arts&Crafts", "arts-and-crafts"},
+		{"leading ampersand", "& more", "and-more"},
+		{"drops non ascii letters", "Café Crème", "caf-cr-me"},
+		{"empty", "", ""},
+		{"only symbols", "!!!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateToken returns a random hex string built from n random bytes.
+func GenerateToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// HashToken returns the SHA-256 hex digest of a token, for storing secrets
+// that are only ever compared, never read back.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSKU returns a random SKU like SKU-3F9A0C21, for products created
+// without one.
+func GenerateSKU() (string, error) {
+	token, err := GenerateToken(4)
+	if err != nil {
+		return "", err
+	}
+	return "SKU-" + strings.ToUpper(token), nil
+}
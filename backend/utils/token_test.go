@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestGenerateToken(t *testing.T) {
+	token, err := GenerateToken(16)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if len(token) != 32 {
+		t.Errorf("len(token) = %d, want 32 hex characters", len(token))
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		t.Errorf("token %q is not hex: %v", token, err)
+	}
+
+	other, err := GenerateToken(16)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if token == other {
+		t.Errorf("two tokens are both %q", token)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	// SHA-256 of "abc"
+	if got, want := HashToken("abc"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("HashToken(\"abc\") = %q, want %q", got, want)
+	}
+	if HashToken("abc") == HashToken("abd") {
+		t.Error("different tokens hash to the same value")
+	}
+}
+
+func TestGenerateSKU(t *testing.T) {
+	sku, err := GenerateSKU()
+	if err != nil {
+		t.Fatalf("GenerateSKU() error = %v", err)
+	}
+	if len(sku) != 12 || sku[:4] != "SKU-" || sku != strings.ToUpper(sku) {
+		t.Errorf("GenerateSKU() = %q, want SKU- and 8 uppercase hex characters", sku)
+	}
+}
@@ -0,0 +1,113 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestSerialDate(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want float64
+	}{
+		{"epoch", time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC), 0},
+		{"1900-01-01", time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), 2},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 45292},
+		{"noon", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), 45292.5},
+		{"local wall clock", time.Date(2024, 1, 1, 6, 0, 0, 0, berlin), 45292.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serialDate(tt.t); got != tt.want {
+				t.Errorf("serialDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Orders & Co")
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	w.WriteRow("Name", "Count", "Total", "Paid", "Placed At")
+	w.WriteRow("<Widget>", 3, 9.5, true, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	w.WriteRow(nil, int64(4))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(r)
+		r.Close()
+		parts[f.Name] = string(body)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook is missing %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Orders &amp; Co"`) {
+		t.Errorf("workbook.xml = %s, want escaped sheet name", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">Name</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;Widget&gt;</t></is></c>`,
+		`<c r="B2"><v>3</v></c>`,
+		`<c r="C2"><v>9.5</v></c>`,
+		`<c r="D2" t="b"><v>1</v></c>`,
+		`<c r="E2" s="1"><v>45292.500000</v></c>`,
+		`<row r="3"><c r="B3"><v>4</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet1.xml missing %s", want)
+		}
+	}
+	if !strings.HasSuffix(sheet, `</sheetData></worksheet>`) {
+		t.Errorf("sheet1.xml is not closed: %s", sheet)
+	}
+}
@@ -3,6 +3,7 @@ import { Link } from 'react-router-dom';
 import { Order, Product } from '../../types';
 import { orderService } from '../../services/orderService';
 import { productService } from '../../services/productService';
+import { reportService } from '../../services/reportService';
 import LoadingSpinner from '../../components/LoadingSpinner';
 import { 
   ShoppingBagIcon, 
@@ -36,24 +37,23 @@ const Dashboard: React.FC = () => {
     try {
       setLoading(true);
       
-      // Fetch orders and products
-      const [ordersResponse, productsResponse] = await Promise.all([
+      // Fetch recent orders, products and the last 30 days' aggregates
+      const [ordersResponse, productsResponse, statusReport, revenueReport] = await Promise.all([
         orderService.getAllOrders({ limit: 10 }),
-        productService.getProducts({ limit: 1000 })
+        productService.getProducts({ limit: 1000, admin: true }),
+        reportService.getOrdersByStatus(),
+        reportService.getAverageOrderValue()
       ]);
 
       const orders = ordersResponse.data || [];
       const products = productsResponse.data || [];
-
-      // Calculate stats
-      const totalRevenue = orders.reduce((sum, order) => sum + order.totalAmount, 0);
-      const pendingOrders = orders.filter(order => order.status === 'pending').length;
+      const statuses = statusReport.statuses || [];
 
       setStats({
-        totalOrders: orders.length,
+        totalOrders: statuses.reduce((sum, status) => sum + status.orders, 0),
         totalProducts: products.length,
-        totalRevenue,
-        pendingOrders,
+        totalRevenue: revenueReport.revenue,
+        pendingOrders: statuses.find(status => status.status === 'pending')?.orders || 0,
       });
 
       setRecentOrders(orders.slice(0, 5));
@@ -16,6 +16,7 @@ const schema = yup.object().shape({
   stock: yup.number().min(0, 'Stock cannot be negative').required('Stock is required'),
   categoryId: yup.string().required('Category is required'),
   image: yup.string().url('Must be a valid URL').optional(),
+  status: yup.string().oneOf(['draft', 'active', 'archived']).required('Status is required'),
 });
 
 const Products: React.FC = () => {
@@ -33,7 +34,8 @@ const Products: React.FC = () => {
     reset,
     setValue
   } = useForm<ProductRequest>({
-    resolver: yupResolver(schema)
+    resolver: yupResolver(schema),
+    defaultValues: { status: 'active' }
   });
 
   useEffect(() => {
@@ -44,7 +46,7 @@ const Products: React.FC = () => {
     try {
       setLoading(true);
       const [productsResponse, categoriesResponse] = await Promise.all([
-        productService.getProducts({ limit: 1000 }),
+        productService.getProducts({ limit: 1000, admin: true }),
         categoryService.getCategories()
       ]);
       
@@ -88,6 +90,7 @@ const Products: React.FC = () => {
     setValue('stock', product.stock);
     setValue('categoryId', product.categoryId);
     setValue('image', product.image || '');
+    setValue('status', product.status || 'active');
     setShowForm(true);
   };
 
@@ -231,6 +234,23 @@ const Products: React.FC = () => {
                 )}
               </div>
 
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Status
+                </label>
+                <select
+                  {...register('status')}
+                  className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-blue-500 focus:border-blue-500"
+                >
+                  <option value="draft">Draft (hidden from the store)</option>
+                  <option value="active">Active (visible in the store)</option>
+                  <option value="archived">Archived</option>
+                </select>
+                {errors.status && (
+                  <p className="mt-1 text-sm text-red-600">{errors.status.message}</p>
+                )}
+              </div>
+
               <div>
                 <label className="block text-sm font-medium text-gray-700 mb-1">
                   Image URL (optional)
@@ -343,6 +363,11 @@ const Products: React.FC = () => {
                         <div className="ml-4">
                           <div className="text-sm font-medium text-gray-900">
                             {product.name}
+                            {product.status && product.status !== 'active' && (
+                              <span className="ml-2 inline-flex px-2 py-0.5 text-xs font-semibold rounded-full bg-gray-100 text-gray-700 capitalize">
+                                {product.status}
+                              </span>
+                            )}
                           </div>
                           <div className="text-sm text-gray-500 truncate max-w-xs">
                             {product.description}
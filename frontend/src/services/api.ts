@@ -25,6 +25,11 @@ class ApiService {
         if (token && config.headers) {
           config.headers.Authorization = `Bearer ${token}`;
         }
+        // Guest cart token, merged into the user's cart on login/register
+        const cartToken = localStorage.getItem('cart_token');
+        if (cartToken && config.headers) {
+          config.headers['X-Cart-Token'] = cartToken;
+        }
         return config;
       },
       (error) => {
@@ -35,6 +40,10 @@ class ApiService {
     // Response interceptor for error handling
     this.api.interceptors.response.use(
       (response: AxiosResponse) => {
+        const cartToken = response.headers['x-cart-token'];
+        if (cartToken) {
+          localStorage.setItem('cart_token', cartToken);
+        }
         return response;
       },
       (error) => {
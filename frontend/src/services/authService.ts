@@ -20,6 +20,8 @@ class AuthService {
         const { user, token } = response;
         apiService.setAuthToken(token);
         localStorage.setItem('user', JSON.stringify(user));
+        // The guest cart has been merged into the user's cart
+        localStorage.removeItem('cart_token');
         return { user, token };
       }
       throw new Error(response?.message || 'Login failed');
@@ -36,6 +38,7 @@ class AuthService {
       
       if (response && response.user && response.token) {
         const { user, token } = response;
+        localStorage.removeItem('cart_token');
         // Don't auto-login after registration, let user login manually
         return { user, token };
       }
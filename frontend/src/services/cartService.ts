@@ -2,11 +2,12 @@ import apiService from './api';
 import { 
   ApiResponse, 
   CartItem, 
-  CartItemRequest 
+  CartItemRequest,
+  CartWarning 
 } from '../types';
 
 class CartService {
-  async getCart(): Promise<{ cart_items: CartItem[], total_amount: number, total_items: number }> {
+  async getCart(): Promise<{ cart_items: CartItem[], saved_items: CartItem[], total_amount: number, total_items: number, warnings: CartWarning[] }> {
     try {
       const response = await apiService.get('/cart');
       console.log('Cart API response:', response);
@@ -17,6 +18,10 @@ class CartService {
     }
   }
 
+  async adjustCart(): Promise<{ cart_items: CartItem[], saved_items: CartItem[], total_amount: number, total_items: number, warnings: CartWarning[] }> {
+    return apiService.post('/cart/adjust');
+  }
+
   async addToCart(productId: string | number, quantity: number): Promise<CartItem> {
     try {
       const cartData = { 
@@ -54,6 +59,21 @@ class CartService {
     }
   }
 
+  async saveForLater(itemId: string | number): Promise<CartItem> {
+    const response = await apiService.post<{ cart_item: CartItem }>(`/cart/item/${itemId}/save-for-later`);
+    return response.cart_item;
+  }
+
+  async moveToCart(itemId: string | number): Promise<CartItem> {
+    const response = await apiService.post<{ cart_item: CartItem }>(`/cart/item/${itemId}/move-to-cart`);
+    return response.cart_item;
+  }
+
+  async updateCartItemNote(itemId: string | number, note: string): Promise<CartItem> {
+    const response = await apiService.put<{ cart_item: CartItem }>(`/cart/item/${itemId}`, { note });
+    return response.cart_item;
+  }
+
   async clearCart(): Promise<void> {
     try {
       const response = await apiService.delete('/cart/clear');
@@ -0,0 +1,101 @@
+import apiService from './api';
+import {
+  Product,
+  StockMovementReason,
+  StockMovementsResponse,
+  StockAdjustmentRequest,
+  LowStockReport,
+  AllocationRule,
+  Warehouse,
+  WarehouseSummary,
+  WarehouseRequest,
+  WarehouseStock,
+  StockTransfer,
+  StockTransferRequest,
+  OrderItemAllocation,
+  ProductCost
+} from '../types';
+
+class InventoryService {
+  async getLowStock(options: { category_id?: number; include_archived?: boolean } = {}): Promise<LowStockReport> {
+    const params = new URLSearchParams();
+    if (options.category_id) params.append('category_id', String(options.category_id));
+    if (options.include_archived) params.append('include_archived', 'true');
+    return apiService.get<LowStockReport>(`/admin/inventory/low-stock?${params.toString()}`);
+  }
+
+  async getMovements(filters: {
+    product_id?: number;
+    reason?: StockMovementReason;
+    from?: string;
+    to?: string;
+    page?: number;
+    limit?: number;
+  } = {}): Promise<StockMovementsResponse> {
+    const params = new URLSearchParams();
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') {
+        params.append(key, String(value));
+      }
+    });
+    params.append('tz', Intl.DateTimeFormat().resolvedOptions().timeZone);
+    return apiService.get<StockMovementsResponse>(`/admin/inventory/movements?${params.toString()}`);
+  }
+
+  async adjustStock(adjustment: StockAdjustmentRequest): Promise<{ message: string; product: Product }> {
+    return apiService.post<{ message: string; product: Product }>('/admin/inventory/adjustments', adjustment);
+  }
+
+  async setLowStockThreshold(productId: number, threshold: number | null): Promise<{ message: string; low_stock_threshold: number | null; effective_threshold: number }> {
+    return apiService.put(`/admin/products/${productId}/low-stock-threshold`, { threshold });
+  }
+
+  async getProductCosts(productId: number, page = 1, limit = 20): Promise<{ product_id: number; cost: number | null; costs: ProductCost[]; total: number; page: number; limit: number; total_pages: number }> {
+    return apiService.get(`/admin/products/${productId}/costs?page=${page}&limit=${limit}`);
+  }
+
+  async setProductCost(productId: number, cost: number, note?: string): Promise<{ message: string; product_id: number; cost: number }> {
+    return apiService.put(`/admin/products/${productId}/cost`, { cost, note });
+  }
+
+  async getWarehouses(): Promise<{ warehouses: WarehouseSummary[]; allocation_rule: AllocationRule }> {
+    return apiService.get<{ warehouses: WarehouseSummary[]; allocation_rule: AllocationRule }>('/admin/warehouses');
+  }
+
+  async createWarehouse(warehouse: WarehouseRequest): Promise<{ message: string; warehouse: Warehouse }> {
+    return apiService.post<{ message: string; warehouse: Warehouse }>('/admin/warehouses', warehouse);
+  }
+
+  async updateWarehouse(id: number, warehouse: WarehouseRequest): Promise<{ message: string; warehouse: Warehouse }> {
+    return apiService.put<{ message: string; warehouse: Warehouse }>(`/admin/warehouses/${id}`, warehouse);
+  }
+
+  async getWarehouseStock(id: number, page = 1, limit = 20): Promise<{ warehouse: Warehouse; stock: WarehouseStock[]; total: number; page: number; limit: number; total_pages: number }> {
+    return apiService.get(`/admin/warehouses/${id}/stock?page=${page}&limit=${limit}`);
+  }
+
+  async getProductStockLevels(productId: number): Promise<{ product_id: number; stock: number; levels: WarehouseStock[] }> {
+    return apiService.get(`/admin/products/${productId}/stock-levels`);
+  }
+
+  async getTransfers(filters: { product_id?: number; warehouse_id?: number; page?: number; limit?: number } = {}): Promise<{ transfers: StockTransfer[]; total: number; page: number; limit: number; total_pages: number }> {
+    const params = new URLSearchParams();
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value !== undefined) {
+        params.append(key, String(value));
+      }
+    });
+    return apiService.get(`/admin/stock-transfers?${params.toString()}`);
+  }
+
+  async transferStock(transfer: StockTransferRequest): Promise<{ message: string; transfer: StockTransfer }> {
+    return apiService.post<{ message: string; transfer: StockTransfer }>('/admin/stock-transfers', transfer);
+  }
+
+  async getOrderAllocations(orderId: number): Promise<{ order_id: number; allocations: OrderItemAllocation[] }> {
+    return apiService.get(`/orders/${orderId}/allocations`);
+  }
+}
+
+export const inventoryService = new InventoryService();
+export default inventoryService;
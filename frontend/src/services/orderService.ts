@@ -3,7 +3,15 @@ import {
   ApiResponse, 
   PaginatedResponse, 
   Order, 
-  OrderRequest 
+  OrderRequest,
+  CheckoutQuote,
+  CheckoutQuoteRequest,
+  OrderEdit,
+  CartItem,
+  CartWarning,
+  OrderSubscription,
+  OrderSubscriptionRequest,
+  OrderSearchFilters
 } from '../types';
 
 class OrderService {
@@ -26,9 +34,40 @@ class OrderService {
     throw new Error(response.message || 'Failed to get order');
   }
 
-  async createOrder(orderData: OrderRequest): Promise<Order> {
+  async getInvoice(id: string | number): Promise<Blob> {
+    return apiService.get<Blob>(`/orders/${id}/invoice.pdf`, { responseType: 'blob' });
+  }
+
+  async reorder(id: string | number): Promise<{ items: CartItem[]; warnings: CartWarning[] }> {
+    return apiService.post<{ items: CartItem[]; warnings: CartWarning[] }>(`/orders/${id}/reorder`);
+  }
+
+  async getSubscriptions(): Promise<OrderSubscription[]> {
+    const response = await apiService.get<{ subscriptions: OrderSubscription[] }>('/order-subscriptions');
+    return response.subscriptions;
+  }
+
+  async createSubscription(data: OrderSubscriptionRequest): Promise<OrderSubscription> {
+    const response = await apiService.post<{ subscription: OrderSubscription }>('/order-subscriptions', data);
+    return response.subscription;
+  }
+
+  async updateSubscription(id: number, action: 'pause' | 'resume' | 'skip' | 'cancel'): Promise<OrderSubscription> {
+    const response = await apiService.post<{ subscription: OrderSubscription }>(`/order-subscriptions/${id}/${action}`);
+    return response.subscription;
+  }
+
+  async getQuote(quoteData: CheckoutQuoteRequest): Promise<CheckoutQuote> {
+    const response = await apiService.post<{ quote: CheckoutQuote }>('/checkout/quote', quoteData);
+    return response.quote;
+  }
+
+  // Reuse the same idempotencyKey when retrying a submission so the order is only created once
+  async createOrder(orderData: OrderRequest, idempotencyKey: string = crypto.randomUUID()): Promise<Order> {
     try {
-      const response = await apiService.post<any>('/orders', orderData);
+      const response = await apiService.post<any>('/orders', orderData, {
+        headers: { 'Idempotency-Key': idempotencyKey },
+      });
       
       // Handle backend response format: { message: "...", order: {...} }
       if (response.order) {
@@ -57,22 +96,73 @@ class OrderService {
     throw new Error(response.message || 'Failed to update order status');
   }
 
-  async getAllOrders(options?: { 
+  async fulfillOrderItem(orderId: string | number, itemId: number, quantity: number): Promise<Order> {
+    const response = await apiService.post<{ order: Order }>(`/orders/${orderId}/items/${itemId}/fulfill`, { quantity });
+    return response.order;
+  }
+
+  async cancelOrderItem(orderId: string | number, itemId: number, quantity: number): Promise<{ order: Order; refund_amount: number }> {
+    return apiService.post<{ order: Order; refund_amount: number }>(`/orders/${orderId}/items/${itemId}/cancel`, { quantity });
+  }
+
+  async returnOrderItem(orderId: string | number, itemId: number, quantity: number, restock: boolean = true): Promise<{ order: Order; refund_amount: number }> {
+    return apiService.post<{ order: Order; refund_amount: number }>(`/orders/${orderId}/items/${itemId}/return`, { quantity, restock });
+  }
+
+  async getOrderEdits(orderId: string | number): Promise<OrderEdit[]> {
+    const response = await apiService.get<{ edits: OrderEdit[] }>(`/orders/${orderId}/edits`);
+    return response.edits;
+  }
+
+  async updateShippingAddress(orderId: string | number, shippingAddress: string, reason?: string): Promise<Order> {
+    const response = await apiService.put<{ order: Order }>(`/orders/${orderId}/shipping-address`, { shipping_address: shippingAddress, reason });
+    return response.order;
+  }
+
+  async addOrderItem(orderId: string | number, productId: number, quantity: number, reason?: string): Promise<Order> {
+    const response = await apiService.post<{ order: Order }>(`/orders/${orderId}/items`, { product_id: productId, quantity, reason });
+    return response.order;
+  }
+
+  async updateOrderItem(orderId: string | number, itemId: number, changes: { quantity?: number; price?: number; reason?: string }): Promise<Order> {
+    const response = await apiService.put<{ order: Order }>(`/orders/${orderId}/items/${itemId}`, changes);
+    return response.order;
+  }
+
+  async removeOrderItem(orderId: string | number, itemId: number, reason?: string): Promise<Order> {
+    const params = new URLSearchParams(reason ? { reason } : {});
+    const response = await apiService.delete<{ order: Order }>(`/orders/${orderId}/items/${itemId}?${params.toString()}`);
+    return response.order;
+  }
+
+  async getAllOrders(options?: OrderSearchFilters & { 
     page?: number; 
     limit?: number; 
-    status?: Order['status'];
   }): Promise<PaginatedResponse<Order[]>> {
-    const { page = 1, limit = 10, status } = options || {};
-    const params = new URLSearchParams({
-      page: page.toString(),
-      limit: limit.toString(),
-    });
-    
-    if (status) params.append('status', status);
+    const { page = 1, limit = 10, ...filters } = options || {};
+    const params = this.filterParams(filters);
+    params.set('page', page.toString());
+    params.set('limit', limit.toString());
     
     return apiService.get<PaginatedResponse<Order[]>>(`/admin/orders?${params.toString()}`);
   }
 
+  async exportOrders(format: 'csv' | 'xlsx', filters: OrderSearchFilters = {}): Promise<Blob> {
+    const params = this.filterParams(filters);
+    params.set('format', format);
+    return apiService.get<Blob>(`/orders/export?${params.toString()}`, { responseType: 'blob' });
+  }
+
+  private filterParams(filters: OrderSearchFilters): URLSearchParams {
+    const params = new URLSearchParams();
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value !== undefined && value !== null && value !== '') {
+        params.append(key, String(value));
+      }
+    });
+    return params;
+  }
+
   async cancelOrder(id: string): Promise<Order> {
     return this.updateOrderStatus(id, 'cancelled');
   }
@@ -13,9 +13,10 @@ class ProductService {
     category?: string;
     search?: string;
     categoryIds?: string;
+    admin?: boolean;
   }): Promise<{ products: Product[]; total: number; page: number; limit: number; total_pages: number }> {
     try {
-      const { page = 1, limit = 10, category, search, categoryIds } = options || {};
+      const { page = 1, limit = 10, category, search, categoryIds, admin } = options || {};
       const params = new URLSearchParams({
         page: page.toString(),
         limit: limit.toString(),
@@ -24,8 +25,10 @@ class ProductService {
       if (search) params.append('search', search);
       if (categoryIds) params.append('category_id', categoryIds);
       
-      console.log('API call URL:', `/products?${params.toString()}`);
-      const response = await apiService.get(`/products?${params.toString()}`);
+      // Admin listings include drafts, scheduled and archived products
+      const basePath = admin ? '/admin/products' : '/products';
+      console.log('API call URL:', `${basePath}?${params.toString()}`);
+      const response = await apiService.get(`${basePath}?${params.toString()}`);
       console.log('Raw API response:', response);
       
       // Handle response structure - backend returns direct object
@@ -0,0 +1,93 @@
+import apiService from './api';
+import {
+  Supplier,
+  SupplierRequest,
+  SupplierProduct,
+  SupplierProductRequest,
+  PurchaseOrder,
+  PurchaseOrderStatus,
+  PurchaseOrderRequest,
+  ReceivePurchaseOrderRequest,
+  ReorderSuggestionsReport
+} from '../types';
+
+class PurchasingService {
+  async getSuppliers(activeOnly = false): Promise<{ suppliers: Supplier[] }> {
+    return apiService.get<{ suppliers: Supplier[] }>(`/admin/suppliers${activeOnly ? '?active=true' : ''}`);
+  }
+
+  async createSupplier(supplier: SupplierRequest): Promise<{ message: string; supplier: Supplier }> {
+    return apiService.post<{ message: string; supplier: Supplier }>('/admin/suppliers', supplier);
+  }
+
+  async updateSupplier(id: number, supplier: SupplierRequest): Promise<{ message: string; supplier: Supplier }> {
+    return apiService.put<{ message: string; supplier: Supplier }>(`/admin/suppliers/${id}`, supplier);
+  }
+
+  async deleteSupplier(id: number): Promise<{ message: string }> {
+    return apiService.delete<{ message: string }>(`/admin/suppliers/${id}`);
+  }
+
+  async getSupplierProducts(supplierId: number): Promise<{ supplier: Supplier; products: SupplierProduct[] }> {
+    return apiService.get(`/admin/suppliers/${supplierId}/products`);
+  }
+
+  async getProductSuppliers(productId: number): Promise<{ suppliers: SupplierProduct[] }> {
+    return apiService.get(`/admin/products/${productId}/suppliers`);
+  }
+
+  async setSupplierProduct(supplierId: number, productId: number, supplierProduct: SupplierProductRequest): Promise<{ message: string; supplier_product: SupplierProduct }> {
+    return apiService.put(`/admin/suppliers/${supplierId}/products/${productId}`, supplierProduct);
+  }
+
+  async deleteSupplierProduct(supplierId: number, productId: number): Promise<{ message: string }> {
+    return apiService.delete<{ message: string }>(`/admin/suppliers/${supplierId}/products/${productId}`);
+  }
+
+  async getPurchaseOrders(filters: { status?: PurchaseOrderStatus; supplier_id?: number; page?: number; limit?: number } = {}): Promise<{ purchase_orders: PurchaseOrder[]; total: number; page: number; limit: number; total_pages: number }> {
+    const params = new URLSearchParams();
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value !== undefined) {
+        params.append(key, String(value));
+      }
+    });
+    return apiService.get(`/admin/purchase-orders?${params.toString()}`);
+  }
+
+  async getPurchaseOrder(id: number): Promise<{ purchase_order: PurchaseOrder }> {
+    return apiService.get<{ purchase_order: PurchaseOrder }>(`/admin/purchase-orders/${id}`);
+  }
+
+  async createPurchaseOrder(order: PurchaseOrderRequest): Promise<{ message: string; purchase_order: PurchaseOrder }> {
+    return apiService.post<{ message: string; purchase_order: PurchaseOrder }>('/admin/purchase-orders', order);
+  }
+
+  async updatePurchaseOrder(id: number, order: PurchaseOrderRequest): Promise<{ message: string; purchase_order: PurchaseOrder }> {
+    return apiService.put<{ message: string; purchase_order: PurchaseOrder }>(`/admin/purchase-orders/${id}`, order);
+  }
+
+  async submitPurchaseOrder(id: number): Promise<{ message: string; purchase_order: PurchaseOrder }> {
+    return apiService.post<{ message: string; purchase_order: PurchaseOrder }>(`/admin/purchase-orders/${id}/submit`);
+  }
+
+  async receivePurchaseOrder(id: number, receipt: ReceivePurchaseOrderRequest): Promise<{ message: string; purchase_order: PurchaseOrder }> {
+    return apiService.post<{ message: string; purchase_order: PurchaseOrder }>(`/admin/purchase-orders/${id}/receive`, receipt);
+  }
+
+  async cancelPurchaseOrder(id: number): Promise<{ message: string; purchase_order: PurchaseOrder }> {
+    return apiService.post<{ message: string; purchase_order: PurchaseOrder }>(`/admin/purchase-orders/${id}/cancel`);
+  }
+
+  async getReorderSuggestions(options: { days?: number; cover_days?: number; supplier_id?: number } = {}): Promise<ReorderSuggestionsReport> {
+    const params = new URLSearchParams();
+    Object.entries(options).forEach(([key, value]) => {
+      if (value !== undefined) {
+        params.append(key, String(value));
+      }
+    });
+    return apiService.get<ReorderSuggestionsReport>(`/admin/inventory/reorder-suggestions?${params.toString()}`);
+  }
+}
+
+export const purchasingService = new PurchasingService();
+export default purchasingService;
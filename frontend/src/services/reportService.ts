@@ -0,0 +1,60 @@
+import apiService from './api';
+import {
+  ReportRange,
+  RevenueReport,
+  OrdersByStatusReport,
+  AverageOrderValueReport,
+  TopProductsReport,
+  TopCategoriesReport,
+  OrderMarginsReport,
+  CustomersReport,
+  CartConversionReport
+} from '../types';
+
+class ReportService {
+  private params(range: ReportRange = {}, extra: Record<string, string | number | undefined> = {}): string {
+    const params = new URLSearchParams();
+    const tz = range.tz || Intl.DateTimeFormat().resolvedOptions().timeZone;
+    Object.entries({ ...range, tz, ...extra }).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') {
+        params.append(key, String(value));
+      }
+    });
+    return params.toString();
+  }
+
+  async getRevenue(range?: ReportRange, interval: 'day' | 'week' | 'month' = 'day'): Promise<RevenueReport> {
+    return apiService.get<RevenueReport>(`/admin/reports/revenue?${this.params(range, { interval })}`);
+  }
+
+  async getOrdersByStatus(range?: ReportRange): Promise<OrdersByStatusReport> {
+    return apiService.get<OrdersByStatusReport>(`/admin/reports/orders-by-status?${this.params(range)}`);
+  }
+
+  async getAverageOrderValue(range?: ReportRange): Promise<AverageOrderValueReport> {
+    return apiService.get<AverageOrderValueReport>(`/admin/reports/average-order-value?${this.params(range)}`);
+  }
+
+  async getTopProducts(range?: ReportRange, options: { limit?: number; sort?: 'revenue' | 'quantity' | 'margin' } = {}): Promise<TopProductsReport> {
+    return apiService.get<TopProductsReport>(`/admin/reports/top-products?${this.params(range, options)}`);
+  }
+
+  async getTopCategories(range?: ReportRange, options: { limit?: number; sort?: 'revenue' | 'quantity' | 'margin' } = {}): Promise<TopCategoriesReport> {
+    return apiService.get<TopCategoriesReport>(`/admin/reports/top-categories?${this.params(range, options)}`);
+  }
+
+  async getOrderMargins(range?: ReportRange, options: { limit?: number; sort?: 'highest' | 'lowest' } = {}): Promise<OrderMarginsReport> {
+    return apiService.get<OrderMarginsReport>(`/admin/reports/order-margins?${this.params(range, options)}`);
+  }
+
+  async getCustomers(range?: ReportRange): Promise<CustomersReport> {
+    return apiService.get<CustomersReport>(`/admin/reports/customers?${this.params(range)}`);
+  }
+
+  async getCartConversion(range?: ReportRange): Promise<CartConversionReport> {
+    return apiService.get<CartConversionReport>(`/admin/reports/cart-conversion?${this.params(range)}`);
+  }
+}
+
+export const reportService = new ReportService();
+export default reportService;
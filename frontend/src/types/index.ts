@@ -12,42 +12,96 @@ export interface User {
 export interface Category {
   id: number;
   name: string;
+  slug?: string;
   description?: string;
   image?: string;
+  parent_id?: number | null;
+  depth?: number;
+  sort_order?: number;
+  children?: Category[];
   created_at: string;
   createdAt?: string; // For backward compatibility
 }
 
 export interface Product {
   id: number;
+  sku?: string;
   name: string;
   description: string;
   price: number;
   stock: number;
+  low_stock_threshold?: number | null;
+  cost?: number | null;
   image?: string;
   category_id: number;
   categoryId?: number; // For backward compatibility
   category?: Category;
+  status?: 'draft' | 'active' | 'archived';
+  publish_at?: string | null;
+  unpublish_at?: string | null;
+  archived_at?: string | null;
+  average_rating?: number;
+  review_count?: number;
+  breadcrumbs?: Breadcrumb[];
+  attributes?: ProductAttribute[];
   created_at: string;
   createdAt?: string; // For backward compatibility
 }
 
+export interface Breadcrumb {
+  id: number;
+  name: string;
+  slug: string;
+}
+
+export interface ProductAttribute {
+  attribute_id: number;
+  code: string;
+  name: string;
+  type: 'text' | 'number' | 'enum' | 'boolean';
+  unit?: string;
+  value: string | number | boolean | null;
+}
+
 export interface CartItem {
   id: number;
-  user_id: number;
+  user_id: number | null;
   product_id: number;
   quantity: number;
+  price_at_add: number;
+  saved_for_later: boolean;
+  note: string;
   product?: Product;
   created_at: string;
   subtotal: number;
 }
 
+export interface CartWarning {
+  cart_item_id: number;
+  product_id: number;
+  product_name: string;
+  type: 'price_increased' | 'price_decreased' | 'stock_reduced' | 'out_of_stock' | 'unavailable';
+  message: string;
+  previous_price?: number;
+  current_price?: number;
+  requested_quantity?: number;
+  available_quantity: number;
+  adjusted: boolean;
+}
+
 export interface OrderItem {
   id: number;
   order_id: number;
   product_id: number;
   quantity: number;
+  note?: string;
   price: number;
+  status: 'pending' | 'partially_fulfilled' | 'fulfilled' | 'partially_returned' | 'returned' | 'cancelled';
+  fulfilled_quantity: number;
+  cancelled_quantity: number;
+  returned_quantity: number;
+  open_quantity: number;
+  refunded_amount: number;
   product?: Product;
   
   // Backward compatibility
@@ -57,9 +111,23 @@ export interface OrderItem {
 
 export interface Order {
   id: number;
-  user_id: number;
+  order_number: string;
+  invoice_number?: string;
+  user_id: number | null;
+  guest_email?: string;
+  guest_name?: string;
+  is_guest: boolean;
+  subscription_id?: number;
+  subtotal: number;
+  discount_amount: number;
+  shipping_amount: number;
+  tax_amount: number;
+  tax_rate: number;
   total_amount: number;
-  status: 'pending' | 'processing' | 'shipped' | 'delivered' | 'cancelled';
+  refunded_amount: number;
+  coupon_code?: string;
+  shipping_method: string;
+  status: 'pending' | 'confirmed' | 'processing' | 'partially_shipped' | 'shipped' | 'delivered' | 'cancelled' | 'returned';
   shipping_address: string;
   payment_method: string;
   created_at: string;
@@ -74,6 +142,403 @@ export interface Order {
   orderItems?: OrderItem[];
 }
 
+export interface OrderSubscriptionItem {
+  id: number;
+  subscription_id: number;
+  product_id: number;
+  quantity: number;
+  product?: Product;
+}
+
+export interface OrderSubscription {
+  id: number;
+  user_id: number;
+  frequency: 'weekly' | 'monthly';
+  status: 'active' | 'paused' | 'cancelled';
+  shipping_address: string;
+  payment_method: string;
+  shipping_method: string;
+  next_run_at: string;
+  last_run_at?: string | null;
+  last_order_id?: number | null;
+  last_error: string;
+  cancelled_at?: string | null;
+  items: OrderSubscriptionItem[];
+  created_at: string;
+}
+
+export interface OrderSubscriptionRequest {
+  frequency: 'weekly' | 'monthly';
+  shipping_address: string;
+  payment_method: string;
+  shipping_method?: string;
+  start_at?: string;
+  items?: { product_id: number; quantity: number }[];
+  order_id?: number;
+}
+
+export interface OrderSearchFilters {
+  status?: Order['status'];
+  from?: string;
+  to?: string;
+  tz?: string;
+  email?: string;
+  name?: string;
+  min_total?: number;
+  max_total?: number;
+  product_id?: number;
+  product?: string;
+  payment_method?: string;
+  address?: string;
+}
+
+export interface OrderEdit {
+  id: number;
+  order_id: number;
+  order_item_id?: number | null;
+  product_id?: number | null;
+  admin_id: number;
+  action: 'item_added' | 'item_removed' | 'quantity_changed' | 'price_adjusted' | 'address_changed';
+  old_value: string;
+  new_value: string;
+  reason: string;
+  old_total: number;
+  new_total: number;
+  created_at: string;
+}
+
+export interface ReportRange {
+  from?: string;
+  to?: string;
+  tz?: string;
+}
+
+export interface ReportPeriod {
+  from: string;
+  to: string;
+  tz: string;
+}
+
+export interface RevenueBucket {
+  period: string;
+  orders: number;
+  gross: number;
+  refunds: number;
+  net: number;
+}
+
+export interface RevenueReport extends ReportPeriod {
+  interval: 'day' | 'week' | 'month';
+  buckets: RevenueBucket[];
+  totals: RevenueBucket;
+}
+
+export interface OrdersByStatusReport extends ReportPeriod {
+  statuses: { status: Order['status']; orders: number; total: number }[];
+}
+
+export interface AverageOrderValueReport extends ReportPeriod {
+  orders: number;
+  revenue: number;
+  average_order_value: number;
+  items_per_order: number;
+}
+
+export interface SalesMargin {
+  revenue: number;
+  cost: number;
+  gross_margin: number;
+  margin_percent: number;
+  uncosted_quantity: number;
+}
+
+export interface TopProductsReport extends ReportPeriod {
+  products: ({ product_id: number; name: string; sku: string; quantity: number } & SalesMargin)[];
+}
+
+export interface TopCategoriesReport extends ReportPeriod {
+  categories: ({ category_id: number; name: string; quantity: number } & SalesMargin)[];
+}
+
+export interface OrderMarginsReport extends ReportPeriod, SalesMargin {
+  discount: number;
+  orders: ({ order_id: number; order_number: string; created_at: string; discount: number } & SalesMargin)[];
+}
+
+export interface ProductCost {
+  id: number;
+  product_id: number;
+  cost: number;
+  previous_cost: number | null;
+  source: 'manual' | 'purchase_order';
+  purchase_order_id?: number | null;
+  user_id?: number | null;
+  note: string;
+  created_at: string;
+  user?: User;
+}
+
+export interface CustomerSegment {
+  customers: number;
+  orders: number;
+  revenue: number;
+}
+
+export interface CustomersReport extends ReportPeriod {
+  new: CustomerSegment;
+  returning: CustomerSegment;
+  returning_rate: number;
+}
+
+export interface CartConversionReport extends ReportPeriod {
+  carts: number;
+  ordered: number;
+  open: number;
+  conversion_rate: number;
+  abandoned: number;
+  recovered: number;
+  recovery_rate: number;
+}
+
+export type StockMovementReason = 'sale' | 'cancellation' | 'restock' | 'adjustment' | 'return' | 'transfer';
+
+export interface StockMovement {
+  id: number;
+  product_id: number;
+  warehouse_id?: number | null;
+  quantity: number;
+  reason: StockMovementReason;
+  balance_after: number;
+  order_id?: number | null;
+  order_item_id?: number | null;
+  purchase_order_id?: number | null;
+  user_id?: number | null;
+  note: string;
+  created_at: string;
+  product?: Product;
+  warehouse?: Warehouse;
+  user?: User;
+}
+
+export interface StockMovementsResponse {
+  movements: StockMovement[];
+  total: number;
+  page: number;
+  limit: number;
+  total_pages: number;
+}
+
+export interface StockAdjustmentRequest {
+  product_id: number;
+  quantity: number;
+  reason?: 'adjustment' | 'restock';
+  note?: string;
+  warehouse_id?: number;
+}
+
+export type AllocationRule = 'priority' | 'closest' | 'split';
+
+export interface Warehouse {
+  id: number;
+  code: string;
+  name: string;
+  address: string;
+  service_areas: string;
+  priority: number;
+  active: boolean;
+  is_default: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface WarehouseSummary extends Warehouse {
+  units: number;
+  products: number;
+}
+
+export interface WarehouseRequest {
+  code: string;
+  name: string;
+  address?: string;
+  service_areas?: string;
+  priority?: number;
+  active?: boolean;
+  is_default?: boolean;
+}
+
+export interface WarehouseStock {
+  id: number;
+  warehouse_id: number;
+  product_id: number;
+  quantity: number;
+  updated_at: string;
+  warehouse?: Warehouse;
+  product?: Product;
+}
+
+export interface StockTransfer {
+  id: number;
+  product_id: number;
+  from_warehouse_id: number;
+  to_warehouse_id: number;
+  quantity: number;
+  user_id?: number | null;
+  note: string;
+  created_at: string;
+  product?: Product;
+  from_warehouse?: Warehouse;
+  to_warehouse?: Warehouse;
+}
+
+export interface StockTransferRequest {
+  product_id: number;
+  from_warehouse_id: number;
+  to_warehouse_id: number;
+  quantity: number;
+  note?: string;
+}
+
+export interface OrderItemAllocation {
+  id: number;
+  order_id: number;
+  order_item_id: number;
+  warehouse_id: number;
+  quantity: number;
+  warehouse?: Warehouse;
+}
+
+export interface LowStockProduct {
+  product_id: number;
+  sku: string;
+  name: string;
+  status: string;
+  category_id: number;
+  stock: number;
+  threshold: number;
+  shortfall: number;
+}
+
+export interface LowStockReport {
+  default_threshold: number;
+  products: LowStockProduct[];
+}
+
+export interface Supplier {
+  id: number;
+  name: string;
+  contact_name: string;
+  email: string;
+  phone: string;
+  address: string;
+  lead_time_days: number;
+  notes: string;
+  active: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface SupplierRequest {
+  name: string;
+  contact_name?: string;
+  email?: string;
+  phone?: string;
+  address?: string;
+  lead_time_days?: number;
+  notes?: string;
+  active?: boolean;
+}
+
+export interface SupplierProduct {
+  id: number;
+  supplier_id: number;
+  product_id: number;
+  supplier_sku: string;
+  cost: number;
+  min_order_quantity: number;
+  lead_time_days?: number | null;
+  preferred: boolean;
+  supplier?: Supplier;
+  product?: Product;
+}
+
+export interface SupplierProductRequest {
+  supplier_sku?: string;
+  cost: number;
+  min_order_quantity?: number;
+  lead_time_days?: number | null;
+  preferred?: boolean;
+}
+
+export type PurchaseOrderStatus = 'draft' | 'ordered' | 'partially_received' | 'received' | 'closed' | 'cancelled';
+
+export interface PurchaseOrderLine {
+  id: number;
+  purchase_order_id: number;
+  product_id: number;
+  supplier_sku: string;
+  quantity: number;
+  received_quantity: number;
+  unit_cost: number;
+  product?: Product;
+}
+
+export interface PurchaseOrder {
+  id: number;
+  number: string;
+  supplier_id: number;
+  warehouse_id: number;
+  status: PurchaseOrderStatus;
+  total: number;
+  notes: string;
+  expected_at?: string | null;
+  ordered_at?: string | null;
+  received_at?: string | null;
+  created_by_id: number;
+  created_at: string;
+  updated_at: string;
+  supplier?: Supplier;
+  warehouse?: Warehouse;
+  lines: PurchaseOrderLine[];
+}
+
+export interface PurchaseOrderRequest {
+  supplier_id: number;
+  warehouse_id?: number;
+  expected_at?: string;
+  notes?: string;
+  lines: { product_id: number; quantity: number; unit_cost?: number }[];
+}
+
+export interface ReceivePurchaseOrderRequest {
+  lines: { line_id: number; quantity: number }[];
+  note?: string;
+}
+
+export interface ReorderSuggestion {
+  product_id: number;
+  sku: string;
+  name: string;
+  stock: number;
+  on_order: number;
+  threshold: number;
+  sold_units: number;
+  daily_velocity: number;
+  days_of_cover: number | null;
+  supplier_id: number | null;
+  supplier_name: string;
+  supplier_sku: string;
+  lead_time_days: number;
+  unit_cost: number;
+  suggested_quantity: number;
+  estimated_cost: number;
+}
+
+export interface ReorderSuggestionsReport {
+  days: number;
+  cover_days: number;
+  suggestions: ReorderSuggestion[];
+}
+
 export interface ApiResponse<T> {
   success: boolean;
   data: T;
@@ -117,6 +582,7 @@ export interface ProductRequest {
   stock: number;
   image?: string;
   categoryId: string;
+  status?: 'draft' | 'active' | 'archived';
 }
 
 export interface CategoryRequest {
@@ -133,6 +599,39 @@ export interface CartItemRequest {
 export interface OrderRequest {
   shippingAddress: string;
   paymentMethod: string;
+  shipping_method?: string;
+  coupon_code?: string;
+  quote_id?: string;
+}
+
+export interface QuoteLine {
+  product_id: number;
+  product_name: string;
+  quantity: number;
+  unit_price: number;
+  line_total: number;
+}
+
+export interface CheckoutQuote {
+  id: string;
+  shipping_address: string;
+  shipping_method: string;
+  coupon_code: string;
+  lines: QuoteLine[];
+  subtotal: number;
+  discount: number;
+  shipping: number;
+  tax_rate: number;
+  tax: number;
+  total: number;
+  expires_at: string;
+  created_at: string;
+}
+
+export interface CheckoutQuoteRequest {
+  shipping_address: string;
+  shipping_method?: string;
+  coupon_code?: string;
 }
 
 export interface UpdateProfileRequest {